@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"indexer/internal/chains"
+	"indexer/internal/config"
+	"indexer/internal/db"
+	"indexer/internal/model"
+	"indexer/internal/repository"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// runBenchIngest implements the bench-ingest command: it writes the same synthetic blocks
+// through the per-block insert path and through COPY, each into a scratch chain of its
+// own, and compares the throughput. The scratch chains are dropped afterwards. Point it
+// at a database other than production: it competes with the indexer for I/O.
+func runBenchIngest(args []string) int {
+	flags := flag.NewFlagSet("bench-ingest", flag.ExitOnError)
+	family := flags.String("family", "evm", "chain family to simulate: evm or utxo")
+	blocks := flags.Int("blocks", 200, "blocks to write per path")
+	txs := flags.Int("txs", 200, "transactions per block")
+	batch := flags.Int("batch", 10, "blocks per DB transaction, as <NAME>_BATCH_SIZE")
+	keep := flags.Bool("keep", false, "keep the scratch tables for inspection")
+	flags.Parse(args)
+
+	if *family != "evm" && *family != "utxo" || *blocks < 1 || *txs < 1 || *batch < 1 {
+		flags.Usage()
+		return 2
+	}
+
+	cfg := config.LoadConfig()
+	database, err := db.Connect(cfg)
+	if err != nil {
+		log.Printf("[BENCH] Failed to connect to database: %v", err)
+		return 1
+	}
+	ctx := context.Background()
+
+	paths := []struct {
+		name string
+		opts repository.Options
+	}{
+		{"insert", repository.Options{CopyIngest: false}},
+		{"copy", repository.Options{CopyIngest: true}},
+	}
+
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = "bench_" + p.name
+	}
+	infos, err := createBenchChains(ctx, database, *family, names...)
+	if err != nil {
+		log.Printf("[BENCH] %v", err)
+		return 1
+	}
+	if !*keep {
+		defer dropBenchChains(ctx, database, infos)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "PATH\tBLOCKS\tTXS\tROWS\tTIME\tBLOCKS/S\tTXS/S\t")
+	for i, p := range paths {
+		repo := repository.NewRepository(database, p.opts)
+		info := infos[i]
+
+		var elapsed time.Duration
+		rows := 0
+		for start := 1; start <= *blocks; start += *batch {
+			var run []repository.BlockWithTransactions
+			for h := start; h < start+*batch && h <= *blocks; h++ {
+				b := benchBlock(info, uint64(h), *txs)
+				rows += benchRows(b)
+				run = append(run, b)
+			}
+			began := time.Now()
+			if err := repo.SaveBlocks(ctx, run); err != nil {
+				log.Printf("[BENCH] %s path failed at block %d: %v", p.name, start, err)
+				return 1
+			}
+			elapsed += time.Since(began)
+		}
+
+		secs := elapsed.Seconds()
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%.1f\t%.0f\t\n", p.name, *blocks, *blocks**txs, rows,
+			elapsed.Round(time.Millisecond), float64(*blocks)/secs, float64(*blocks**txs)/secs)
+	}
+	w.Flush()
+	return 0
+}
+
+// createBenchChains registers scratch chains of the family under the given names and
+// creates their tables, emptied first in case an earlier run was interrupted
+func createBenchChains(ctx context.Context, database *gorm.DB, family string, names ...string) ([]chains.Info, error) {
+	infos := make([]chains.Info, len(names))
+	for i, name := range names {
+		if family == "evm" {
+			infos[i] = chains.EVMNetwork(name, "", 0)
+		} else {
+			infos[i] = chains.UTXONetwork(name)
+		}
+		if err := chains.Register(infos[i]); err != nil {
+			return nil, fmt.Errorf("failed to register %s: %w", name, err)
+		}
+		if err := db.DropChain(ctx, database, infos[i]); err != nil {
+			return nil, fmt.Errorf("failed to clear %s: %w", name, err)
+		}
+	}
+	if _, err := db.MigrateUp(ctx, database); err != nil {
+		return nil, fmt.Errorf("failed to create the scratch tables: %w", err)
+	}
+	return infos, nil
+}
+
+func dropBenchChains(ctx context.Context, database *gorm.DB, infos []chains.Info) {
+	for _, info := range infos {
+		if err := db.DropChain(ctx, database, info); err != nil {
+			log.Printf("[BENCH] Failed to drop %s: %v", info.Name, err)
+		}
+	}
+}
+
+// benchBlock builds a block of n transactions shaped like the chain family's real ones.
+// UTXO transactions spend the outputs of the same transaction one block below.
+func benchBlock(info chains.Info, height uint64, n int) repository.BlockWithTransactions {
+	ts := time.Unix(1700000000+int64(height)*12, 0).UTC()
+	block := &model.Block{
+		Chain:     info.Type,
+		Height:    height,
+		Hash:      benchHash("block", height, 0),
+		BlockHash: benchHash("block", height-1, 0),
+		TXCount:   uint64(n),
+		Timestamp: ts,
+	}
+
+	txs := make([]*model.Transaction, n)
+	for i := range txs {
+		t := &model.Transaction{
+			Chain:     info.Type,
+			Hash:      benchHash("tx", height, i),
+			BlockHash: block.Hash,
+			Height:    height,
+			Status:    "success",
+			Timestamp: ts,
+		}
+		from, to := benchAddress(info, i), benchAddress(info, i+n)
+
+		if info.HasUTXO() {
+			t.From, t.To, t.Value, t.Fee = from, to, "99000", "1000"
+			for vout := range 2 {
+				t.Outputs = append(t.Outputs, model.TxOutput{
+					Txid: t.Hash, Vout: uint32(vout), Address: to, Value: 49500, ScriptType: "witness_v0_keyhash", Height: height,
+				})
+			}
+			for vin := range 2 {
+				t.Inputs = append(t.Inputs, model.TxInput{
+					Txid: t.Hash, Vin: uint32(vin), PrevTxid: benchHash("tx", height-1, i), PrevVout: uint32(vin),
+					Address: from, Value: 50000, ScriptType: "witness_v0_keyhash", Height: height,
+				})
+			}
+		} else {
+			contract := benchAddress(info, 2*n+i%10)
+			t.From, t.To, t.Value, t.Fee = from, contract, "0", "210000000000000"
+			t.GasUsed, t.GasPrice, t.TxType, t.Nonce = 52000, "30000000000", 2, height
+			t.TokenTransfers = []model.TokenTransfer{{
+				TxHash: t.Hash, LogIndex: uint(2 * i), Contract: contract, Standard: "erc20", From: from, To: to,
+				Amount: "1000000", Height: height, Timestamp: ts,
+			}}
+			for li := range 2 {
+				t.Logs = append(t.Logs, model.Log{
+					TxHash: t.Hash, LogIndex: uint(2*i + li), Address: contract,
+					Topic0: benchHash("topic", uint64(li), 0), Topic1: from, Topic2: to,
+					Data: "0x00000000000000000000000000000000000000000000000000000000000f4240", BlockHash: block.Hash,
+					Height: height, Timestamp: ts,
+				})
+			}
+		}
+		txs[i] = t
+	}
+	return repository.BlockWithTransactions{Block: block, Txs: txs}
+}
+
+// benchRows counts the rows a block writes, balances and address links aside
+func benchRows(b repository.BlockWithTransactions) int {
+	rows := 1 + len(b.Txs)
+	for _, t := range b.Txs {
+		rows += len(t.Inputs) + len(t.Outputs) + len(t.TokenTransfers) + len(t.Logs)
+	}
+	return rows
+}
+
+func benchHash(kind string, height uint64, i int) string {
+	return fmt.Sprintf("0x%x%016x%08x", kind, height, i)
+}
+
+func benchAddress(info chains.Info, i int) string {
+	if info.IsEVM() {
+		return fmt.Sprintf("0x%040x", i)
+	}
+	return fmt.Sprintf("bc1qbench%030d", i)
+}
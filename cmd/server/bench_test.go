@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"indexer/internal/repository"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// benchDSN names the variable holding the DSN of a scratch database the ingest
+// benchmarks may create and drop tables in, e.g.
+// "host=localhost user=indexer password=indexer dbname=indexer_test sslmode=disable"
+const benchDSN = "INDEXER_TEST_DSN"
+
+func benchDatabase(b *testing.B) *gorm.DB {
+	dsn := os.Getenv(benchDSN)
+	if dsn == "" {
+		b.Skipf("%s not set", benchDSN)
+	}
+	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		b.Fatalf("failed to connect: %v", err)
+	}
+	return database
+}
+
+// BenchmarkSaveBlocks writes batches of synthetic blocks through the per-block insert path
+// and through COPY, as the bench-ingest command does
+func BenchmarkSaveBlocks(b *testing.B) {
+	const (
+		batchSize   = 10
+		txsPerBlock = 200
+	)
+	database := benchDatabase(b)
+	ctx := context.Background()
+
+	for _, family := range []string{"evm", "utxo"} {
+		for _, path := range []string{"insert", "copy"} {
+			b.Run(family+"/"+path, func(b *testing.B) {
+				infos, err := createBenchChains(ctx, database, family, "bench_"+family+"_"+path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.Cleanup(func() { dropBenchChains(ctx, database, infos) })
+				repo := repository.NewRepository(database, repository.Options{CopyIngest: path == "copy"})
+
+				rows := 0
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					run := make([]repository.BlockWithTransactions, batchSize)
+					for j := range run {
+						run[j] = benchBlock(infos[0], uint64(i*batchSize+j+1), txsPerBlock)
+						rows += benchRows(run[j])
+					}
+					b.StartTimer()
+
+					if err := repo.SaveBlocks(ctx, run); err != nil {
+						b.Fatalf("batch %d: %v", i, err)
+					}
+				}
+				secs := b.Elapsed().Seconds()
+				b.ReportMetric(float64(b.N*batchSize*txsPerBlock)/secs, "txs/s")
+				b.ReportMetric(float64(rows)/secs, "rows/s")
+			})
+		}
+	}
+}
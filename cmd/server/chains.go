@@ -0,0 +1,107 @@
+package main
+
+import (
+	"indexer/internal/chains"
+	"indexer/internal/config"
+	"indexer/internal/model"
+	"indexer/internal/rpcpool"
+	"indexer/internal/workers"
+	"log"
+	"strings"
+	"time"
+)
+
+// registerChains makes every configured chain known to the repository and the API, even
+// when its node is not configured, so previously indexed data stays readable. With connect
+// set it also creates and registers the node adapters. It returns each chain's sync options.
+func registerChains(cfg *config.Config, connect bool) map[model.ChainType]workers.SyncOptions {
+	syncOptions := map[model.ChainType]workers.SyncOptions{}
+
+	for _, network := range cfg.UTXONetworks {
+		info := chains.UTXONetwork(network.Name)
+		if network.Symbol != "" {
+			info.Symbol = network.Symbol
+		}
+		if network.Decimals > 0 {
+			info.Decimals = network.Decimals
+		}
+		if network.Bech32HRP != "" {
+			info.Bech32HRP = network.Bech32HRP
+		}
+		info.TxPartitionSize = uint64(max(network.TxPartitionSize, 0))
+		if err := chains.Register(info); err != nil {
+			log.Fatalf("[MAIN] Failed to register chain %s: %v", info.Name, err)
+		}
+		syncOptions[info.Type] = workers.SyncOptions{
+			StartHeight:       network.StartHeight,
+			SyncIntervalMS:    network.SyncIntervalMS,
+			Concurrency:       network.Concurrency,
+			BatchSize:         network.BatchSize,
+			MempoolIntervalMS: network.MempoolMS,
+			VerifyIntervalMS:  network.VerifyMS,
+			VerifyRepair:      network.VerifyRepair,
+		}
+
+		if !connect {
+			continue
+		}
+		adapter, err := workers.NewBTCAdapter(info, workers.BTCOptions{
+			RPCURLs:          network.RPCURLs,
+			RPCUser:          network.RPCUser,
+			RPCPass:          network.RPCPass,
+			Pool:             poolOptions(network.RPCMaxLag, network.RPCHealthMS),
+			PrevoutCacheSize: network.PrevoutCache,
+			AddressField:     network.AddressField,
+			ZMQURL:           network.ZMQURL,
+			StrictPrevouts:   network.StrictPrevouts,
+		})
+		if err != nil {
+			log.Printf("[MAIN] %s adapter initialization warning: %v", strings.ToUpper(info.Name), err)
+			continue
+		}
+		if err := chains.RegisterAdapter(adapter); err != nil {
+			log.Fatalf("[MAIN] Failed to register %s adapter: %v", strings.ToUpper(info.Name), err)
+		}
+	}
+
+	for _, network := range cfg.EVMNetworks {
+		info := chains.EVMNetwork(network.Name, network.Symbol, network.ChainID)
+		if network.Name == chains.Ethereum.Name {
+			info = chains.Ethereum
+			info.ChainID = network.ChainID
+		}
+		info.TxPartitionSize = uint64(max(network.TxPartitionSize, 0))
+		if err := chains.Register(info); err != nil {
+			log.Fatalf("[MAIN] Failed to register chain %s: %v", info.Name, err)
+		}
+		syncOptions[info.Type] = workers.SyncOptions{
+			StartHeight:       network.StartHeight,
+			SyncIntervalMS:    network.SyncIntervalMS,
+			Concurrency:       network.Concurrency,
+			BatchSize:         network.BatchSize,
+			MempoolIntervalMS: network.MempoolMS,
+			VerifyIntervalMS:  network.VerifyMS,
+			VerifyRepair:      network.VerifyRepair,
+		}
+
+		if !connect {
+			continue
+		}
+		adapter, err := workers.NewETHAdapter(info, network.RPCURLs, poolOptions(network.RPCMaxLag, network.RPCHealthMS), network.LogAddresses, network.LogTopics)
+		if err != nil {
+			log.Printf("[MAIN] %s adapter initialization warning: %v", strings.ToUpper(info.Name), err)
+			continue
+		}
+		if err := chains.RegisterAdapter(adapter); err != nil {
+			log.Fatalf("[MAIN] Failed to register %s adapter: %v", strings.ToUpper(info.Name), err)
+		}
+	}
+	return syncOptions
+}
+
+func poolOptions(maxLag, healthMS int) rpcpool.Options {
+	return rpcpool.Options{
+		MaxLag:         uint64(maxLag),
+		HealthInterval: time.Duration(healthMS) * time.Millisecond,
+	}
+}
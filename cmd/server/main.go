@@ -2,16 +2,19 @@ package main
 
 import (
 	"context"
+	"indexer/internal/chains"
 	"indexer/internal/config"
 	"indexer/internal/db"
 	"indexer/internal/handlers"
 	"indexer/internal/repository"
 	"indexer/internal/routes"
+	"indexer/internal/supervisor"
 	"indexer/internal/workers"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -19,35 +22,55 @@ import (
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "bench-ingest":
+			os.Exit(runBenchIngest(os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "verify":
+			os.Exit(runVerify(os.Args[2:]))
+		default:
+			log.Fatalf("[MAIN] Unknown command %q, expected bench-ingest, migrate, verify or none to run the server", os.Args[1])
+		}
+	}
+	runServer()
+}
+
+func runServer() {
 	cfg := config.LoadConfig()
+
+	// 1. Registering Chains and their node adapters
+	syncOptions := registerChains(cfg, true)
+
 	database := db.InitDB(cfg)
-	repo := repository.NewRepository(database)
+	repo := repository.NewRepository(database, repoOptions(cfg))
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// 1. Initializing Workers
-	btcWorker := workers.NewBTCWorker(repo, cfg.BTCRPC, cfg.BTCKey, cfg.BTCStartHeight, cfg.BTCSyncIntervalMS)
-	ethWorker, err := workers.NewETHWorker(repo, cfg.ETHRPC, cfg.ETHStartHeight, cfg.ETHSyncIntervalMS)
-	if err != nil {
-		log.Printf("[MAIN] ETH Worker initialization warning: %v", err)
-	}
-
-	// 2. Starting Workers in Goroutines
-	if btcWorker != nil {
-		go btcWorker.Start(ctx)
-		log.Println("[MAIN] BTC sync worker spawned")
-	}
-	if ethWorker != nil {
-		go ethWorker.Start(ctx)
-		log.Println("[MAIN] ETH sync worker spawned")
+	// 2. Starting one supervised Worker per adapter, restarted when it fails
+	sup := supervisor.New()
+	var syncWorkers []*workers.Worker
+	for _, adapter := range chains.Adapters() {
+		info := adapter.Info()
+		w := workers.NewWorker(repo, adapter, syncOptions[info.Type])
+		sup.Go(ctx, info.Name, w.Run)
+		syncWorkers = append(syncWorkers, w)
+		log.Printf("[MAIN] %s sync worker spawned", strings.ToUpper(info.Name))
 	}
 
 	// 3. API Handlers Layer
-	apiHandler := handlers.NewAPIHandler(repo)
+	apiHandler := handlers.NewAPIHandler(repo, sup)
+	var adminHandler *handlers.AdminHandler
+	if cfg.AdminToken != "" {
+		adminHandler = handlers.NewAdminHandler(cfg.AdminToken, syncWorkers)
+	} else {
+		log.Println("[MAIN] ADMIN_TOKEN not set, admin API disabled")
+	}
 
 	// 4. Router Setup
-	r := routes.SetupRouter(apiHandler)
+	r := routes.SetupRouter(apiHandler, adminHandler)
 
 	server := &http.Server{
 		Addr:    ":" + cfg.ServerPort,
@@ -68,6 +91,7 @@ func main() {
 
 	log.Println("[MAIN] Shutting down gracefully...")
 	cancel() // Stop workers
+	sup.Wait()
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
@@ -77,3 +101,11 @@ func main() {
 
 	log.Println("[MAIN] Good bye!")
 }
+
+func repoOptions(cfg *config.Config) repository.Options {
+	return repository.Options{
+		CopyIngest:   cfg.DBCopyIngest,
+		QueryTimeout: time.Duration(cfg.DBQueryTimeoutMS) * time.Millisecond,
+		WriteTimeout: time.Duration(cfg.DBWriteTimeoutMS) * time.Millisecond,
+	}
+}
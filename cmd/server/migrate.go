@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"indexer/internal/config"
+	"indexer/internal/db"
+	"log"
+	"os"
+	"text/tabwriter"
+)
+
+// runMigrate implements the migrate command: up applies every pending migration, down
+// reverts the latest ones and status lists them per scope
+func runMigrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	steps := flags.Int("steps", 1, "number of migration versions to revert with down")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: migrate up|down|status [-steps n]")
+		flags.PrintDefaults()
+	}
+	if len(args) == 0 {
+		flags.Usage()
+		return 2
+	}
+	action := args[0]
+	flags.Parse(args[1:])
+
+	cfg := config.LoadConfig()
+	registerChains(cfg, false)
+
+	database, err := db.Connect(cfg)
+	if err != nil {
+		log.Printf("[DB] Failed to connect to database: %v", err)
+		return 1
+	}
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		n, err := db.MigrateUp(ctx, database)
+		if err != nil {
+			log.Printf("[DB] Migration failed: %v", err)
+			return 1
+		}
+		log.Printf("[DB] %d migrations applied", n)
+	case "down":
+		n, err := db.MigrateDown(ctx, database, *steps)
+		if err != nil {
+			log.Printf("[DB] Migration failed: %v", err)
+			return 1
+		}
+		log.Printf("[DB] %d migrations reverted", n)
+	case "status":
+		status, err := db.GetMigrationStatus(ctx, database)
+		if err != nil {
+			log.Printf("[DB] Failed to read migration status: %v", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SCOPE\tVERSION\tNAME\tAPPLIED")
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", s.Scope, s.Version, s.Name, applied)
+		}
+		w.Flush()
+	default:
+		flags.Usage()
+		return 2
+	}
+	return 0
+}
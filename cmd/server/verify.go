@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"indexer/internal/chains"
+	"indexer/internal/config"
+	"indexer/internal/db"
+	"indexer/internal/repository"
+	"indexer/internal/verify"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// runVerify implements the verify command: it checks the indexed blocks of one or every
+// chain and exits with 0 when they are consistent, 1 when problems were found and 2 when
+// the check itself failed.
+func runVerify(args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	chainName := flags.String("chain", "", "chain to check (default: all configured chains)")
+	from := flags.Uint64("from", 0, "first height to check (default: lowest indexed block)")
+	to := flags.Uint64("to", 0, "last height to check (default: last indexed block)")
+	repair := flags.Bool("repair", false, "delete inconsistent blocks and queue backfill jobs to re-fetch them and fill gaps")
+	asJSON := flags.Bool("json", false, "print the reports as JSON")
+	flags.Parse(args)
+
+	cfg := config.LoadConfig()
+	registerChains(cfg, false)
+
+	targets := chains.All()
+	if *chainName != "" {
+		info, ok := chains.Resolve(*chainName)
+		if !ok {
+			log.Printf("[VERIFY] Unknown chain %q", *chainName)
+			return 2
+		}
+		targets = []chains.Info{info}
+	}
+
+	repo := repository.NewRepository(db.InitDB(cfg), repoOptions(cfg))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	code := 0
+	var reports []*verify.Report
+	for _, info := range targets {
+		report, err := verify.Run(ctx, repo, info, verify.Options{From: *from, To: *to, Repair: *repair})
+		if err != nil {
+			log.Printf("[%s] Verification failed: %v", strings.ToUpper(info.Name), err)
+			code = 2
+			if report == nil {
+				continue
+			}
+		}
+		reports = append(reports, report)
+		if !report.OK() && code == 0 {
+			code = 1
+		}
+
+		if *asJSON {
+			continue
+		}
+		fmt.Printf("%s: %s\n", info.Name, report.Summary())
+		for _, r := range report.Missing {
+			fmt.Printf("  missing %d-%d\n", r.From, r.To)
+		}
+		for _, h := range report.BrokenLinks {
+			fmt.Printf("  block %d does not build on block %d\n", h, h-1)
+		}
+		for _, m := range report.TxCountMismatches {
+			fmt.Printf("  block %d has %d of %d transactions\n", m.Height, m.Actual, m.Expected)
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			log.Printf("[VERIFY] Failed to write report: %v", err)
+			return 2
+		}
+	}
+	return code
+}
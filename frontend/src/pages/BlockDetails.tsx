@@ -91,7 +91,7 @@ const BlockDetails = ({ chain }: { chain: 'btc' | 'eth' }) => {
                 <AddressDisplay address={tx.to} />
               </td>
               <td className="px-6 py-4 whitespace-nowrap">
-                <span className="font-bold text-sm">{tx.value} {chain === 'btc' ? 'BTC' : 'ETH'}</span>
+                <span className="font-bold text-sm">{tx.valueFormatted} {chain === 'btc' ? 'BTC' : 'ETH'}</span>
               </td>
             </tr>
           ))}
@@ -97,7 +97,7 @@ const SearchResults = () => {
                   <ArrowRightLeft className="w-3 h-3" />
                   <span className="text-[10px] font-bold uppercase">Value</span>
                 </div>
-                <p className="font-bold text-primary">{results.result.value} {results.chain === 'btc' ? 'BTC' : 'ETH'}</p>
+                <p className="font-bold text-primary">{results.result.valueFormatted} {results.chain === 'btc' ? 'BTC' : 'ETH'}</p>
               </div>
             </div>
 
@@ -37,7 +37,8 @@ export interface Transaction {
   hash: string;
   from: string;
   to: string;
-  value: string;
+  value: string; // base units (satoshis / wei)
+  valueFormatted: string;
   height: number;
   timestamp: number;
 }
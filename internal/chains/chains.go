@@ -0,0 +1,268 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/model"
+	"indexer/internal/rpcpool"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Family groups chains that share a data model and RPC dialect
+type Family string
+
+const (
+	FamilyUTXO Family = "utxo" // Bitcoin-like: inputs/outputs tables
+	FamilyEVM  Family = "evm"  // Ethereum-like: receipts, logs and token transfers
+)
+
+// Info describes a chain to the rest of the indexer: how the API addresses it and
+// which tables hold its data.
+type Info struct {
+	Type     model.ChainType // stored in chain columns and indexer_state
+	Name     string          // short name used in API paths and as the table prefix, e.g. "btc"
+	Aliases  []string        // extra names accepted in API paths
+	Family   Family
+	Symbol   string
+	Decimals int
+	ChainID  uint64 // EVM chain ID; 0 when unknown or not applicable
+
+	// Bech32HRP is the human-readable part of the chain's segwit addresses, e.g. "bc".
+	// Such addresses are case-insensitive and stored lower-case.
+	Bech32HRP string
+
+	// TxPartitionSize, when set, stores the chain's transactions in a table partitioned
+	// by block height ranges of this many blocks
+	TxPartitionSize uint64
+}
+
+var (
+	Bitcoin = Info{
+		Type:      model.ChainBTC,
+		Name:      "btc",
+		Aliases:   []string{"bitcoin"},
+		Family:    FamilyUTXO,
+		Symbol:    "BTC",
+		Decimals:  8,
+		Bech32HRP: "bc",
+	}
+	Ethereum = Info{
+		Type:     model.ChainETH,
+		Name:     "eth",
+		Aliases:  []string{"ethereum"},
+		Family:   FamilyEVM,
+		Symbol:   "ETH",
+		Decimals: 18,
+	}
+)
+
+// utxoNetworks are the Bitcoin-family networks we know the metadata of
+var utxoNetworks = map[string]Info{
+	"btc":         Bitcoin,
+	"btc_testnet": {Name: "btc_testnet", Aliases: []string{"testnet"}, Symbol: "tBTC", Decimals: 8, Bech32HRP: "tb"},
+	"btc_signet":  {Name: "btc_signet", Aliases: []string{"signet"}, Symbol: "sBTC", Decimals: 8, Bech32HRP: "tb"},
+	"btc_regtest": {Name: "btc_regtest", Aliases: []string{"regtest"}, Symbol: "rBTC", Decimals: 8, Bech32HRP: "bcrt"},
+	"ltc":         {Name: "ltc", Aliases: []string{"litecoin"}, Symbol: "LTC", Decimals: 8, Bech32HRP: "ltc"},
+	"ltc_testnet": {Name: "ltc_testnet", Symbol: "tLTC", Decimals: 8, Bech32HRP: "tltc"},
+	"doge":        {Name: "doge", Aliases: []string{"dogecoin"}, Symbol: "DOGE", Decimals: 8},
+}
+
+// UTXONetwork describes a Bitcoin-family chain such as Litecoin or a Bitcoin testnet,
+// addressed by its configured name. Well-known names come with their metadata filled in.
+func UTXONetwork(name string) Info {
+	info, ok := utxoNetworks[name]
+	if !ok {
+		info = Info{Name: name, Symbol: strings.ToUpper(name), Decimals: 8}
+	}
+	if info.Type == "" {
+		info.Type = model.ChainType(name)
+	}
+	info.Family = FamilyUTXO
+	return info
+}
+
+// EVMNetwork describes an additional EVM chain such as Polygon or a testnet, addressed
+// by its configured name
+func EVMNetwork(name, symbol string, chainID uint64) Info {
+	if symbol == "" {
+		symbol = strings.ToUpper(name)
+	}
+	return Info{
+		Type:     model.ChainType(name),
+		Name:     name,
+		Family:   FamilyEVM,
+		Symbol:   symbol,
+		Decimals: 18,
+		ChainID:  chainID,
+	}
+}
+
+func (i Info) BlockTable() string         { return i.Name + "_blocks" }
+func (i Info) TxTable() string            { return i.Name + "_transactions" }
+func (i Info) InputTable() string         { return i.Name + "_tx_inputs" }
+func (i Info) OutputTable() string        { return i.Name + "_tx_outputs" }
+func (i Info) TokenTransferTable() string { return i.Name + "_token_transfers" }
+func (i Info) LogTable() string           { return i.Name + "_logs" }
+
+// HasUTXO reports whether the chain keeps separate input/output tables
+func (i Info) HasUTXO() bool { return i.Family == FamilyUTXO }
+
+// IsEVM reports whether the chain keeps receipt-derived tables such as logs and token transfers
+func (i Info) IsEVM() bool { return i.Family == FamilyEVM }
+
+// Adapter is everything the generic worker needs to index a chain
+type Adapter interface {
+	Info() Info
+	GetTip(ctx context.Context) (uint64, error)
+	GetBlockHash(ctx context.Context, height uint64) (string, error)
+	FetchBlock(ctx context.Context, height uint64) (*model.Block, []*model.Transaction, error)
+}
+
+// HeadNotifier is implemented by adapters that can be told about new blocks by the node
+// instead of polling for them
+type HeadNotifier interface {
+	// SubscribeHeads returns a channel signalled whenever the node has a new block, or a
+	// nil channel when push notifications are not configured. The channel is closed when
+	// the subscription drops.
+	SubscribeHeads(ctx context.Context) (<-chan struct{}, error)
+}
+
+// MempoolAdapter is implemented by adapters that can list the node's unconfirmed transactions
+type MempoolAdapter interface {
+	// FetchMempool returns the hashes of every transaction currently pending on the node and
+	// the details of those not in known yet. Adapters may leave some new transactions out of
+	// fresh to bound the work of one call; they are picked up by the next one.
+	FetchMempool(ctx context.Context, known map[string]bool) (pending []string, fresh []model.PendingTransaction, err error)
+}
+
+// EndpointReporter is implemented by adapters spreading their calls over several RPC endpoints
+type EndpointReporter interface {
+	Endpoints() []rpcpool.EndpointStatus
+}
+
+// Names end up in table names, so keep them to plain identifiers
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// maxTypeLength is the width of the chain columns
+const maxTypeLength = 32
+
+var (
+	mu       sync.RWMutex
+	order    []model.ChainType
+	infos    = map[model.ChainType]Info{}
+	adapters = map[model.ChainType]Adapter{}
+)
+
+// Register makes a chain known to the repository and the API. Registering the same
+// chain twice with identical metadata is a no-op.
+func Register(info Info) error {
+	if !namePattern.MatchString(info.Name) {
+		return fmt.Errorf("invalid chain name %q", info.Name)
+	}
+	if info.Type == "" || len(info.Type) > maxTypeLength {
+		return fmt.Errorf("chain %q needs a type of 1-%d characters", info.Name, maxTypeLength)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if existing, ok := infos[info.Type]; ok {
+		if existing.Name != info.Name || existing.Family != info.Family {
+			return fmt.Errorf("chain %s already registered as %q", info.Type, existing.Name)
+		}
+		return nil
+	}
+	for _, other := range infos {
+		for _, name := range append([]string{info.Name, string(info.Type)}, info.Aliases...) {
+			if other.matches(name) {
+				return fmt.Errorf("chain name %q already used by %s", name, other.Type)
+			}
+		}
+	}
+
+	infos[info.Type] = info
+	order = append(order, info.Type)
+	return nil
+}
+
+// RegisterAdapter registers the adapter's chain and the adapter that indexes it
+func RegisterAdapter(adapter Adapter) error {
+	info := adapter.Info()
+	if err := Register(info); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := adapters[info.Type]; ok {
+		return fmt.Errorf("adapter for %s already registered", info.Type)
+	}
+	adapters[info.Type] = adapter
+	return nil
+}
+
+// Get returns the metadata of a registered chain
+func Get(chain model.ChainType) (Info, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	info, ok := infos[chain]
+	return info, ok
+}
+
+// GetAdapter returns the adapter indexing a registered chain
+func GetAdapter(chain model.ChainType) (Adapter, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	a, ok := adapters[chain]
+	return a, ok
+}
+
+// Resolve finds a chain by type, name or alias, ignoring case
+func Resolve(name string) (Info, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, chain := range order {
+		if info := infos[chain]; info.matches(name) {
+			return info, true
+		}
+	}
+	return Info{}, false
+}
+
+// All returns every registered chain in registration order
+func All() []Info {
+	mu.RLock()
+	defer mu.RUnlock()
+	res := make([]Info, 0, len(order))
+	for _, chain := range order {
+		res = append(res, infos[chain])
+	}
+	return res
+}
+
+// Adapters returns every registered adapter in registration order
+func Adapters() []Adapter {
+	mu.RLock()
+	defer mu.RUnlock()
+	var res []Adapter
+	for _, chain := range order {
+		if a, ok := adapters[chain]; ok {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+func (i Info) matches(name string) bool {
+	if strings.EqualFold(name, i.Name) || strings.EqualFold(name, string(i.Type)) {
+		return true
+	}
+	for _, alias := range i.Aliases {
+		if strings.EqualFold(name, alias) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package chains
+
+import (
+	"context"
+	"indexer/internal/model"
+	"strings"
+	"testing"
+)
+
+// resetRegistry gives the test an empty registry and restores the old one afterwards
+func resetRegistry(t *testing.T) {
+	t.Helper()
+	mu.Lock()
+	oldOrder, oldInfos, oldAdapters := order, infos, adapters
+	order, infos, adapters = nil, map[model.ChainType]Info{}, map[model.ChainType]Adapter{}
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		order, infos, adapters = oldOrder, oldInfos, oldAdapters
+		mu.Unlock()
+	})
+}
+
+func TestRegister(t *testing.T) {
+	polygon := EVMNetwork("polygon", "POL", 137)
+	tests := []struct {
+		name     string
+		existing []Info
+		info     Info
+		wantErr  string
+	}{
+		{name: "first chain", info: Bitcoin},
+		{name: "second chain", existing: []Info{Bitcoin}, info: Ethereum},
+		{name: "same chain again", existing: []Info{Bitcoin}, info: Bitcoin},
+		{name: "same chain with other aliases", existing: []Info{Bitcoin}, info: Info{Type: model.ChainBTC, Name: "btc", Family: FamilyUTXO, Aliases: []string{"xbt"}}},
+		{
+			name:     "type taken under another name",
+			existing: []Info{Bitcoin},
+			info:     Info{Type: model.ChainBTC, Name: "bitcoin_core", Family: FamilyUTXO},
+			wantErr:  `chain bitcoin already registered as "btc"`,
+		},
+		{
+			name:     "type taken by another family",
+			existing: []Info{Bitcoin},
+			info:     Info{Type: model.ChainBTC, Name: "btc", Family: FamilyEVM},
+			wantErr:  `chain bitcoin already registered as "btc"`,
+		},
+		{
+			name:     "name taken by another chain",
+			existing: []Info{Ethereum},
+			info:     Info{Type: "ethereum_classic", Name: "eth", Family: FamilyEVM},
+			wantErr:  `chain name "eth" already used by ethereum`,
+		},
+		{
+			name:     "alias taken by another chain",
+			existing: []Info{Bitcoin},
+			info:     Info{Type: "bitcoin_cash", Name: "bch", Family: FamilyUTXO, Aliases: []string{"bitcoin"}},
+			wantErr:  `chain name "bitcoin" already used by bitcoin`,
+		},
+		{
+			name:     "alias matching a type case-insensitively",
+			existing: []Info{Ethereum},
+			info:     Info{Type: "etc", Name: "etc", Family: FamilyEVM, Aliases: []string{"ETHEREUM"}},
+			wantErr:  `chain name "ETHEREUM" already used by ethereum`,
+		},
+		{
+			name:     "type matching another chain's alias",
+			existing: []Info{polygon, {Type: "matic_pos", Name: "matic_pos", Family: FamilyEVM, Aliases: []string{"matic"}}},
+			info:     EVMNetwork("matic", "", 0),
+			wantErr:  `chain name "matic" already used by matic_pos`,
+		},
+		{name: "invalid name", info: Info{Type: "x", Name: "Bad-Name"}, wantErr: `invalid chain name "Bad-Name"`},
+		{name: "name starting with a digit", info: Info{Type: "x", Name: "1chain"}, wantErr: `invalid chain name "1chain"`},
+		{name: "missing type", info: Info{Name: "nochain"}, wantErr: `chain "nochain" needs a type`},
+		{name: "type wider than the chain column", info: Info{Type: model.ChainType(strings.Repeat("x", 33)), Name: "wide"}, wantErr: `chain "wide" needs a type`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetRegistry(t)
+			for _, info := range tt.existing {
+				if err := Register(info); err != nil {
+					t.Fatalf("Register(%s) error = %v", info.Name, err)
+				}
+			}
+
+			err := Register(tt.info)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Register() error = %v", err)
+				}
+				if got, ok := Get(tt.info.Type); !ok || got.Name != tt.info.Name {
+					t.Errorf("Get(%s) = %+v, %v", tt.info.Type, got, ok)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Fatalf("Register() error = %v, want %q", err, tt.wantErr)
+			}
+			if len(All()) != len(tt.existing) {
+				t.Errorf("failed registration changed the registry: %v", All())
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	resetRegistry(t)
+	for _, info := range []Info{Bitcoin, Ethereum, UTXONetwork("ltc")} {
+		if err := Register(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name string
+		want model.ChainType
+		ok   bool
+	}{
+		{name: "btc", want: model.ChainBTC, ok: true},
+		{name: "bitcoin", want: model.ChainBTC, ok: true},
+		{name: "BTC", want: model.ChainBTC, ok: true},
+		{name: "ethereum", want: model.ChainETH, ok: true},
+		{name: "litecoin", want: "ltc", ok: true},
+		{name: "doge", ok: false},
+		{name: "", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := Resolve(tt.name)
+		if ok != tt.ok || got.Type != tt.want {
+			t.Errorf("Resolve(%q) = %s, %v, want %s, %v", tt.name, got.Type, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+type stubAdapter struct{ info Info }
+
+func (a stubAdapter) Info() Info                                                 { return a.info }
+func (a stubAdapter) GetTip(ctx context.Context) (uint64, error)                 { return 0, nil }
+func (a stubAdapter) GetBlockHash(ctx context.Context, h uint64) (string, error) { return "", nil }
+func (a stubAdapter) FetchBlock(ctx context.Context, h uint64) (*model.Block, []*model.Transaction, error) {
+	return nil, nil, nil
+}
+
+func TestRegisterAdapter(t *testing.T) {
+	resetRegistry(t)
+	if err := RegisterAdapter(stubAdapter{Bitcoin}); err != nil {
+		t.Fatalf("RegisterAdapter() error = %v", err)
+	}
+	if _, ok := GetAdapter(model.ChainBTC); !ok {
+		t.Error("adapter not registered")
+	}
+	if err := RegisterAdapter(stubAdapter{Bitcoin}); err == nil || !strings.Contains(err.Error(), "adapter for bitcoin already registered") {
+		t.Errorf("second RegisterAdapter() error = %v", err)
+	}
+	// A conflicting chain registers no adapter
+	conflict := Info{Type: "bitcoin_cash", Name: "bch", Family: FamilyUTXO, Aliases: []string{"btc"}}
+	if err := RegisterAdapter(stubAdapter{conflict}); err == nil {
+		t.Error("RegisterAdapter() of a conflicting chain succeeded")
+	}
+	if _, ok := GetAdapter(conflict.Type); ok {
+		t.Error("adapter of a conflicting chain registered")
+	}
+}
@@ -2,44 +2,169 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	DBHost            string
-	DBPort            string
-	DBUser            string
-	DBPass            string
-	DBName            string
-	BTCRPC            string
-	BTCKey            string
-	BTCStartHeight    int
-	BTCSyncIntervalMS int
-	ETHRPC            string
-	ETHStartHeight    int
-	ETHSyncIntervalMS int
-	ServerPort        string
+	DBHost           string
+	DBPort           string
+	DBUser           string
+	DBPass           string
+	DBName           string
+	DBAutoMigrate    bool // apply pending migrations on startup
+	DBCopyIngest     bool // write catch-up and backfill batches with COPY
+	DBQueryTimeoutMS int  // bound on each repository read; 0 disables it
+	DBWriteTimeoutMS int  // bound on block writes, rollbacks and verify scans; 0 disables it
+	UTXONetworks     []UTXONetwork
+	EVMNetworks      []EVMNetwork
+	ServerPort       string
+	AdminToken       string // enables the admin API when set
+}
+
+// UTXONetwork is one Bitcoin-family chain to index. Bitcoin itself is always the first
+// entry and reads the BTC_* variables; every name listed in UTXO_NETWORKS reads <NAME>_*
+// instead, e.g. LTC_RPC_URL.
+type UTXONetwork struct {
+	Name            string
+	Symbol          string   // overrides the built-in metadata when set
+	Decimals        int      // overrides the built-in metadata when set
+	Bech32HRP       string   // overrides the built-in metadata when set
+	AddressField    string   // "address", "addresses", or empty to use whichever the node fills
+	RPCURLs         []string // several URLs form a failover pool
+	RPCMaxLag       int      // blocks an endpoint may trail the others and still be used
+	RPCHealthMS     int      // endpoint health check interval
+	RPCUser         string
+	RPCPass         string
+	ZMQURL          string // hashblock publisher; empty polls only
+	StartHeight     int
+	SyncIntervalMS  int
+	Concurrency     int
+	BatchSize       int
+	PrevoutCache    int
+	StrictPrevouts  bool // retry blocks whose inputs cannot be resolved instead of storing them without a fee
+	MempoolMS       int  // mempool poll interval; 0 disables mempool tracking
+	TxPartitionSize int  // blocks per transactions table partition; 0 keeps one plain table
+	VerifyMS        int  // integrity check interval; 0 disables background checks
+	VerifyRepair    bool
+}
+
+// EVMNetwork is one EVM chain to index. Ethereum itself is always the first entry and
+// reads the ETH_* variables; every name listed in EVM_NETWORKS reads <NAME>_* instead,
+// e.g. POLYGON_RPC_URL.
+type EVMNetwork struct {
+	Name            string
+	ChainID         uint64 // expected chain ID; 0 accepts whatever the node reports
+	Symbol          string
+	RPCURLs         []string // several URLs form a failover pool
+	RPCMaxLag       int      // blocks an endpoint may trail the others and still be used
+	RPCHealthMS     int      // endpoint health check interval
+	StartHeight     int
+	SyncIntervalMS  int
+	Concurrency     int
+	BatchSize       int
+	MempoolMS       int // mempool poll interval; 0 disables mempool tracking
+	TxPartitionSize int // blocks per transactions table partition; 0 keeps one plain table
+	VerifyMS        int // integrity check interval; 0 disables background checks
+	VerifyRepair    bool
+	LogAddresses    []string
+	LogTopics       []string
 }
 
 func LoadConfig() *Config {
 	_ = godotenv.Load()
 
 	return &Config{
-		DBHost:            os.Getenv("DB_HOST"),
-		DBPort:            os.Getenv("DB_PORT"),
-		DBUser:            os.Getenv("DB_USER"),
-		DBPass:            os.Getenv("DB_PASSWORD"),
-		DBName:            os.Getenv("DB_NAME"),
-		BTCRPC:            os.Getenv("BTC_RPC_URL"),
-		BTCKey:            os.Getenv("BTC_RPC_PASS"),
-		BTCStartHeight:    getEnvInt("BTC_START_HEIGHT", 0),
-		BTCSyncIntervalMS: getEnvInt("BTC_SYNC_INTERVAL_MS", 2000),
-		ETHRPC:            os.Getenv("ETH_RPC_URL"),
-		ETHStartHeight:    getEnvInt("ETH_START_HEIGHT", 0),
-		ETHSyncIntervalMS: getEnvInt("ETH_SYNC_INTERVAL_MS", 2000),
-		ServerPort:        os.Getenv("PORT"),
+		DBHost:           os.Getenv("DB_HOST"),
+		DBPort:           os.Getenv("DB_PORT"),
+		DBUser:           os.Getenv("DB_USER"),
+		DBPass:           os.Getenv("DB_PASSWORD"),
+		DBName:           os.Getenv("DB_NAME"),
+		DBAutoMigrate:    getEnvBool("DB_AUTO_MIGRATE", true),
+		DBCopyIngest:     getEnvBool("DB_COPY_INGEST", true),
+		DBQueryTimeoutMS: getEnvInt("DB_QUERY_TIMEOUT_MS", 30000),
+		DBWriteTimeoutMS: getEnvInt("DB_WRITE_TIMEOUT_MS", 0),
+		UTXONetworks:     loadUTXONetworks(),
+		EVMNetworks:      loadEVMNetworks(),
+		ServerPort:       os.Getenv("PORT"),
+		AdminToken:       os.Getenv("ADMIN_TOKEN"),
+	}
+}
+
+func loadUTXONetworks() []UTXONetwork {
+	networks := []UTXONetwork{loadUTXONetwork("btc")}
+	for _, name := range getEnvList("UTXO_NETWORKS") {
+		name = strings.ToLower(name)
+		if name == "btc" {
+			continue
+		}
+		networks = append(networks, loadUTXONetwork(name))
+	}
+	return networks
+}
+
+func loadUTXONetwork(name string) UTXONetwork {
+	prefix := strings.ToUpper(name) + "_"
+	return UTXONetwork{
+		Name:            name,
+		Symbol:          os.Getenv(prefix + "SYMBOL"),
+		Decimals:        getEnvInt(prefix+"DECIMALS", 0),
+		Bech32HRP:       os.Getenv(prefix + "BECH32_HRP"),
+		AddressField:    os.Getenv(prefix + "ADDRESS_FIELD"),
+		RPCURLs:         getEnvList(prefix + "RPC_URL"),
+		RPCMaxLag:       getEnvInt(prefix+"RPC_MAX_LAG", 2),
+		RPCHealthMS:     getEnvInt(prefix+"RPC_HEALTH_INTERVAL_MS", 10000),
+		RPCUser:         os.Getenv(prefix + "RPC_USER"),
+		RPCPass:         os.Getenv(prefix + "RPC_PASS"),
+		ZMQURL:          os.Getenv(prefix + "ZMQ_URL"),
+		StartHeight:     getEnvInt(prefix+"START_HEIGHT", 0),
+		SyncIntervalMS:  getEnvInt(prefix+"SYNC_INTERVAL_MS", 2000),
+		Concurrency:     getEnvPositiveInt(prefix+"FETCH_CONCURRENCY", 4),
+		BatchSize:       getEnvPositiveInt(prefix+"BATCH_SIZE", 10),
+		PrevoutCache:    getEnvInt(prefix+"PREVOUT_CACHE_SIZE", 500000),
+		StrictPrevouts:  getEnvBool(prefix+"STRICT_PREVOUTS", false),
+		MempoolMS:       getEnvInt(prefix+"MEMPOOL_INTERVAL_MS", 0),
+		TxPartitionSize: getEnvInt(prefix+"TX_PARTITION_SIZE", 0),
+		VerifyMS:        getEnvInt(prefix+"VERIFY_INTERVAL_MS", 0),
+		VerifyRepair:    getEnvBool(prefix+"VERIFY_REPAIR", false),
+	}
+}
+
+func loadEVMNetworks() []EVMNetwork {
+	networks := []EVMNetwork{loadEVMNetwork("eth")}
+	for _, name := range getEnvList("EVM_NETWORKS") {
+		name = strings.ToLower(name)
+		if name == "eth" {
+			continue
+		}
+		networks = append(networks, loadEVMNetwork(name))
+	}
+	return networks
+}
+
+func loadEVMNetwork(name string) EVMNetwork {
+	prefix := strings.ToUpper(name) + "_"
+	return EVMNetwork{
+		Name:            name,
+		ChainID:         uint64(getEnvInt(prefix+"CHAIN_ID", 0)),
+		Symbol:          os.Getenv(prefix + "SYMBOL"),
+		RPCURLs:         getEnvList(prefix + "RPC_URL"),
+		RPCMaxLag:       getEnvInt(prefix+"RPC_MAX_LAG", 2),
+		RPCHealthMS:     getEnvInt(prefix+"RPC_HEALTH_INTERVAL_MS", 10000),
+		StartHeight:     getEnvInt(prefix+"START_HEIGHT", 0),
+		SyncIntervalMS:  getEnvInt(prefix+"SYNC_INTERVAL_MS", 2000),
+		Concurrency:     getEnvPositiveInt(prefix+"FETCH_CONCURRENCY", 4),
+		BatchSize:       getEnvPositiveInt(prefix+"BATCH_SIZE", 10),
+		MempoolMS:       getEnvInt(prefix+"MEMPOOL_INTERVAL_MS", 0),
+		TxPartitionSize: getEnvInt(prefix+"TX_PARTITION_SIZE", 0),
+		VerifyMS:        getEnvInt(prefix+"VERIFY_INTERVAL_MS", 0),
+		VerifyRepair:    getEnvBool(prefix+"VERIFY_REPAIR", false),
+		LogAddresses:    getEnvList(prefix + "LOG_ADDRESSES"),
+		LogTopics:       getEnvList(prefix + "LOG_TOPICS"),
 	}
 }
 
@@ -52,3 +177,36 @@ func getEnvInt(key string, fallback int) int {
 	fmt.Sscanf(val, "%d", &res)
 	return res
 }
+
+// getEnvPositiveInt is getEnvInt for settings that must be at least 1, such as fetch
+// concurrency and batch size. Anything else is a configuration error, not a silent zero.
+func getEnvPositiveInt(key string, fallback int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	res, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil || res < 1 {
+		log.Fatalf("[CONFIG] %s must be a positive integer, got %q", key, val)
+	}
+	return res
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	val, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return val
+}
+
+// getEnvList reads a comma-separated list, skipping empty entries
+func getEnvList(key string) []string {
+	var res []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
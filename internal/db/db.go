@@ -1,33 +1,54 @@
 package db
 
 import (
+	"context"
 	"fmt"
+	"indexer/internal/chains"
 	"indexer/internal/config"
-	"indexer/internal/model"
 	"log"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func InitDB(cfg *config.Config) *gorm.DB {
+// Connect opens the database without touching the schema
+func Connect(cfg *config.Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		cfg.DBHost, cfg.DBUser, cfg.DBPass, cfg.DBName, cfg.DBPort)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
+
+// InitDB connects and brings the schema up to date for the registered chains. With
+// DB_AUTO_MIGRATE=false it only checks that nothing is pending, leaving migrations and
+// partitioning to the migrate command.
+func InitDB(cfg *config.Config) *gorm.DB {
+	db, err := Connect(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	// Auto-migrate
-	err = db.AutoMigrate(
-		&model.BTCBlock{}, &model.BTCTransaction{},
-		&model.ETHBlock{}, &model.ETHTransaction{},
-		&model.IndexerState{},
-	)
-	if err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+	ctx := context.Background()
+	if !cfg.DBAutoMigrate {
+		status, err := GetMigrationStatus(ctx, db)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, s := range status {
+			if s.AppliedAt == nil {
+				log.Fatalf("Migration %d_%s is pending for %s, run the migrate up command first", s.Version, s.Name, s.Scope)
+			}
+		}
+		for _, info := range chains.All() {
+			if err := syncTxPartitioning(db, info, false); err != nil {
+				log.Fatalf("Failed to check %s tables: %v", info.Name, err)
+			}
+		}
+		return db
 	}
 
+	if _, err := MigrateUp(ctx, db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
 	return db
 }
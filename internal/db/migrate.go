@@ -0,0 +1,389 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"indexer/internal/chains"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"text/template"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migrations live in migrations/ as <version>_<name>.up.sql and .down.sql for the shared
+// tables, and <version>_<name>.chain.up.sql and .chain.down.sql for the tables every
+// registered chain gets its own copy of. Chain files are text/template documents executed
+// with the chain's chains.Info, so {{.BlockTable}} or {{if .IsEVM}} work in them.
+//
+// Applied migrations are recorded per scope in schema_migrations: "shared" for the shared
+// files and the chain name for chain files. A chain added to the config later therefore
+// gets every chain migration on the next run, in version order.
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// SharedScope is the schema_migrations scope of the shared tables
+const SharedScope = "shared"
+
+// migrationLock is the advisory lock key serialising migrations across instances
+const migrationLock int64 = 0x696e646578 // "index"
+
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+?)(\.chain)?\.(up|down)\.sql$`)
+
+type migration struct {
+	version   int64
+	name      string
+	up        string
+	down      string
+	chainUp   *template.Template
+	chainDown *template.Template
+}
+
+// MigrationStatus is one migration in one scope
+type MigrationStatus struct {
+	Scope     string
+	Version   int64
+	Name      string
+	AppliedAt *time.Time // nil while pending
+}
+
+// schemaMigration is a row of schema_migrations
+type schemaMigration struct {
+	Scope     string
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+func loadMigrations() ([]*migration, error) {
+	return readMigrations(migrationFiles)
+}
+
+// readMigrations parses the migrations/ directory of fsys into migrations ordered by version
+func readMigrations(fsys fs.FS) ([]*migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*migration{}
+	for _, e := range entries {
+		parts := migrationName.FindStringSubmatch(e.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", e.Name())
+		}
+		version, _ := strconv.ParseInt(parts[1], 10, 64)
+		m := byVersion[version]
+		if m == nil {
+			m = &migration{version: version, name: parts[2]}
+			byVersion[version] = m
+		}
+		if m.name != parts[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.name, parts[2])
+		}
+
+		body, err := fs.ReadFile(fsys, "migrations/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+		chain, up := parts[3] != "", parts[4] == "up"
+		switch {
+		case chain:
+			t, err := template.New(e.Name()).Option("missingkey=error").Parse(string(body))
+			if err != nil {
+				return nil, err
+			}
+			if up {
+				m.chainUp = t
+			} else {
+				m.chainDown = t
+			}
+		case up:
+			m.up = string(body)
+		default:
+			m.down = string(body)
+		}
+	}
+
+	migrations := make([]*migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if (m.up == "") != (m.down == "") || (m.chainUp == nil) != (m.chainDown == nil) {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.version, m.name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// has reports whether the migration has files for scope s
+func (m *migration) has(s scope) bool {
+	if s.info == nil {
+		return m.up != ""
+	}
+	return m.chainUp != nil
+}
+
+// sql returns the statements migrating a chain's tables, or the shared ones for a nil info
+func (m *migration) sql(info *chains.Info, up bool) (string, error) {
+	if info == nil {
+		if up {
+			return m.up, nil
+		}
+		return m.down, nil
+	}
+	t := m.chainDown
+	if up {
+		t = m.chainUp
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, info); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// scope is the shared scope (nil info) or one chain's
+type scope struct {
+	name string
+	info *chains.Info
+}
+
+func scopes() []scope {
+	res := []scope{{name: SharedScope}}
+	for _, info := range chains.All() {
+		res = append(res, scope{name: info.Name, info: &info})
+	}
+	return res
+}
+
+// withMigrationLock runs fn on a single connection holding the migration advisory lock,
+// so instances starting together migrate one after the other
+func withMigrationLock(ctx context.Context, db *gorm.DB, fn func(conn *gorm.DB, applied map[string]map[int64]bool) error) error {
+	return db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		var locked bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", migrationLock).Scan(&locked).Error; err != nil {
+			return fmt.Errorf("failed to take migration lock: %w", err)
+		}
+		if !locked {
+			log.Println("[DB] Waiting for another instance to finish migrating")
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLock).Error; err != nil {
+				return fmt.Errorf("failed to take migration lock: %w", err)
+			}
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLock)
+
+		if err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			scope      varchar(32) NOT NULL,
+			version    bigint NOT NULL,
+			name       text NOT NULL,
+			applied_at timestamptz NOT NULL DEFAULT now(),
+			PRIMARY KEY (scope, version)
+		)`).Error; err != nil {
+			return fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+
+		var rows []schemaMigration
+		if err := conn.Table("schema_migrations").Find(&rows).Error; err != nil {
+			return fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		applied := map[string]map[int64]bool{}
+		for _, row := range rows {
+			if applied[row.Scope] == nil {
+				applied[row.Scope] = map[int64]bool{}
+			}
+			applied[row.Scope][row.Version] = true
+		}
+		return fn(conn, applied)
+	})
+}
+
+// apply runs one migration for one scope in a transaction together with its bookkeeping
+func apply(conn *gorm.DB, m *migration, s scope, up bool) error {
+	stmt, err := m.sql(s.info, up)
+	if err != nil {
+		return fmt.Errorf("migration %d_%s (%s): %w", m.version, m.name, s.name, err)
+	}
+	err = conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+		if up {
+			return tx.Exec("INSERT INTO schema_migrations (scope, version, name) VALUES (?, ?, ?)", s.name, m.version, m.name).Error
+		}
+		return tx.Exec("DELETE FROM schema_migrations WHERE scope = ? AND version = ?", s.name, m.version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s (%s): %w", m.version, m.name, s.name, err)
+	}
+
+	direction := "Applied"
+	if !up {
+		direction = "Reverted"
+	}
+	log.Printf("[DB] %s migration %d_%s for %s", direction, m.version, m.name, s.name)
+	return nil
+}
+
+// MigrateUp applies every pending migration to the shared tables and to each registered
+// chain's tables, partitions the transactions tables configured to be, and returns how
+// many migrations it applied
+func MigrateUp(ctx context.Context, db *gorm.DB) (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = withMigrationLock(ctx, db, func(conn *gorm.DB, applied map[string]map[int64]bool) error {
+		for _, m := range migrations {
+			for _, s := range scopes() {
+				if applied[s.name][m.version] || !m.has(s) {
+					continue
+				}
+				if err := apply(conn, m, s, true); err != nil {
+					return err
+				}
+				count++
+			}
+		}
+
+		for _, s := range scopes()[1:] {
+			if err := syncTxPartitioning(conn, *s.info, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// MigrateDown reverts the latest `steps` migration versions. A version is reverted for every
+// registered chain first and then for the shared tables. Chains no longer in the config
+// keep their tables.
+func MigrateDown(ctx context.Context, db *gorm.DB, steps int) (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	known := make(map[int64]*migration, len(migrations))
+	for _, m := range migrations {
+		known[m.version] = m
+	}
+
+	count := 0
+	err = withMigrationLock(ctx, db, func(conn *gorm.DB, applied map[string]map[int64]bool) error {
+		// Chains before shared, the reverse of MigrateUp
+		all := scopes()
+		order := append(all[1:len(all):len(all)], all[0])
+
+		for ; steps > 0; steps-- {
+			var latest int64 = -1
+			for _, s := range order {
+				for v := range applied[s.name] {
+					latest = max(latest, v)
+				}
+			}
+			if latest < 0 {
+				return nil
+			}
+			m, ok := known[latest]
+			if !ok {
+				return fmt.Errorf("migration %d is not part of this build and cannot be reverted by it", latest)
+			}
+			for _, s := range order {
+				if !applied[s.name][latest] {
+					continue
+				}
+				if err := apply(conn, m, s, false); err != nil {
+					return err
+				}
+				delete(applied[s.name], latest)
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// GetMigrationStatus lists every migration for the shared scope and each registered chain,
+// applied or not, followed by applied versions this build does not know
+func GetMigrationStatus(ctx context.Context, db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []MigrationStatus
+	err = withMigrationLock(ctx, db, func(conn *gorm.DB, _ map[string]map[int64]bool) error {
+		var rows []schemaMigration
+		if err := conn.Table("schema_migrations").Order("scope, version").Find(&rows).Error; err != nil {
+			return err
+		}
+		type key struct {
+			scope   string
+			version int64
+		}
+		appliedAt := make(map[key]*time.Time, len(rows))
+		for i := range rows {
+			appliedAt[key{rows[i].Scope, rows[i].Version}] = &rows[i].AppliedAt
+		}
+
+		listed := map[key]bool{}
+		for _, s := range scopes() {
+			for _, m := range migrations {
+				if !m.has(s) {
+					continue
+				}
+				k := key{s.name, m.version}
+				listed[k] = true
+				res = append(res, MigrationStatus{Scope: s.name, Version: m.version, Name: m.name, AppliedAt: appliedAt[k]})
+			}
+		}
+		for _, row := range rows {
+			if !listed[key{row.Scope, row.Version}] {
+				res = append(res, MigrationStatus{Scope: row.Scope, Version: row.Version, Name: row.Name, AppliedAt: appliedAt[key{row.Scope, row.Version}]})
+			}
+		}
+		return nil
+	})
+	return res, err
+}
+
+// DropChain reverts every migration applied to the chain's own tables, dropping them, and
+// deletes the chain's rows from the shared tables
+func DropChain(ctx context.Context, db *gorm.DB, info chains.Info) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(ctx, db, func(conn *gorm.DB, applied map[string]map[int64]bool) error {
+		s := scope{name: info.Name, info: &info}
+		for i := len(migrations) - 1; i >= 0; i-- {
+			if !applied[s.name][migrations[i].version] {
+				continue
+			}
+			if err := apply(conn, migrations[i], s, false); err != nil {
+				return err
+			}
+		}
+
+		return conn.Transaction(func(tx *gorm.DB) error {
+			for _, table := range []string{"address_transactions", "balances", "balance_changes", "pending_transactions", "backfill_jobs", "indexer_states"} {
+				if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE chain = ?", table), info.Type).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
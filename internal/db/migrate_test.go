@@ -0,0 +1,159 @@
+package db
+
+import (
+	"indexer/internal/chains"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMigrationName(t *testing.T) {
+	tests := []struct {
+		file                         string
+		ok                           bool
+		version, name, chain, upDown string
+	}{
+		{file: "0001_initial.up.sql", ok: true, version: "0001", name: "initial", upDown: "up"},
+		{file: "0001_initial.down.sql", ok: true, version: "0001", name: "initial", upDown: "down"},
+		{file: "0002_numeric_amounts.chain.up.sql", ok: true, version: "0002", name: "numeric_amounts", chain: ".chain", upDown: "up"},
+		{file: "12_add_index.chain.down.sql", ok: true, version: "12", name: "add_index", chain: ".chain", upDown: "down"},
+		{file: "initial.up.sql"},
+		{file: "0001_initial.sql"},
+		{file: "0001_initial.sideways.sql"},
+		{file: "0001_.up.sql"},
+		{file: "0001_bad-name.up.sql"},
+		{file: "0001_initial.up.sql.bak"},
+	}
+	for _, tt := range tests {
+		parts := migrationName.FindStringSubmatch(tt.file)
+		if (parts != nil) != tt.ok {
+			t.Errorf("%s matched = %v, want %v", tt.file, parts != nil, tt.ok)
+			continue
+		}
+		if parts == nil {
+			continue
+		}
+		if parts[1] != tt.version || parts[2] != tt.name || parts[3] != tt.chain || parts[4] != tt.upDown {
+			t.Errorf("%s parsed as %q", tt.file, parts[1:])
+		}
+	}
+}
+
+func TestReadMigrations(t *testing.T) {
+	file := func(body string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(body)} }
+	tests := []struct {
+		name         string
+		files        fstest.MapFS
+		wantVersions []int64
+		wantErr      string
+	}{
+		{
+			name: "orders by version number, not file name",
+			files: fstest.MapFS{
+				"migrations/10_later.up.sql":           file("SELECT 10"),
+				"migrations/10_later.down.sql":         file("SELECT -10"),
+				"migrations/2_second.chain.up.sql":     file("SELECT 2"),
+				"migrations/2_second.chain.down.sql":   file("SELECT -2"),
+				"migrations/0001_first.up.sql":         file("SELECT 1"),
+				"migrations/0001_first.down.sql":       file("SELECT -1"),
+				"migrations/0001_first.chain.up.sql":   file("SELECT 1"),
+				"migrations/0001_first.chain.down.sql": file("SELECT -1"),
+			},
+			wantVersions: []int64{1, 2, 10},
+		},
+		{
+			name: "unexpected file",
+			files: fstest.MapFS{
+				"migrations/README.md": file(""),
+			},
+			wantErr: "unexpected migration file README.md",
+		},
+		{
+			name: "one version with two names",
+			files: fstest.MapFS{
+				"migrations/0001_first.up.sql":   file(""),
+				"migrations/0001_other.down.sql": file(""),
+			},
+			wantErr: "named both",
+		},
+		{
+			name: "up without down",
+			files: fstest.MapFS{
+				"migrations/0001_first.up.sql": file("SELECT 1"),
+			},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name: "chain down without up",
+			files: fstest.MapFS{
+				"migrations/0001_first.chain.down.sql": file("SELECT 1"),
+			},
+			wantErr: "needs both an up and a down file",
+		},
+		{
+			name: "broken chain template",
+			files: fstest.MapFS{
+				"migrations/0001_first.chain.up.sql":   file("CREATE TABLE {{.BlockTable"),
+				"migrations/0001_first.chain.down.sql": file("SELECT 1"),
+			},
+			wantErr: "unclosed action",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := readMigrations(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readMigrations() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readMigrations() error = %v", err)
+			}
+			var versions []int64
+			for _, m := range migrations {
+				versions = append(versions, m.version)
+			}
+			if len(versions) != len(tt.wantVersions) {
+				t.Fatalf("versions = %v, want %v", versions, tt.wantVersions)
+			}
+			for i := range versions {
+				if versions[i] != tt.wantVersions[i] {
+					t.Fatalf("versions = %v, want %v", versions, tt.wantVersions)
+				}
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrationsRender(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range migrations {
+		if i > 0 && m.version <= migrations[i-1].version {
+			t.Errorf("migration %d follows %d", m.version, migrations[i-1].version)
+		}
+		for _, info := range []chains.Info{chains.Bitcoin, chains.Ethereum} {
+			if !m.has(scope{name: info.Name, info: &info}) {
+				continue
+			}
+			for _, up := range []bool{true, false} {
+				sql, err := m.sql(&info, up)
+				if err != nil {
+					t.Errorf("migration %d_%s for %s (up %v): %v", m.version, m.name, info.Name, up, err)
+					continue
+				}
+				if strings.Contains(sql, "{{") {
+					t.Errorf("migration %d_%s for %s left template actions unrendered", m.version, m.name, info.Name)
+				}
+			}
+		}
+	}
+}
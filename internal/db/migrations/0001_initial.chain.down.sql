@@ -0,0 +1,6 @@
+{{if .IsEVM}}DROP TABLE IF EXISTS {{.LogTable}};
+DROP TABLE IF EXISTS {{.TokenTransferTable}};
+{{end}}{{if .HasUTXO}}DROP TABLE IF EXISTS {{.OutputTable}};
+DROP TABLE IF EXISTS {{.InputTable}};
+{{end}}DROP TABLE IF EXISTS {{.TxTable}};
+DROP TABLE IF EXISTS {{.BlockTable}};
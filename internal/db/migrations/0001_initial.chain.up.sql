@@ -0,0 +1,116 @@
+-- Every registered chain's own tables. Index names are prefixed with the table name since
+-- they are global in a Postgres schema.
+
+CREATE TABLE IF NOT EXISTS {{.BlockTable}} (
+    id          bigserial PRIMARY KEY,
+    chain       varchar(32),
+    height      bigint,
+    hash        text,
+    block_hash  text,
+    tx_count    bigint,
+    "timestamp" timestamptz,
+    created_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS {{.BlockTable}}_chain_height_idx ON {{.BlockTable}} (chain, height);
+CREATE INDEX IF NOT EXISTS {{.BlockTable}}_hash_idx ON {{.BlockTable}} (hash);
+CREATE INDEX IF NOT EXISTS {{.BlockTable}}_block_hash_idx ON {{.BlockTable}} (block_hash);
+
+CREATE TABLE IF NOT EXISTS {{.TxTable}} (
+    id               bigserial PRIMARY KEY,
+    chain            varchar(32),
+    hash             text,
+    block_hash       text,
+    block_height     bigint,
+    from_address     text,
+    to_address       text,
+    value            text,
+    status           text,
+    "timestamp"      timestamptz,
+    created_at       timestamptz,
+    fee              text,
+    gas_used         bigint,
+    gas_price        text,
+    tx_type          smallint,
+    nonce            bigint,
+    contract_address text
+);
+CREATE INDEX IF NOT EXISTS {{.TxTable}}_hash_idx ON {{.TxTable}} (hash);
+CREATE INDEX IF NOT EXISTS {{.TxTable}}_block_hash_idx ON {{.TxTable}} (block_hash);
+CREATE INDEX IF NOT EXISTS {{.TxTable}}_block_height_idx ON {{.TxTable}} (block_height);
+{{if .HasUTXO}}
+CREATE TABLE IF NOT EXISTS {{.InputTable}} (
+    id           bigserial PRIMARY KEY,
+    txid         text,
+    vin          bigint,
+    prev_txid    text,
+    prev_vout    bigint,
+    address      text,
+    value        bigint,
+    script_type  text,
+    coinbase     boolean,
+    block_height bigint,
+    created_at   timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS {{.InputTable}}_txid_vin_idx ON {{.InputTable}} (txid, vin);
+CREATE INDEX IF NOT EXISTS {{.InputTable}}_prevout_idx ON {{.InputTable}} (prev_txid, prev_vout);
+CREATE INDEX IF NOT EXISTS {{.InputTable}}_address_idx ON {{.InputTable}} (address);
+CREATE INDEX IF NOT EXISTS {{.InputTable}}_block_height_idx ON {{.InputTable}} (block_height);
+
+CREATE TABLE IF NOT EXISTS {{.OutputTable}} (
+    id            bigserial PRIMARY KEY,
+    txid          text,
+    vout          bigint,
+    address       text,
+    value         bigint,
+    script_type   text,
+    spent_by_txid text,
+    spent_by_vin  bigint,
+    block_height  bigint,
+    created_at    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS {{.OutputTable}}_txid_vout_idx ON {{.OutputTable}} (txid, vout);
+CREATE INDEX IF NOT EXISTS {{.OutputTable}}_address_idx ON {{.OutputTable}} (address);
+CREATE INDEX IF NOT EXISTS {{.OutputTable}}_block_height_idx ON {{.OutputTable}} (block_height);
+{{end}}{{if .IsEVM}}
+CREATE TABLE IF NOT EXISTS {{.TokenTransferTable}} (
+    id           bigserial PRIMARY KEY,
+    tx_hash      text,
+    log_index    bigint,
+    batch_index  bigint,
+    contract     text,
+    standard     varchar(10),
+    from_address text,
+    to_address   text,
+    amount       text,
+    token_id     text,
+    block_height bigint,
+    "timestamp"  timestamptz,
+    created_at   timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS {{.TokenTransferTable}}_log_idx ON {{.TokenTransferTable}} (tx_hash, log_index, batch_index);
+CREATE INDEX IF NOT EXISTS {{.TokenTransferTable}}_contract_idx ON {{.TokenTransferTable}} (contract);
+CREATE INDEX IF NOT EXISTS {{.TokenTransferTable}}_from_address_idx ON {{.TokenTransferTable}} (from_address);
+CREATE INDEX IF NOT EXISTS {{.TokenTransferTable}}_to_address_idx ON {{.TokenTransferTable}} (to_address);
+CREATE INDEX IF NOT EXISTS {{.TokenTransferTable}}_block_height_idx ON {{.TokenTransferTable}} (block_height);
+
+CREATE TABLE IF NOT EXISTS {{.LogTable}} (
+    id           bigserial PRIMARY KEY,
+    tx_hash      text,
+    log_index    bigint,
+    address      text,
+    topic0       text,
+    topic1       text,
+    topic2       text,
+    topic3       text,
+    data         text,
+    removed      boolean,
+    block_hash   text,
+    block_height bigint,
+    "timestamp"  timestamptz,
+    created_at   timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS {{.LogTable}}_tx_index_idx ON {{.LogTable}} (tx_hash, log_index);
+CREATE INDEX IF NOT EXISTS {{.LogTable}}_address_height_idx ON {{.LogTable}} (address, block_height);
+CREATE INDEX IF NOT EXISTS {{.LogTable}}_topic0_height_idx ON {{.LogTable}} (topic0, block_height);
+CREATE INDEX IF NOT EXISTS {{.LogTable}}_block_height_idx ON {{.LogTable}} (block_height);
+{{end}}
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS backfill_jobs;
+DROP TABLE IF EXISTS indexer_states;
+DROP TABLE IF EXISTS pending_transactions;
+DROP TABLE IF EXISTS balance_changes;
+DROP TABLE IF EXISTS balances;
+DROP TABLE IF EXISTS address_transactions;
@@ -0,0 +1,69 @@
+-- Tables shared by every chain, keyed by a chain column
+
+CREATE TABLE IF NOT EXISTS address_transactions (
+    id           bigserial PRIMARY KEY,
+    chain        varchar(32),
+    address      text,
+    tx_hash      text,
+    block_height bigint,
+    sent         boolean,
+    received     boolean,
+    "timestamp"  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_address_tx ON address_transactions (chain, address, tx_hash);
+CREATE INDEX IF NOT EXISTS idx_address_height ON address_transactions (chain, address, block_height);
+CREATE INDEX IF NOT EXISTS idx_address_transactions_block_height ON address_transactions (block_height);
+
+CREATE TABLE IF NOT EXISTS balances (
+    chain          varchar(32),
+    address        text,
+    balance        numeric(78,0) NOT NULL DEFAULT 0,
+    updated_height bigint,
+    updated_at     timestamptz,
+    PRIMARY KEY (chain, address)
+);
+CREATE INDEX IF NOT EXISTS idx_balance_rank ON balances (chain, balance DESC);
+
+CREATE TABLE IF NOT EXISTS balance_changes (
+    chain        varchar(32),
+    address      text,
+    block_height bigint,
+    delta        numeric(78,0) NOT NULL,
+    PRIMARY KEY (chain, address, block_height)
+);
+CREATE INDEX IF NOT EXISTS idx_balance_changes_block_height ON balance_changes (block_height);
+
+CREATE TABLE IF NOT EXISTS pending_transactions (
+    chain        varchar(32),
+    hash         text,
+    from_address text,
+    to_address   text,
+    value        text,
+    fee          text,
+    gas_price    text,
+    nonce        bigint,
+    first_seen   timestamptz,
+    PRIMARY KEY (chain, hash)
+);
+CREATE INDEX IF NOT EXISTS idx_pending_transactions_first_seen ON pending_transactions (first_seen);
+
+CREATE TABLE IF NOT EXISTS indexer_states (
+    chain              varchar(32) PRIMARY KEY,
+    last_indexed_block bigint,
+    updated_at         timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS backfill_jobs (
+    id          bigserial PRIMARY KEY,
+    chain       varchar(32) NOT NULL,
+    from_height bigint,
+    to_height   bigint,
+    next_height bigint,
+    status      varchar(16) NOT NULL,
+    last_error  text,
+    created_at  timestamptz,
+    updated_at  timestamptz,
+    finished_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_backfill_jobs_chain ON backfill_jobs (chain);
+CREATE INDEX IF NOT EXISTS idx_backfill_jobs_status ON backfill_jobs (status);
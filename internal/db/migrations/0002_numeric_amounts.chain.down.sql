@@ -0,0 +1,13 @@
+ALTER TABLE {{.TxTable}}
+{{- if .HasUTXO}}
+    ALTER COLUMN value TYPE text USING COALESCE(round(value / power(10::numeric, {{.Decimals}}), {{.Decimals}})::text, ''),
+    ALTER COLUMN fee TYPE text USING COALESCE(round(fee / power(10::numeric, {{.Decimals}}), {{.Decimals}})::text, ''),
+{{- else}}
+    ALTER COLUMN value TYPE text USING COALESCE(value::text, ''),
+    ALTER COLUMN fee TYPE text USING COALESCE(fee::text, ''),
+{{- end}}
+    ALTER COLUMN gas_price TYPE text USING COALESCE(gas_price::text, '');
+{{if .IsEVM}}
+ALTER TABLE {{.TokenTransferTable}}
+    ALTER COLUMN amount TYPE text USING COALESCE(amount::text, '');
+{{end}}
@@ -0,0 +1,17 @@
+-- Amounts become exact integers in base units (satoshis / wei). UTXO chains used to store
+-- transaction values and fees as decimal coin amounts, so those are scaled up. Unknown
+-- amounts, stored as empty strings so far, become NULL.
+
+ALTER TABLE {{.TxTable}}
+{{- if .HasUTXO}}
+    ALTER COLUMN value TYPE numeric(78,0) USING NULLIF(value, '')::numeric * power(10::numeric, {{.Decimals}}),
+    ALTER COLUMN fee TYPE numeric(78,0) USING NULLIF(fee, '')::numeric * power(10::numeric, {{.Decimals}}),
+{{- else}}
+    ALTER COLUMN value TYPE numeric(78,0) USING NULLIF(value, '')::numeric,
+    ALTER COLUMN fee TYPE numeric(78,0) USING NULLIF(fee, '')::numeric,
+{{- end}}
+    ALTER COLUMN gas_price TYPE numeric(78,0) USING NULLIF(gas_price, '')::numeric;
+{{if .IsEVM}}
+ALTER TABLE {{.TokenTransferTable}}
+    ALTER COLUMN amount TYPE numeric(78,0) USING NULLIF(amount, '')::numeric;
+{{end}}
@@ -0,0 +1,5 @@
+DELETE FROM pending_transactions;
+ALTER TABLE pending_transactions
+    ALTER COLUMN value TYPE text USING COALESCE(value::text, ''),
+    ALTER COLUMN fee TYPE text USING COALESCE(fee::text, ''),
+    ALTER COLUMN gas_price TYPE text USING COALESCE(gas_price::text, '');
@@ -0,0 +1,8 @@
+-- Pending transaction amounts become exact integers in base units, like the chain tables'.
+-- The rows carry no decimals to convert with; the mempool workers repopulate the table.
+
+DELETE FROM pending_transactions;
+ALTER TABLE pending_transactions
+    ALTER COLUMN value TYPE numeric(78,0) USING NULLIF(value, '')::numeric,
+    ALTER COLUMN fee TYPE numeric(78,0) USING NULLIF(fee, '')::numeric,
+    ALTER COLUMN gas_price TYPE numeric(78,0) USING NULLIF(gas_price, '')::numeric;
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"fmt"
+	"indexer/internal/chains"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Transactions tables can be range-partitioned by block height. The partitioned table
+// keeps the name, columns and indexes of the plain one, with the primary key widened to
+// (id, block_height) as Postgres requires the partition key in unique constraints.
+// Partitions are named <table>_p<first height> and created by the repository as blocks
+// arrive. The other per-chain tables stay plain: their unique keys do not contain the
+// height.
+
+// txIndexes are the indexes of a transactions table, see 0001_initial.chain.up.sql
+var txIndexes = []struct{ name, columns string }{
+	{"hash", "hash"},
+	{"block_hash", "block_hash"},
+	{"block_height", "block_height"},
+}
+
+func txTablePartitioned(conn *gorm.DB, table string) (bool, error) {
+	var partitioned bool
+	err := conn.Raw("SELECT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = to_regclass(?))", table).
+		Scan(&partitioned).Error
+	return partitioned, err
+}
+
+// syncTxPartitioning checks that the chain's transactions table is laid out as configured.
+// With convert set, a plain table is partitioned when TxPartitionSize asks for it;
+// turning partitioning off again is left to the operator, since it means copying every row.
+func syncTxPartitioning(conn *gorm.DB, info chains.Info, convert bool) error {
+	table := info.TxTable()
+	partitioned, err := txTablePartitioned(conn, table)
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", table, err)
+	}
+
+	switch {
+	case partitioned && info.TxPartitionSize == 0:
+		return fmt.Errorf("%s is partitioned, set %s_TX_PARTITION_SIZE to keep adding partitions", table, strings.ToUpper(info.Name))
+	case partitioned || info.TxPartitionSize == 0:
+		return nil
+	case !convert:
+		return fmt.Errorf("%s is not partitioned yet, run the migrate up command first", table)
+	}
+
+	log.Printf("[DB] Partitioning %s by %d blocks", table, info.TxPartitionSize)
+	return conn.Transaction(func(tx *gorm.DB) error {
+		return partitionTxTable(tx, table, info.TxPartitionSize)
+	})
+}
+
+// partitionTxTable swaps the plain table for a partitioned one. The rows already stored
+// are not copied: the old table becomes the partition <table>_p0, covering every height
+// up to the next multiple of size above the highest one it holds.
+func partitionTxTable(tx *gorm.DB, table string, size uint64) error {
+	for _, stmt := range partitionTxTableSQL(table) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+
+	var highest *uint64
+	if err := tx.Raw(fmt.Sprintf("SELECT MAX(block_height) FROM %s_p0", table)).Row().Scan(&highest); err != nil {
+		return err
+	}
+	for _, stmt := range attachTxP0SQL(table, highest, size) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// partitionTxTableSQL renames the plain table and its indexes to <table>_p0 and creates the
+// partitioned table in its place
+func partitionTxTableSQL(table string) []string {
+	old := table + "_p0"
+	stmts := []string{
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", table, old),
+		fmt.Sprintf("ALTER TABLE %s RENAME CONSTRAINT %s_pkey TO %s_pkey", old, table, old),
+	}
+	for _, idx := range txIndexes {
+		stmts = append(stmts, fmt.Sprintf("ALTER INDEX IF EXISTS %s_%s_idx RENAME TO %s_%s_idx", table, idx.name, old, idx.name))
+	}
+	stmts = append(stmts,
+		fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING DEFAULTS) PARTITION BY RANGE (block_height)", table, old),
+		fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (id, block_height)", table),
+		// The id sequence must outlive the old table once its partition is dropped
+		fmt.Sprintf("ALTER SEQUENCE IF EXISTS %s_id_seq OWNED BY %s.id", table, table),
+	)
+	for _, idx := range txIndexes {
+		stmts = append(stmts, fmt.Sprintf("CREATE INDEX %s_%s_idx ON %s (%s)", table, idx.name, table, idx.columns))
+	}
+	return stmts
+}
+
+// attachTxP0SQL attaches the renamed table as the partition of every height up to the next
+// multiple of size above highest, its highest stored height, or drops it when it is empty
+func attachTxP0SQL(table string, highest *uint64, size uint64) []string {
+	old := table + "_p0"
+	if highest == nil {
+		return []string{fmt.Sprintf("DROP TABLE %s", old)}
+	}
+	upper := (*highest/size + 1) * size
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN block_height SET NOT NULL", old),
+		fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM (0) TO (%d)", table, old, upper),
+	}
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPartitionTxTableSQL(t *testing.T) {
+	want := []string{
+		"ALTER TABLE btc_transactions RENAME TO btc_transactions_p0",
+		"ALTER TABLE btc_transactions_p0 RENAME CONSTRAINT btc_transactions_pkey TO btc_transactions_p0_pkey",
+		"ALTER INDEX IF EXISTS btc_transactions_hash_idx RENAME TO btc_transactions_p0_hash_idx",
+		"ALTER INDEX IF EXISTS btc_transactions_block_hash_idx RENAME TO btc_transactions_p0_block_hash_idx",
+		"ALTER INDEX IF EXISTS btc_transactions_block_height_idx RENAME TO btc_transactions_p0_block_height_idx",
+		"CREATE TABLE btc_transactions (LIKE btc_transactions_p0 INCLUDING DEFAULTS) PARTITION BY RANGE (block_height)",
+		"ALTER TABLE btc_transactions ADD PRIMARY KEY (id, block_height)",
+		"ALTER SEQUENCE IF EXISTS btc_transactions_id_seq OWNED BY btc_transactions.id",
+		"CREATE INDEX btc_transactions_hash_idx ON btc_transactions (hash)",
+		"CREATE INDEX btc_transactions_block_hash_idx ON btc_transactions (block_hash)",
+		"CREATE INDEX btc_transactions_block_height_idx ON btc_transactions (block_height)",
+	}
+	got := partitionTxTableSQL("btc_transactions")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("partitionTxTableSQL() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestAttachTxP0SQL(t *testing.T) {
+	height := func(h uint64) *uint64 { return &h }
+	tests := []struct {
+		name    string
+		highest *uint64
+		size    uint64
+		want    string
+	}{
+		{
+			name: "empty table is dropped",
+			size: 1000,
+			want: "[DROP TABLE eth_transactions_p0]",
+		},
+		{
+			name:    "genesis only",
+			highest: height(0),
+			size:    1000,
+			want:    "FROM (0) TO (1000)",
+		},
+		{
+			name:    "last height of a range",
+			highest: height(999),
+			size:    1000,
+			want:    "FROM (0) TO (1000)",
+		},
+		{
+			name:    "first height of a range",
+			highest: height(1000),
+			size:    1000,
+			want:    "FROM (0) TO (2000)",
+		},
+		{
+			name:    "mid range",
+			highest: height(18500123),
+			size:    100000,
+			want:    "FROM (0) TO (18600000)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts := attachTxP0SQL("eth_transactions", tt.highest, tt.size)
+			if tt.highest == nil {
+				if got := fmt.Sprint(stmts); got != tt.want {
+					t.Errorf("attachTxP0SQL() = %s, want %s", got, tt.want)
+				}
+				return
+			}
+			want := []string{
+				"ALTER TABLE eth_transactions_p0 ALTER COLUMN block_height SET NOT NULL",
+				"ALTER TABLE eth_transactions ATTACH PARTITION eth_transactions_p0 FOR VALUES " + tt.want,
+			}
+			if fmt.Sprint(stmts) != fmt.Sprint(want) {
+				t.Errorf("attachTxP0SQL() = %q, want %q", stmts, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"indexer/internal/chains"
+	"indexer/internal/model"
+	"indexer/internal/verify"
+	"indexer/internal/workers"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler serves the operator endpoints controlling the sync workers
+type AdminHandler struct {
+	token   string
+	workers map[model.ChainType]*workers.Worker
+}
+
+func NewAdminHandler(token string, ws []*workers.Worker) *AdminHandler {
+	byChain := make(map[model.ChainType]*workers.Worker, len(ws))
+	for _, w := range ws {
+		byChain[w.Info().Type] = w
+	}
+	return &AdminHandler{token: token, workers: byChain}
+}
+
+// RequireToken rejects requests without "Authorization: Bearer <ADMIN_TOKEN>"
+func (h *AdminHandler) RequireToken(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid admin token"})
+		return
+	}
+	c.Next()
+}
+
+// worker looks up the :chain path parameter, answering 404 for chains without a worker
+func (h *AdminHandler) worker(c *gin.Context) (chains.Info, *workers.Worker, bool) {
+	info, ok := chains.Resolve(c.Param("chain"))
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Unknown chain"})
+		return info, nil, false
+	}
+	w, ok := h.workers[info.Type]
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "No worker running for this chain"})
+		return info, nil, false
+	}
+	return info, w, true
+}
+
+// heightRequest is the body of the rewind and reindex endpoints
+type heightRequest struct {
+	Height *uint64 `json:"height" binding:"required"`
+}
+
+func (h *AdminHandler) PauseWorker(c *gin.Context) {
+	info, w, ok := h.worker(c)
+	if !ok {
+		return
+	}
+	if err := w.Pause(c.Request.Context()); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, AdminResponse{Chain: info.Name, Action: "pause", Paused: true})
+}
+
+func (h *AdminHandler) ResumeWorker(c *gin.Context) {
+	info, w, ok := h.worker(c)
+	if !ok {
+		return
+	}
+	if err := w.Resume(c.Request.Context()); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, AdminResponse{Chain: info.Name, Action: "resume", Paused: false})
+}
+
+// RewindWorker deletes everything above the given height; syncing resumes right after it
+func (h *AdminHandler) RewindWorker(c *gin.Context) {
+	info, w, ok := h.worker(c)
+	if !ok {
+		return
+	}
+	var req heightRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Body must be {\"height\": <number>}"})
+		return
+	}
+	if err := w.Rewind(c.Request.Context(), *req.Height); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, AdminResponse{Chain: info.Name, Action: "rewind", Height: *req.Height, Paused: w.Paused()})
+}
+
+// ReindexBlock fetches one indexed block from the node again and replaces the stored copy
+func (h *AdminHandler) ReindexBlock(c *gin.Context) {
+	info, w, ok := h.worker(c)
+	if !ok {
+		return
+	}
+	var req heightRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Body must be {\"height\": <number>}"})
+		return
+	}
+	if err := w.Reindex(c.Request.Context(), *req.Height); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, AdminResponse{Chain: info.Name, Action: "reindex", Height: *req.Height, Paused: w.Paused()})
+}
+
+// backfillRequest is the body of the backfill endpoint
+type backfillRequest struct {
+	From *uint64 `json:"from" binding:"required"`
+	To   *uint64 `json:"to" binding:"required"`
+}
+
+// StartBackfill queues a job indexing a past height range alongside live sync
+func (h *AdminHandler) StartBackfill(c *gin.Context) {
+	info, w, ok := h.worker(c)
+	if !ok {
+		return
+	}
+	var req backfillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Body must be {\"from\": <number>, \"to\": <number>}"})
+		return
+	}
+	job, err := w.StartBackfill(c.Request.Context(), *req.From, *req.To)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, ToBackfillJobDTO(info, *job))
+}
+
+func (h *AdminHandler) CancelBackfill(c *gin.Context) {
+	info, w, ok := h.worker(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid job ID"})
+		return
+	}
+	if err := w.CancelBackfill(c.Request.Context(), uint(id)); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, AdminResponse{Chain: info.Name, Action: "cancel-backfill", Paused: w.Paused()})
+}
+
+// verifyRequest is the optional body of the verify endpoint; zero heights check everything
+// from the lowest indexed block to the live cursor
+type verifyRequest struct {
+	From   uint64 `json:"from"`
+	To     uint64 `json:"to"`
+	Repair bool   `json:"repair"`
+}
+
+// RunVerify checks the chain's indexed blocks for gaps, broken hash links and incomplete
+// transactions, and answers with the report once done
+func (h *AdminHandler) RunVerify(c *gin.Context) {
+	_, w, ok := h.worker(c)
+	if !ok {
+		return
+	}
+	var req verifyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Body must be {\"from\": <number>, \"to\": <number>, \"repair\": <bool>}"})
+			return
+		}
+	}
+	report, err := w.Verify(c.Request.Context(), verify.Options{From: req.From, To: req.To, Repair: req.Repair})
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetVerifyReport returns the latest report, from the background check or RunVerify
+func (h *AdminHandler) GetVerifyReport(c *gin.Context) {
+	_, w, ok := h.worker(c)
+	if !ok {
+		return
+	}
+	report := w.LastVerifyReport()
+	if report == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "No verification has run yet"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func respondAdminError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, workers.ErrInvalidHeight):
+		status = http.StatusBadRequest
+	case errors.Is(err, workers.ErrHashMismatch):
+		status = http.StatusConflict
+	case errors.Is(err, workers.ErrJobNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, workers.ErrWorkerUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,241 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"indexer/internal/chains"
+	"indexer/internal/handlers"
+	"indexer/internal/model"
+	"indexer/internal/repository"
+	"indexer/internal/routes"
+	"indexer/internal/supervisor"
+	"indexer/internal/workers"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const adminToken = "s3cret"
+
+// controlRepo has blocks lowest..last stored and records rewinds and reindexes
+type controlRepo struct {
+	repository.Repository
+
+	mu        sync.Mutex
+	lowest    uint64
+	last      uint64
+	rewinds   []uint64
+	reindexed []uint64
+}
+
+func (r *controlRepo) GetOrCreateState(ctx context.Context, chain model.ChainType, latestBlock uint64, configuredStart int) (uint64, error) {
+	return r.GetState(ctx, chain)
+}
+
+func (r *controlRepo) GetState(ctx context.Context, chain model.ChainType) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last, nil
+}
+
+func (r *controlRepo) GetBlockHash(ctx context.Context, chain model.ChainType, height uint64) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if height < r.lowest || height > r.last {
+		return "", nil
+	}
+	return fmt.Sprintf("h%d", height), nil
+}
+
+func (r *controlRepo) RollbackToHeight(ctx context.Context, chain model.ChainType, height uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rewinds = append(r.rewinds, height)
+	r.last = height
+	return nil
+}
+
+func (r *controlRepo) ReplaceBlock(ctx context.Context, block *model.Block, txs []*model.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reindexed = append(r.reindexed, block.Height)
+	return nil
+}
+
+func (r *controlRepo) NextBackfillJob(ctx context.Context, chain model.ChainType) (*model.BackfillJob, error) {
+	return nil, nil
+}
+
+// controlAdapter is a node at the same tip as the repo. It has replaced the block at
+// height replaced.
+type controlAdapter struct {
+	tip      uint64
+	replaced uint64
+}
+
+func (a *controlAdapter) Info() chains.Info { return chains.Bitcoin }
+
+func (a *controlAdapter) GetTip(ctx context.Context) (uint64, error) { return a.tip, nil }
+
+func (a *controlAdapter) GetBlockHash(ctx context.Context, height uint64) (string, error) {
+	if height == a.replaced {
+		return fmt.Sprintf("x%d", height), nil
+	}
+	return fmt.Sprintf("h%d", height), nil
+}
+
+func (a *controlAdapter) FetchBlock(ctx context.Context, height uint64) (*model.Block, []*model.Transaction, error) {
+	hash, _ := a.GetBlockHash(ctx, height)
+	return &model.Block{Chain: model.ChainBTC, Height: height, Hash: hash}, nil, nil
+}
+
+// newAdminRouter runs a BTC worker with blocks 50-100 indexed behind the full router
+func newAdminRouter(t *testing.T) (*gin.Engine, *workers.Worker, *controlRepo) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	for _, info := range []chains.Info{chains.Bitcoin, chains.Ethereum} {
+		if err := chains.Register(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := &controlRepo{lowest: 50, last: 100}
+	w := workers.NewWorker(repo, &controlAdapter{tip: 100, replaced: 70}, workers.SyncOptions{SyncIntervalMS: 3600000})
+	ctx, cancel := context.WithCancel(context.Background())
+	s := supervisor.New()
+	s.Go(ctx, "btc", w.Run)
+	t.Cleanup(func() {
+		cancel()
+		s.Wait()
+	})
+
+	return routes.SetupRouter(nil, handlers.NewAdminHandler(adminToken, []*workers.Worker{w})), w, repo
+}
+
+func serve(router *gin.Engine, method, path, auth, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequireToken(t *testing.T) {
+	router, w, _ := newAdminRouter(t)
+	tests := []struct {
+		name string
+		auth string
+		want int
+	}{
+		{name: "missing header", auth: "", want: http.StatusUnauthorized},
+		{name: "wrong token", auth: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "token prefix", auth: "Bearer " + adminToken[:3], want: http.StatusUnauthorized},
+		{name: "token without scheme", auth: adminToken, want: http.StatusUnauthorized},
+		{name: "other scheme", auth: "Basic " + adminToken, want: http.StatusUnauthorized},
+		{name: "empty bearer", auth: "Bearer ", want: http.StatusUnauthorized},
+		{name: "valid token", auth: "Bearer " + adminToken, want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(router, http.MethodPost, "/api/admin/bitcoin/pause", tt.auth, "")
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusUnauthorized && w.Paused() {
+				t.Error("rejected request paused the worker")
+			}
+		})
+	}
+}
+
+func TestAdminHeightValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		body        string
+		want        int
+		wantRewinds []uint64
+		wantReindex []uint64
+	}{
+		{name: "rewind below the tip", path: "/api/admin/btc/rewind", body: `{"height": 90}`, want: http.StatusOK, wantRewinds: []uint64{90}},
+		{name: "rewind to the tip", path: "/api/admin/btc/rewind", body: `{"height": 100}`, want: http.StatusOK, wantRewinds: []uint64{100}},
+		{name: "rewind above the tip", path: "/api/admin/btc/rewind", body: `{"height": 101}`, want: http.StatusBadRequest},
+		{name: "rewind without a height", path: "/api/admin/btc/rewind", body: `{}`, want: http.StatusBadRequest},
+		{name: "rewind with a negative height", path: "/api/admin/btc/rewind", body: `{"height": -1}`, want: http.StatusBadRequest},
+		{name: "reindex a stored block", path: "/api/admin/btc/reindex", body: `{"height": 60}`, want: http.StatusOK, wantReindex: []uint64{60}},
+		{name: "reindex below the lowest block", path: "/api/admin/btc/reindex", body: `{"height": 49}`, want: http.StatusBadRequest},
+		{name: "reindex above the tip", path: "/api/admin/btc/reindex", body: `{"height": 101}`, want: http.StatusBadRequest},
+		{name: "reindex a block the node replaced", path: "/api/admin/btc/reindex", body: `{"height": 70}`, want: http.StatusConflict},
+		{name: "unknown chain", path: "/api/admin/doge/rewind", body: `{"height": 90}`, want: http.StatusNotFound},
+		{name: "chain without a worker", path: "/api/admin/eth/rewind", body: `{"height": 90}`, want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _, repo := newAdminRouter(t)
+			rec := serve(router, http.MethodPost, tt.path, "Bearer "+adminToken, tt.body)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			repo.mu.Lock()
+			defer repo.mu.Unlock()
+			if fmt.Sprint(repo.rewinds) != fmt.Sprint(tt.wantRewinds) {
+				t.Errorf("rewound to %v, want %v", repo.rewinds, tt.wantRewinds)
+			}
+			if fmt.Sprint(repo.reindexed) != fmt.Sprint(tt.wantReindex) {
+				t.Errorf("reindexed %v, want %v", repo.reindexed, tt.wantReindex)
+			}
+		})
+	}
+}
+
+func TestAdminPauseResume(t *testing.T) {
+	router, w, repo := newAdminRouter(t)
+	auth := "Bearer " + adminToken
+
+	paused := func(rec *httptest.ResponseRecorder) bool {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		var resp handlers.AdminResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Paused
+	}
+
+	if !paused(serve(router, http.MethodPost, "/api/admin/btc/pause", auth, "")) || !w.Paused() {
+		t.Fatal("pause did not pause the worker")
+	}
+	// A second pause is a no-op
+	if !paused(serve(router, http.MethodPost, "/api/admin/btc/pause", auth, "")) {
+		t.Fatal("second pause resumed the worker")
+	}
+	// Commands still run while paused, and leave the worker paused
+	if !paused(serve(router, http.MethodPost, "/api/admin/btc/rewind", auth, `{"height": 80}`)) {
+		t.Error("rewind while paused resumed the worker")
+	}
+	repo.mu.Lock()
+	rewinds := fmt.Sprint(repo.rewinds)
+	repo.mu.Unlock()
+	if rewinds != "[80]" {
+		t.Errorf("rewound to %s while paused, want [80]", rewinds)
+	}
+
+	if paused(serve(router, http.MethodPost, "/api/admin/btc/resume", auth, "")) || w.Paused() {
+		t.Fatal("resume did not resume the worker")
+	}
+	if paused(serve(router, http.MethodPost, "/api/admin/btc/rewind", auth, `{"height": 75}`)) {
+		t.Error("rewind after resume reports the worker paused")
+	}
+}
@@ -1,6 +1,12 @@
 package handlers
 
-import "indexer/internal/model"
+import (
+	"indexer/internal/chains"
+	"indexer/internal/model"
+	"indexer/internal/rpcpool"
+	"indexer/internal/supervisor"
+	"strconv"
+)
 
 type BlockResponse struct {
 	Height    uint64 `json:"height"`
@@ -9,13 +15,64 @@ type BlockResponse struct {
 	Timestamp int64  `json:"timestamp"`
 }
 
+// Amounts are in base units (satoshis / wei); the *Formatted fields spell them out in whole
+// coins using the chain's decimals.
 type TransactionResponse struct {
-	Hash      string `json:"hash"`
-	From      string `json:"from"`
-	To        string `json:"to"`
-	Value     string `json:"value"`
-	Height    uint64 `json:"height"`
-	Timestamp int64  `json:"timestamp"`
+	Hash            string `json:"hash"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	Value           string `json:"value"`
+	ValueFormatted  string `json:"valueFormatted"`
+	Height          uint64 `json:"height"`
+	Timestamp       int64  `json:"timestamp"`
+	Status          string `json:"status"`
+	Fee             string `json:"fee,omitempty"`
+	FeeFormatted    string `json:"feeFormatted,omitempty"`
+	GasUsed         uint64 `json:"gasUsed,omitempty"`
+	GasPrice        string `json:"gasPrice,omitempty"`
+	Type            uint8  `json:"type"`
+	Nonce           uint64 `json:"nonce"`
+	ContractAddress string `json:"contractAddress,omitempty"`
+}
+
+type TransactionDetailsResponse struct {
+	TransactionResponse
+	Chain          string                  `json:"chain"`
+	BlockHash      string                  `json:"blockHash"`
+	Confirmations  uint64                  `json:"confirmations"`
+	FirstSeen      int64                   `json:"firstSeen,omitempty"` // pending transactions only
+	Inputs         []TxInputResponse       `json:"inputs,omitempty"`
+	Outputs        []TxOutputResponse      `json:"outputs,omitempty"`
+	Logs           []LogResponse           `json:"logs,omitempty"`
+	TokenTransfers []TokenTransferResponse `json:"tokenTransfers,omitempty"`
+}
+
+type PaginatedTransactionsResponse struct {
+	Page         int                   `json:"page"`
+	Limit        int                   `json:"limit"`
+	Transactions []TransactionResponse `json:"transactions"`
+}
+
+type PendingTransactionResponse struct {
+	Hash           string `json:"hash"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	Value          string `json:"value"`
+	ValueFormatted string `json:"valueFormatted"`
+	Fee            string `json:"fee,omitempty"`
+	FeeFormatted   string `json:"feeFormatted,omitempty"`
+	GasPrice       string `json:"gasPrice,omitempty"`
+	Nonce          uint64 `json:"nonce"`
+	FirstSeen      int64  `json:"firstSeen"`
+	Status         string `json:"status"`
+}
+
+type MempoolResponse struct {
+	Chain        string                       `json:"chain"`
+	Page         int                          `json:"page"`
+	Limit        int                          `json:"limit"`
+	Total        int64                        `json:"total"`
+	Transactions []PendingTransactionResponse `json:"transactions"`
 }
 
 type PaginatedBlocksResponse struct {
@@ -39,11 +96,21 @@ type SearchResult struct {
 	Result interface{} `json:"result"`
 }
 
-type StatsResponse struct {
-	BTC ChainStats `json:"btc"`
-	ETH ChainStats `json:"eth"`
+type ChainResponse struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Family   string   `json:"family"`
+	Symbol   string   `json:"symbol"`
+	Decimals int      `json:"decimals"`
+	ChainID  uint64   `json:"chainId,omitempty"`
+
+	Bech32HRP string `json:"bech32Hrp,omitempty"`
 }
 
+// StatsResponse is keyed by chain name, e.g. "btc"
+type StatsResponse map[string]ChainStats
+
 type ChainStats struct {
 	LatestBlock uint64 `json:"latestBlock"`
 	TotalBlocks int64  `json:"totalBlocks"`
@@ -51,10 +118,159 @@ type ChainStats struct {
 	Synced      bool   `json:"synced"`
 }
 
+type TxInputResponse struct {
+	Vin            uint32 `json:"vin"`
+	PrevTxid       string `json:"prevTxid,omitempty"`
+	PrevVout       uint32 `json:"prevVout"`
+	Address        string `json:"address"`
+	Value          int64  `json:"value"`
+	ValueFormatted string `json:"valueFormatted"`
+	ScriptType     string `json:"scriptType"`
+	Coinbase       bool   `json:"coinbase"`
+}
+
+type TxOutputResponse struct {
+	Vout           uint32  `json:"vout"`
+	Address        string  `json:"address"`
+	Value          int64   `json:"value"`
+	ValueFormatted string  `json:"valueFormatted"`
+	ScriptType     string  `json:"scriptType"`
+	Spent          bool    `json:"spent"`
+	SpentByTxid    string  `json:"spentByTxid,omitempty"`
+	SpentByVin     *uint32 `json:"spentByVin,omitempty"`
+}
+
+type TxInputsResponse struct {
+	Hash   string            `json:"hash"`
+	Inputs []TxInputResponse `json:"inputs"`
+}
+
+type TxOutputsResponse struct {
+	Hash    string             `json:"hash"`
+	Outputs []TxOutputResponse `json:"outputs"`
+}
+
+type TokenTransferResponse struct {
+	TxHash     string `json:"txHash"`
+	LogIndex   uint   `json:"logIndex"`
+	BatchIndex uint   `json:"batchIndex"`
+	Contract   string `json:"contract"`
+	Standard   string `json:"standard"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Amount     string `json:"amount"`
+	TokenID    string `json:"tokenId,omitempty"`
+	Height     uint64 `json:"height"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+type PaginatedTokenTransfersResponse struct {
+	Page      int                     `json:"page"`
+	Limit     int                     `json:"limit"`
+	Transfers []TokenTransferResponse `json:"transfers"`
+}
+
+type LogResponse struct {
+	Address   string   `json:"address"`
+	Topics    []string `json:"topics"`
+	Data      string   `json:"data"`
+	Height    uint64   `json:"blockNumber"`
+	BlockHash string   `json:"blockHash"`
+	TxHash    string   `json:"transactionHash"`
+	LogIndex  uint     `json:"logIndex"`
+	Removed   bool     `json:"removed"`
+	Timestamp int64    `json:"timestamp"`
+}
+
+type PaginatedLogsResponse struct {
+	Page  int           `json:"page"`
+	Limit int           `json:"limit"`
+	Logs  []LogResponse `json:"logs"`
+}
+
+type AddressResponse struct {
+	Address          string                `json:"address"`
+	Chain            string                `json:"chain"`
+	Balance          string                `json:"balance"`
+	BalanceFormatted string                `json:"balanceFormatted"`
+	TxCount          int64                 `json:"txCount"`
+	FirstSeenHeight  uint64                `json:"firstSeenHeight"`
+	FirstSeen        int64                 `json:"firstSeen"`
+	LastSeenHeight   uint64                `json:"lastSeenHeight"`
+	LastSeen         int64                 `json:"lastSeen"`
+	Page             int                   `json:"page"`
+	Limit            int                   `json:"limit"`
+	Transactions     []TransactionResponse `json:"transactions"`
+}
+
+type RichListEntry struct {
+	Rank             int    `json:"rank"`
+	Address          string `json:"address"`
+	Balance          string `json:"balance"`
+	BalanceFormatted string `json:"balanceFormatted"`
+	UpdatedHeight    uint64 `json:"updatedHeight"`
+}
+
+type RichListResponse struct {
+	Chain   string          `json:"chain"`
+	Holders []RichListEntry `json:"holders"`
+}
+
+// WorkerStatusResponse is the lifecycle state of one chain's sync worker
+type WorkerStatusResponse struct {
+	Chain       string                   `json:"chain"`
+	State       string                   `json:"state"`
+	Height      uint64                   `json:"height"`
+	Tip         uint64                   `json:"tip"`
+	LastError   string                   `json:"lastError,omitempty"`
+	LastErrorAt int64                    `json:"lastErrorAt,omitempty"`
+	Restarts    int                      `json:"restarts"`
+	Since       int64                    `json:"since"`
+	UpdatedAt   int64                    `json:"updatedAt"`
+	Endpoints   []rpcpool.EndpointStatus `json:"endpoints,omitempty"`
+}
+
+// BackfillJobResponse is a backfill job and how far it has got
+type BackfillJobResponse struct {
+	ID         uint    `json:"id"`
+	Chain      string  `json:"chain"`
+	FromHeight uint64  `json:"fromHeight"`
+	ToHeight   uint64  `json:"toHeight"`
+	NextHeight uint64  `json:"nextHeight"`
+	Status     string  `json:"status"`
+	Progress   float64 `json:"progress"` // percent of the range behind the cursor
+	LastError  string  `json:"lastError,omitempty"`
+	CreatedAt  int64   `json:"createdAt"`
+	UpdatedAt  int64   `json:"updatedAt"`
+	FinishedAt int64   `json:"finishedAt,omitempty"`
+}
+
+// AdminResponse acknowledges an operator command once the worker has carried it out
+type AdminResponse struct {
+	Chain  string `json:"chain"`
+	Action string `json:"action"`
+	Height uint64 `json:"height,omitempty"`
+	Paused bool   `json:"paused"`
+}
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+func ToChainDTO(info chains.Info) ChainResponse {
+	return ChainResponse{
+		Name:     info.Name,
+		Type:     string(info.Type),
+		Aliases:  info.Aliases,
+		Family:   string(info.Family),
+		Symbol:   info.Symbol,
+		Decimals: info.Decimals,
+		ChainID:  info.ChainID,
+
+		Bech32HRP: info.Bech32HRP,
+	}
+}
+
 func ToBlockDTO(b model.Block, txCount int) BlockResponse {
 	return BlockResponse{
 		Height:    b.Height,
@@ -64,13 +280,148 @@ func ToBlockDTO(b model.Block, txCount int) BlockResponse {
 	}
 }
 
-func ToTransactionDTO(t model.Transaction) TransactionResponse {
+func ToTransactionDTO(info chains.Info, t model.Transaction) TransactionResponse {
 	return TransactionResponse{
-		Hash:      t.Hash,
-		From:      t.From,
-		To:        t.To,
-		Value:     t.Value,
-		Height:    t.Height,
-		Timestamp: t.Timestamp.Unix(),
+		Hash:            t.Hash,
+		From:            t.From,
+		To:              t.To,
+		Value:           string(t.Value),
+		ValueFormatted:  t.Value.Format(info.Decimals),
+		Height:          t.Height,
+		Timestamp:       t.Timestamp.Unix(),
+		Status:          t.Status,
+		Fee:             string(t.Fee),
+		FeeFormatted:    t.Fee.Format(info.Decimals),
+		GasUsed:         t.GasUsed,
+		GasPrice:        string(t.GasPrice),
+		Type:            t.TxType,
+		Nonce:           t.Nonce,
+		ContractAddress: t.ContractAddress,
+	}
+}
+
+func ToTxInputDTO(info chains.Info, in model.TxInput) TxInputResponse {
+	return TxInputResponse{
+		Vin:            in.Vin,
+		PrevTxid:       in.PrevTxid,
+		PrevVout:       in.PrevVout,
+		Address:        in.Address,
+		Value:          in.Value,
+		ValueFormatted: satoshis(in.Value).Format(info.Decimals),
+		ScriptType:     in.ScriptType,
+		Coinbase:       in.Coinbase,
+	}
+}
+
+func ToTxOutputDTO(info chains.Info, out model.TxOutput) TxOutputResponse {
+	return TxOutputResponse{
+		Vout:           out.Vout,
+		Address:        out.Address,
+		Value:          out.Value,
+		ValueFormatted: satoshis(out.Value).Format(info.Decimals),
+		ScriptType:     out.ScriptType,
+		Spent:          out.SpentByTxid != "",
+		SpentByTxid:    out.SpentByTxid,
+		SpentByVin:     out.SpentByVin,
+	}
+}
+
+func ToTokenTransferDTO(t model.TokenTransfer) TokenTransferResponse {
+	return TokenTransferResponse{
+		TxHash:     t.TxHash,
+		LogIndex:   t.LogIndex,
+		BatchIndex: t.BatchIndex,
+		Contract:   t.Contract,
+		Standard:   t.Standard,
+		From:       t.From,
+		To:         t.To,
+		Amount:     string(t.Amount),
+		TokenID:    t.TokenID,
+		Height:     t.Height,
+		Timestamp:  t.Timestamp.Unix(),
+	}
+}
+
+// satoshis wraps an input or output value for formatting
+func satoshis(v int64) model.Amount {
+	return model.Amount(strconv.FormatInt(v, 10))
+}
+
+func ToLogDTO(l model.Log) LogResponse {
+	topics := make([]string, 0, 4)
+	for _, t := range []string{l.Topic0, l.Topic1, l.Topic2, l.Topic3} {
+		if t == "" {
+			break
+		}
+		topics = append(topics, t)
+	}
+	return LogResponse{
+		Address:   l.Address,
+		Topics:    topics,
+		Data:      l.Data,
+		Height:    l.Height,
+		BlockHash: l.BlockHash,
+		TxHash:    l.TxHash,
+		LogIndex:  l.LogIndex,
+		Removed:   l.Removed,
+		Timestamp: l.Timestamp.Unix(),
+	}
+}
+
+func ToPendingTransactionDTO(info chains.Info, t model.PendingTransaction) PendingTransactionResponse {
+	return PendingTransactionResponse{
+		Hash:           t.Hash,
+		From:           t.From,
+		To:             t.To,
+		Value:          string(t.Value),
+		ValueFormatted: t.Value.Format(info.Decimals),
+		Fee:            string(t.Fee),
+		FeeFormatted:   t.Fee.Format(info.Decimals),
+		GasPrice:       string(t.GasPrice),
+		Nonce:          t.Nonce,
+		FirstSeen:      t.FirstSeen.Unix(),
+		Status:         "pending",
+	}
+}
+
+func ToWorkerStatusDTO(status supervisor.Status, endpoints []rpcpool.EndpointStatus) WorkerStatusResponse {
+	resp := WorkerStatusResponse{
+		Chain:     status.Name,
+		State:     string(status.State),
+		Height:    status.Height,
+		Tip:       status.Tip,
+		LastError: status.LastError,
+		Restarts:  status.Restarts,
+		Since:     status.Since.Unix(),
+		UpdatedAt: status.UpdatedAt.Unix(),
+		Endpoints: endpoints,
+	}
+	if status.LastErrorAt != nil {
+		resp.LastErrorAt = status.LastErrorAt.Unix()
+	}
+	return resp
+}
+
+func ToBackfillJobDTO(info chains.Info, job model.BackfillJob) BackfillJobResponse {
+	resp := BackfillJobResponse{
+		ID:         job.ID,
+		Chain:      info.Name,
+		FromHeight: job.FromHeight,
+		ToHeight:   job.ToHeight,
+		NextHeight: job.NextHeight,
+		Status:     job.Status,
+		LastError:  job.LastError,
+		CreatedAt:  job.CreatedAt.Unix(),
+		UpdatedAt:  job.UpdatedAt.Unix(),
+	}
+	if total := job.ToHeight - job.FromHeight + 1; job.NextHeight > job.FromHeight {
+		resp.Progress = float64(job.NextHeight-job.FromHeight) * 100 / float64(total)
+	}
+	if job.Status == model.BackfillDone {
+		resp.Progress = 100
+	}
+	if job.FinishedAt != nil {
+		resp.FinishedAt = job.FinishedAt.Unix()
 	}
+	return resp
 }
@@ -1,49 +1,95 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
+	"indexer/internal/chains"
 	"indexer/internal/model"
 	"indexer/internal/repository"
+	"indexer/internal/rpcpool"
+	"indexer/internal/supervisor"
 	"net/http"
+	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/gin-gonic/gin"
 )
 
+// baseUnitsPattern matches a non-negative amount in base units, at most NUMERIC(78,0) wide
+var baseUnitsPattern = regexp.MustCompile(`^[0-9]{1,78}$`)
+
 type APIHandler struct {
-	repo repository.Repository
+	repo    repository.Repository
+	workers *supervisor.Supervisor
 }
 
-func NewAPIHandler(repo repository.Repository) *APIHandler {
-	return &APIHandler{repo: repo}
+func NewAPIHandler(repo repository.Repository, workers *supervisor.Supervisor) *APIHandler {
+	return &APIHandler{repo: repo, workers: workers}
 }
 
-func (h *APIHandler) normalizeChain(c string) model.ChainType {
-	switch strings.ToLower(c) {
-	case "btc", "bitcoin":
-		return model.ChainBTC
-	case "eth", "ethereum":
-		return model.ChainETH
+// resolveChain looks up the :chain path parameter in the registry and answers 404 for
+// chains this indexer does not know about
+func (h *APIHandler) resolveChain(c *gin.Context) (chains.Info, bool) {
+	info, ok := chains.Resolve(c.Param("chain"))
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Unknown chain"})
 	}
-	return model.ChainType(c)
+	return info, ok
 }
 
-func (h *APIHandler) GetBlocks(c *gin.Context) {
-	chain := h.normalizeChain(c.Param("chain"))
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+// resolveEVMChain is resolveChain for endpoints backed by receipt data, which only EVM
+// chains have
+func (h *APIHandler) resolveEVMChain(c *gin.Context) (chains.Info, bool) {
+	info, ok := h.resolveChain(c)
+	if ok && !info.IsEVM() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Not an EVM chain"})
+		return info, false
+	}
+	return info, ok
+}
+
+// parsePagination reads ?page=&limit= with the same defaults and caps for every list endpoint
+func parsePagination(c *gin.Context) (page, limit, offset int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
 	if limit > 100 {
 		limit = 100
 	}
-	offset := (page - 1) * limit
+	return page, limit, (page - 1) * limit
+}
 
-	blocks, err := h.repo.GetLatestBlocks(chain, limit, offset)
+// normalizeAddress returns the checksummed form we store EVM addresses in
+func normalizeAddress(addr string) (string, bool) {
+	if !common.IsHexAddress(addr) {
+		return "", false
+	}
+	return common.HexToAddress(addr).Hex(), true
+}
+
+func (h *APIHandler) GetBlocks(c *gin.Context) {
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	chain := info.Type
+	page, limit, offset := parsePagination(c)
+
+	blocks, err := h.repo.GetLatestBlocks(c.Request.Context(), chain, limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch blocks"})
 		return
 	}
 
-	total, _ := h.repo.CountBlocks(chain)
+	total, _ := h.repo.CountBlocks(c.Request.Context(), chain)
 
 	dtos := make([]BlockResponse, len(blocks))
 	for i, b := range blocks {
@@ -59,24 +105,28 @@ func (h *APIHandler) GetBlocks(c *gin.Context) {
 }
 
 func (h *APIHandler) GetBlockByHeight(c *gin.Context) {
-	chain := h.normalizeChain(c.Param("chain"))
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	chain := info.Type
 	height, err := strconv.ParseUint(c.Param("height"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid block height"})
 		return
 	}
 
-	block, err := h.repo.GetBlockByHeight(chain, height)
+	block, err := h.repo.GetBlockByHeight(c.Request.Context(), chain, height)
 	if err != nil {
 		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Block not found"})
 		return
 	}
 
-	txs, _ := h.repo.GetTransactionsByBlock(chain, height)
+	txs, _ := h.repo.GetTransactionsByBlock(c.Request.Context(), chain, height)
 
 	txDTOs := make([]TransactionResponse, len(txs))
 	for i, t := range txs {
-		txDTOs[i] = ToTransactionDTO(t)
+		txDTOs[i] = ToTransactionDTO(info, t)
 	}
 
 	resp := BlockDetailsResponse{
@@ -90,36 +140,31 @@ func (h *APIHandler) GetBlockByHeight(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-func (h *APIHandler) GetStats(c *gin.Context) {
-	btcLatest, _ := h.repo.GetState(model.ChainBTC)
-	ethLatest, _ := h.repo.GetState(model.ChainETH)
-
-	btcMax, _ := h.repo.GetMaxBlockHeight(model.ChainBTC)
-	ethMax, _ := h.repo.GetMaxBlockHeight(model.ChainETH)
-
-	btcBlocks, _ := h.repo.CountBlocks(model.ChainBTC)
-	ethBlocks, _ := h.repo.CountBlocks(model.ChainETH)
-
-	btcTxCount, _ := h.repo.CountTransactions(model.ChainBTC)
-	ethTxCount, _ := h.repo.CountTransactions(model.ChainETH)
+// GetChains lists the chains this indexer serves and the names to address them by
+func (h *APIHandler) GetChains(c *gin.Context) {
+	all := chains.All()
+	resp := make([]ChainResponse, len(all))
+	for i, info := range all {
+		resp[i] = ToChainDTO(info)
+	}
+	c.JSON(http.StatusOK, resp)
+}
 
-	// Sync logic: latestIndexedBlock >= (maxBlockInDB - 2)
-	btcSynced := btcLatest >= (btcMax-2) && btcMax > 0
-	ethSynced := ethLatest >= (ethMax-2) && ethMax > 0
+func (h *APIHandler) GetStats(c *gin.Context) {
+	resp := StatsResponse{}
+	for _, info := range chains.All() {
+		latest, _ := h.repo.GetState(c.Request.Context(), info.Type)
+		max, _ := h.repo.GetMaxBlockHeight(c.Request.Context(), info.Type)
+		blocks, _ := h.repo.CountBlocks(c.Request.Context(), info.Type)
+		txCount, _ := h.repo.CountTransactions(c.Request.Context(), info.Type)
 
-	resp := StatsResponse{
-		BTC: ChainStats{
-			LatestBlock: btcLatest,
-			TotalBlocks: btcBlocks,
-			TotalTx:     btcTxCount,
-			Synced:      btcSynced,
-		},
-		ETH: ChainStats{
-			LatestBlock: ethLatest,
-			TotalBlocks: ethBlocks,
-			TotalTx:     ethTxCount,
-			Synced:      ethSynced,
-		},
+		resp[info.Name] = ChainStats{
+			LatestBlock: latest,
+			TotalBlocks: blocks,
+			TotalTx:     txCount,
+			// Sync logic: latestIndexedBlock >= (maxBlockInDB - 2)
+			Synced: latest >= (max-2) && max > 0,
+		}
 	}
 
 	c.JSON(http.StatusOK, resp)
@@ -134,47 +179,595 @@ func (h *APIHandler) Search(c *gin.Context) {
 
 	// 1. Try as block height (numeric)
 	if height, err := strconv.ParseUint(q, 10, 64); err == nil {
-		// Search BTC
-		if block, err := h.repo.GetBlockByHeight(model.ChainBTC, height); err == nil {
-			c.JSON(http.StatusOK, SearchResult{
-				Type:   "block",
-				Chain:  "btc",
-				Result: ToBlockDTO(*block, 0),
-			})
+		for _, info := range chains.All() {
+			if block, err := h.repo.GetBlockByHeight(c.Request.Context(), info.Type, height); err == nil {
+				c.JSON(http.StatusOK, SearchResult{
+					Type:   "block",
+					Chain:  info.Name,
+					Result: ToBlockDTO(*block, 0),
+				})
+				return
+			}
+		}
+	}
+
+	// 2. Try as transaction hash (hex string)
+	if strings.HasPrefix(q, "0x") || len(q) >= 32 {
+		for _, info := range chains.All() {
+			tx, err := h.repo.FindTransactionByHash(c.Request.Context(), info.Type, q)
+			if err != nil {
+				continue
+			}
+			if details, err := h.transactionDetails(c.Request.Context(), info, tx); err == nil {
+				c.JSON(http.StatusOK, SearchResult{
+					Type:   "transaction",
+					Chain:  info.Name,
+					Result: details,
+				})
+				return
+			}
+		}
+
+		// 3. Not mined yet: look in the mempools
+		for _, info := range chains.All() {
+			if pending, err := h.repo.FindPendingTransaction(c.Request.Context(), info.Type, q); err == nil {
+				c.JSON(http.StatusOK, SearchResult{
+					Type:   "transaction",
+					Chain:  info.Name,
+					Result: pendingDetails(info, pending),
+				})
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusNotFound, ErrorResponse{Error: "Not found"})
+}
+
+func (h *APIHandler) GetTxInputs(c *gin.Context) {
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	chain := info.Type
+	if !info.HasUTXO() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Chain does not have transaction inputs"})
+		return
+	}
+	hash := c.Param("hash")
+
+	inputs, err := h.repo.GetTxInputs(c.Request.Context(), chain, hash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch inputs"})
+		return
+	}
+	if len(inputs) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Transaction not found"})
+		return
+	}
+
+	dtos := make([]TxInputResponse, len(inputs))
+	for i, in := range inputs {
+		dtos[i] = ToTxInputDTO(info, in)
+	}
+
+	c.JSON(http.StatusOK, TxInputsResponse{Hash: hash, Inputs: dtos})
+}
+
+func (h *APIHandler) GetTxOutputs(c *gin.Context) {
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	chain := info.Type
+	if !info.HasUTXO() {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Chain does not have transaction outputs"})
+		return
+	}
+	hash := c.Param("hash")
+
+	outputs, err := h.repo.GetTxOutputs(c.Request.Context(), chain, hash)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch outputs"})
+		return
+	}
+	if len(outputs) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Transaction not found"})
+		return
+	}
+
+	dtos := make([]TxOutputResponse, len(outputs))
+	for i, out := range outputs {
+		dtos[i] = ToTxOutputDTO(info, out)
+	}
+
+	c.JSON(http.StatusOK, TxOutputsResponse{Hash: hash, Outputs: dtos})
+}
+
+func (h *APIHandler) GetTokenTransfersByContract(c *gin.Context) {
+	info, ok := h.resolveEVMChain(c)
+	if !ok {
+		return
+	}
+	contract, ok := normalizeAddress(c.Param("contract"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid contract address"})
+		return
+	}
+	page, limit, offset := parsePagination(c)
+
+	transfers, err := h.repo.GetTokenTransfersByContract(c.Request.Context(), info.Type, contract, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch token transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedTokenTransfersResponse{
+		Page:      page,
+		Limit:     limit,
+		Transfers: toTokenTransferDTOs(transfers),
+	})
+}
+
+func (h *APIHandler) GetTokenTransfersByAddress(c *gin.Context) {
+	// Shares the /:chain/address/:addr prefix, so only EVM chains are served here
+	info, ok := h.resolveEVMChain(c)
+	if !ok {
+		return
+	}
+	address, ok := normalizeAddress(c.Param("addr"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid address"})
+		return
+	}
+	page, limit, offset := parsePagination(c)
+
+	transfers, err := h.repo.GetTokenTransfersByAddress(c.Request.Context(), info.Type, address, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch token transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginatedTokenTransfersResponse{
+		Page:      page,
+		Limit:     limit,
+		Transfers: toTokenTransferDTOs(transfers),
+	})
+}
+
+func toTokenTransferDTOs(transfers []model.TokenTransfer) []TokenTransferResponse {
+	dtos := make([]TokenTransferResponse, len(transfers))
+	for i, t := range transfers {
+		dtos[i] = ToTokenTransferDTO(t)
+	}
+	return dtos
+}
+
+// GetLogs serves eth_getLogs-style queries from the database. address and topicN accept
+// comma-separated alternatives; fromBlock/toBlock accept decimal or 0x-prefixed heights.
+func (h *APIHandler) GetLogs(c *gin.Context) {
+	info, ok := h.resolveEVMChain(c)
+	if !ok {
+		return
+	}
+
+	var filter repository.LogFilter
+
+	for _, a := range splitQuery(c.Query("address")) {
+		addr, ok := normalizeAddress(a)
+		if !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid address: " + a})
 			return
 		}
-		// Search ETH
-		if block, err := h.repo.GetBlockByHeight(model.ChainETH, height); err == nil {
-			c.JSON(http.StatusOK, SearchResult{
-				Type:   "block",
-				Chain:  "eth",
-				Result: ToBlockDTO(*block, 0),
-			})
+		filter.Addresses = append(filter.Addresses, addr)
+	}
+
+	for i := range filter.Topics {
+		for _, t := range splitQuery(c.Query(fmt.Sprintf("topic%d", i))) {
+			b, err := hexutil.Decode(t)
+			if err != nil || len(b) != common.HashLength {
+				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid topic: " + t})
+				return
+			}
+			filter.Topics[i] = append(filter.Topics[i], common.BytesToHash(b).Hex())
+		}
+	}
+
+	for param, dst := range map[string]**uint64{"fromBlock": &filter.FromBlock, "toBlock": &filter.ToBlock} {
+		v := c.Query(param)
+		if v == "" || v == "latest" {
+			continue
+		}
+		height, err := strconv.ParseUint(v, 0, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid " + param})
 			return
 		}
+		*dst = &height
 	}
 
-	// 2. Try as transaction hash (hex string)
-	if strings.HasPrefix(q, "0x") || len(q) >= 32 {
-		// Search BTC
-		if tx, err := h.repo.FindTransactionByHash(model.ChainBTC, q); err == nil {
-			c.JSON(http.StatusOK, SearchResult{
-				Type:   "transaction",
-				Chain:  "btc",
-				Result: ToTransactionDTO(*tx),
-			})
+	page, limit, offset := parsePagination(c)
+
+	logs, err := h.repo.GetLogs(c.Request.Context(), info.Type, filter, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch logs"})
+		return
+	}
+
+	dtos := make([]LogResponse, len(logs))
+	for i, l := range logs {
+		dtos[i] = ToLogDTO(l)
+	}
+
+	c.JSON(http.StatusOK, PaginatedLogsResponse{
+		Page:  page,
+		Limit: limit,
+		Logs:  dtos,
+	})
+}
+
+// splitQuery splits a comma-separated query value, skipping empty entries
+func splitQuery(v string) []string {
+	var res []string
+	for _, part := range strings.Split(v, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			res = append(res, part)
+		}
+	}
+	return res
+}
+
+func (h *APIHandler) GetAddress(c *gin.Context) {
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	chain := info.Type
+	address := c.Param("addr")
+	if info.IsEVM() {
+		normalized, ok := normalizeAddress(address)
+		if !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid address"})
 			return
 		}
-		// Search ETH
-		if tx, err := h.repo.FindTransactionByHash(model.ChainETH, q); err == nil {
-			c.JSON(http.StatusOK, SearchResult{
-				Type:   "transaction",
-				Chain:  "eth",
-				Result: ToTransactionDTO(*tx),
-			})
+		address = normalized
+	} else if info.Bech32HRP != "" && strings.HasPrefix(strings.ToLower(address), info.Bech32HRP+"1") {
+		// Bech32 addresses may be written upper-case, nodes report them lower-case
+		address = strings.ToLower(address)
+	}
+
+	summary, err := h.repo.GetAddressSummary(c.Request.Context(), chain, address)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch address"})
+		return
+	}
+	if summary.TxCount == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Address not found"})
+		return
+	}
+
+	balance, err := h.repo.GetBalance(c.Request.Context(), chain, address)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch balance"})
+		return
+	}
+
+	page, limit, offset := parsePagination(c)
+	txs, err := h.repo.GetAddressTransactions(c.Request.Context(), chain, address, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch address transactions"})
+		return
+	}
+
+	txDTOs := make([]TransactionResponse, len(txs))
+	for i, t := range txs {
+		txDTOs[i] = ToTransactionDTO(info, t)
+	}
+
+	c.JSON(http.StatusOK, AddressResponse{
+		Address:          address,
+		Chain:            string(chain),
+		Balance:          string(balance),
+		BalanceFormatted: balance.Format(info.Decimals),
+		TxCount:          summary.TxCount,
+		FirstSeenHeight:  summary.FirstSeenHeight,
+		FirstSeen:        summary.FirstSeen.Unix(),
+		LastSeenHeight:   summary.LastSeenHeight,
+		LastSeen:         summary.LastSeen.Unix(),
+		Page:             page,
+		Limit:            limit,
+		Transactions:     txDTOs,
+	})
+}
+
+func (h *APIHandler) GetRichList(c *gin.Context) {
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	chain := info.Type
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	balances, err := h.repo.GetRichList(c.Request.Context(), chain, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch rich list"})
+		return
+	}
+
+	holders := make([]RichListEntry, len(balances))
+	for i, b := range balances {
+		holders[i] = RichListEntry{
+			Rank:             i + 1,
+			Address:          b.Address,
+			Balance:          string(b.Balance),
+			BalanceFormatted: b.Balance.Format(info.Decimals),
+			UpdatedHeight:    b.UpdatedHeight,
+		}
+	}
+
+	c.JSON(http.StatusOK, RichListResponse{Chain: string(chain), Holders: holders})
+}
+
+func (h *APIHandler) GetTransaction(c *gin.Context) {
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	chain := info.Type
+
+	tx, err := h.repo.FindTransactionByHash(c.Request.Context(), chain, c.Param("hash"))
+	if err != nil {
+		if pending, err := h.repo.FindPendingTransaction(c.Request.Context(), chain, c.Param("hash")); err == nil {
+			c.JSON(http.StatusOK, pendingDetails(info, pending))
 			return
 		}
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Transaction not found"})
+		return
 	}
 
-	c.JSON(http.StatusNotFound, ErrorResponse{Error: "Not found"})
+	details, err := h.transactionDetails(c.Request.Context(), info, tx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch transaction details"})
+		return
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// pendingDetails is the transaction view of a mempool transaction: no block and no
+// confirmations yet
+func pendingDetails(info chains.Info, tx *model.PendingTransaction) TransactionDetailsResponse {
+	return TransactionDetailsResponse{
+		TransactionResponse: TransactionResponse{
+			Hash:           tx.Hash,
+			From:           tx.From,
+			To:             tx.To,
+			Value:          string(tx.Value),
+			ValueFormatted: tx.Value.Format(info.Decimals),
+			Status:         "pending",
+			Fee:            string(tx.Fee),
+			FeeFormatted:   tx.Fee.Format(info.Decimals),
+			GasPrice:       string(tx.GasPrice),
+			Nonce:          tx.Nonce,
+		},
+		Chain:     string(info.Type),
+		FirstSeen: tx.FirstSeen.Unix(),
+	}
+}
+
+// GetMempool lists the chain's pending transactions, most recently seen first
+func (h *APIHandler) GetMempool(c *gin.Context) {
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	page, limit, offset := parsePagination(c)
+
+	txs, err := h.repo.GetPendingTransactions(c.Request.Context(), info.Type, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch mempool"})
+		return
+	}
+	total, _ := h.repo.CountPendingTransactions(c.Request.Context(), info.Type)
+
+	dtos := make([]PendingTransactionResponse, len(txs))
+	for i, t := range txs {
+		dtos[i] = ToPendingTransactionDTO(info, t)
+	}
+
+	c.JSON(http.StatusOK, MempoolResponse{
+		Chain:        info.Name,
+		Page:         page,
+		Limit:        limit,
+		Total:        total,
+		Transactions: dtos,
+	})
+}
+
+// transactionDetails assembles the full view of a transaction: confirmations against the
+// indexed tip plus inputs/outputs on UTXO chains or logs and token transfers on EVM chains.
+func (h *APIHandler) transactionDetails(ctx context.Context, info chains.Info, tx *model.Transaction) (TransactionDetailsResponse, error) {
+	chain := info.Type
+	resp := TransactionDetailsResponse{
+		TransactionResponse: ToTransactionDTO(info, *tx),
+		Chain:               string(chain),
+		BlockHash:           tx.BlockHash,
+	}
+
+	tip, err := h.repo.GetState(ctx, chain)
+	if err != nil {
+		return resp, err
+	}
+	if tip >= tx.Height {
+		resp.Confirmations = tip - tx.Height + 1
+	}
+
+	switch info.Family {
+	case chains.FamilyUTXO:
+		inputs, err := h.repo.GetTxInputs(ctx, chain, tx.Hash)
+		if err != nil {
+			return resp, err
+		}
+		outputs, err := h.repo.GetTxOutputs(ctx, chain, tx.Hash)
+		if err != nil {
+			return resp, err
+		}
+		for _, in := range inputs {
+			resp.Inputs = append(resp.Inputs, ToTxInputDTO(info, in))
+		}
+		for _, out := range outputs {
+			resp.Outputs = append(resp.Outputs, ToTxOutputDTO(info, out))
+		}
+
+	case chains.FamilyEVM:
+		logs, err := h.repo.GetLogsByTx(ctx, chain, tx.Hash)
+		if err != nil {
+			return resp, err
+		}
+		transfers, err := h.repo.GetTokenTransfersByTx(ctx, chain, tx.Hash)
+		if err != nil {
+			return resp, err
+		}
+		for _, l := range logs {
+			resp.Logs = append(resp.Logs, ToLogDTO(l))
+		}
+		resp.TokenTransfers = toTokenTransferDTOs(transfers)
+	}
+
+	return resp, nil
+}
+
+// GetTransactions lists transactions newest first. Optional filters: fromHeight, toHeight,
+// minValue (in base units, like the value field) and status.
+func (h *APIHandler) GetTransactions(c *gin.Context) {
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	chain := info.Type
+
+	var filter repository.TransactionFilter
+	for param, dst := range map[string]**uint64{"fromHeight": &filter.FromHeight, "toHeight": &filter.ToHeight} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		height, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid " + param})
+			return
+		}
+		*dst = &height
+	}
+
+	if v := c.Query("minValue"); v != "" {
+		if !baseUnitsPattern.MatchString(v) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid minValue"})
+			return
+		}
+		filter.MinValue = model.Amount(v)
+	}
+
+	switch status := c.Query("status"); status {
+	case "", "success", "failed":
+		filter.Status = status
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid status, expected success or failed"})
+		return
+	}
+
+	page, limit, offset := parsePagination(c)
+
+	txs, err := h.repo.GetTransactions(c.Request.Context(), chain, filter, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch transactions"})
+		return
+	}
+
+	dtos := make([]TransactionResponse, len(txs))
+	for i, t := range txs {
+		dtos[i] = ToTransactionDTO(info, t)
+	}
+
+	c.JSON(http.StatusOK, PaginatedTransactionsResponse{
+		Page:         page,
+		Limit:        limit,
+		Transactions: dtos,
+	})
+}
+
+// GetWorkers lists the state of every sync worker
+func (h *APIHandler) GetWorkers(c *gin.Context) {
+	resp := []WorkerStatusResponse{}
+	for _, info := range chains.All() {
+		if status, ok := h.workers.Status(info.Name); ok {
+			resp = append(resp, ToWorkerStatusDTO(status, endpoints(info)))
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetWorker returns the state of one chain's sync worker, including its RPC endpoints
+func (h *APIHandler) GetWorker(c *gin.Context) {
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	status, ok := h.workers.Status(info.Name)
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "No worker running for this chain"})
+		return
+	}
+	c.JSON(http.StatusOK, ToWorkerStatusDTO(status, endpoints(info)))
+}
+
+func endpoints(info chains.Info) []rpcpool.EndpointStatus {
+	if adapter, ok := chains.GetAdapter(info.Type); ok {
+		if reporter, ok := adapter.(chains.EndpointReporter); ok {
+			return reporter.Endpoints()
+		}
+	}
+	return nil
+}
+
+// GetBackfillJobs lists the chain's backfill jobs, newest first
+func (h *APIHandler) GetBackfillJobs(c *gin.Context) {
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	_, limit, offset := parsePagination(c)
+
+	jobs, err := h.repo.GetBackfillJobs(c.Request.Context(), info.Type, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch backfill jobs"})
+		return
+	}
+	dtos := make([]BackfillJobResponse, len(jobs))
+	for i, job := range jobs {
+		dtos[i] = ToBackfillJobDTO(info, job)
+	}
+	c.JSON(http.StatusOK, dtos)
+}
+
+func (h *APIHandler) GetBackfillJob(c *gin.Context) {
+	info, ok := h.resolveChain(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid job ID"})
+		return
+	}
+
+	job, err := h.repo.GetBackfillJob(c.Request.Context(), info.Type, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Backfill job not found"})
+		return
+	}
+	c.JSON(http.StatusOK, ToBackfillJobDTO(info, *job))
 }
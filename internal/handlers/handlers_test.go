@@ -0,0 +1,198 @@
+package handlers_test
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/chains"
+	"indexer/internal/handlers"
+	"indexer/internal/model"
+	"indexer/internal/repository"
+	"indexer/internal/routes"
+	"indexer/internal/supervisor"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryRepo records the filters the list endpoints pass on and returns nothing
+type queryRepo struct {
+	repository.Repository
+
+	logFilter *repository.LogFilter
+	txFilter  *repository.TransactionFilter
+}
+
+func (r *queryRepo) GetTransactions(ctx context.Context, chain model.ChainType, filter repository.TransactionFilter, limit, offset int) ([]model.Transaction, error) {
+	r.txFilter = &filter
+	return nil, nil
+}
+
+func (r *queryRepo) GetLogs(ctx context.Context, chain model.ChainType, filter repository.LogFilter, limit, offset int) ([]model.Log, error) {
+	r.logFilter = &filter
+	return nil, nil
+}
+
+func newAPIRouter(t *testing.T) (*gin.Engine, *queryRepo) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	for _, info := range []chains.Info{chains.Bitcoin, chains.Ethereum} {
+		if err := chains.Register(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := &queryRepo{}
+	return routes.SetupRouter(handlers.NewAPIHandler(repo, supervisor.New()), nil), repo
+}
+
+func TestGetLogsFilter(t *testing.T) {
+	const (
+		usdc     = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		weth     = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+		transfer = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+		approval = "0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"
+		holder   = "0x000000000000000000000000c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+	)
+	height := func(h uint64) *uint64 { return &h }
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+		// wantFilter is compared as printed, with heights dereferenced
+		wantFilter repository.LogFilter
+	}{
+		{name: "no filter", query: "", want: http.StatusOK},
+		{
+			name:       "address is checksummed",
+			query:      "address=0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			want:       http.StatusOK,
+			wantFilter: repository.LogFilter{Addresses: []string{usdc}},
+		},
+		{
+			name:       "comma-separated addresses",
+			query:      "address=" + usdc + ",%20" + weth + ",",
+			want:       http.StatusOK,
+			wantFilter: repository.LogFilter{Addresses: []string{usdc, weth}},
+		},
+		{name: "short address", query: "address=0xa0b86991", want: http.StatusBadRequest},
+		{name: "not an address", query: "address=vitalik.eth", want: http.StatusBadRequest},
+		{
+			name:       "topics by position",
+			query:      "topic0=" + transfer + "," + approval + "&topic2=" + holder,
+			want:       http.StatusOK,
+			wantFilter: repository.LogFilter{Topics: [4][]string{{transfer, approval}, nil, {holder}}},
+		},
+		{
+			name:       "topics are lower-cased",
+			query:      "topic0=0xDDF252AD1BE2C89B69C2B068FC378DAA952BA7F163C4A11628F55A4DF523B3EF",
+			want:       http.StatusOK,
+			wantFilter: repository.LogFilter{Topics: [4][]string{{transfer}}},
+		},
+		{name: "topic without 0x", query: "topic0=" + transfer[2:], want: http.StatusBadRequest},
+		{name: "short topic", query: "topic1=0xddf252ad", want: http.StatusBadRequest},
+		{name: "topic that is not hex", query: "topic3=0x" + fmt.Sprintf("%064s", "zz"), want: http.StatusBadRequest},
+		{
+			name:       "decimal and hex heights",
+			query:      "fromBlock=19000000&toBlock=0x121eac0",
+			want:       http.StatusOK,
+			wantFilter: repository.LogFilter{FromBlock: height(19000000), ToBlock: height(19000000)},
+		},
+		{name: "latest leaves the range open", query: "toBlock=latest", want: http.StatusOK},
+		{name: "invalid height", query: "fromBlock=-1", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, repo := newAPIRouter(t)
+			rec := serve(router, http.MethodGet, "/api/eth/logs?"+tt.query, "", "")
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				if repo.logFilter != nil {
+					t.Error("rejected request reached the repository")
+				}
+				return
+			}
+			if printLogFilter(*repo.logFilter) != printLogFilter(tt.wantFilter) {
+				t.Errorf("filter = %s, want %s", printLogFilter(*repo.logFilter), printLogFilter(tt.wantFilter))
+			}
+		})
+	}
+
+	t.Run("not an EVM chain", func(t *testing.T) {
+		router, _ := newAPIRouter(t)
+		if rec := serve(router, http.MethodGet, "/api/btc/logs", "", ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+func printLogFilter(f repository.LogFilter) string {
+	return fmt.Sprintf("addresses=%v topics=%v from=%s to=%s", f.Addresses, f.Topics, printHeight(f.FromBlock), printHeight(f.ToBlock))
+}
+
+func TestGetTransactionsFilter(t *testing.T) {
+	maxAmount := strings.Repeat("9", 78)
+	tests := []struct {
+		name       string
+		query      string
+		want       int
+		wantFilter string
+	}{
+		{name: "no filter", query: "", want: http.StatusOK, wantFilter: "from=open to=open min= status="},
+		{name: "height range", query: "fromHeight=100&toHeight=200", want: http.StatusOK, wantFilter: "from=100 to=200 min= status="},
+		{name: "zero height", query: "fromHeight=0", want: http.StatusOK, wantFilter: "from=0 to=open min= status="},
+		{name: "negative height", query: "fromHeight=-1", want: http.StatusBadRequest},
+		{name: "hex height", query: "toHeight=0x10", want: http.StatusBadRequest},
+		{name: "height that is not a number", query: "toHeight=tip", want: http.StatusBadRequest},
+		{name: "min value", query: "minValue=100000000", want: http.StatusOK, wantFilter: "from=open to=open min=100000000 status="},
+		{name: "zero min value", query: "minValue=0", want: http.StatusOK, wantFilter: "from=open to=open min=0 status="},
+		{name: "widest min value", query: "minValue=" + maxAmount, want: http.StatusOK, wantFilter: "from=open to=open min=" + maxAmount + " status="},
+		{name: "min value wider than NUMERIC(78,0)", query: "minValue=1" + maxAmount, want: http.StatusBadRequest},
+		{name: "negative min value", query: "minValue=-1", want: http.StatusBadRequest},
+		{name: "min value in coins", query: "minValue=1.5", want: http.StatusBadRequest},
+		{name: "min value with an exponent", query: "minValue=1e18", want: http.StatusBadRequest},
+		{name: "hex min value", query: "minValue=0x10", want: http.StatusBadRequest},
+		{name: "min value with a space", query: "minValue=%201", want: http.StatusBadRequest},
+		{name: "success", query: "status=success", want: http.StatusOK, wantFilter: "from=open to=open min= status=success"},
+		{name: "failed", query: "status=failed", want: http.StatusOK, wantFilter: "from=open to=open min= status=failed"},
+		{name: "unknown status", query: "status=pending", want: http.StatusBadRequest},
+		{
+			name:       "everything",
+			query:      "fromHeight=5&toHeight=9&minValue=42&status=failed",
+			want:       http.StatusOK,
+			wantFilter: "from=5 to=9 min=42 status=failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, repo := newAPIRouter(t)
+			rec := serve(router, http.MethodGet, "/api/btc/txs?"+tt.query, "", "")
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				if repo.txFilter != nil {
+					t.Error("rejected request reached the repository")
+				}
+				return
+			}
+			if got := printTxFilter(*repo.txFilter); got != tt.wantFilter {
+				t.Errorf("filter = %s, want %s", got, tt.wantFilter)
+			}
+		})
+	}
+}
+
+func printTxFilter(f repository.TransactionFilter) string {
+	return fmt.Sprintf("from=%s to=%s min=%s status=%s", printHeight(f.FromHeight), printHeight(f.ToHeight), f.MinValue, f.Status)
+}
+
+func printHeight(h *uint64) string {
+	if h == nil {
+		return "open"
+	}
+	return fmt.Sprint(*h)
+}
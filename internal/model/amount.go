@@ -0,0 +1,71 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Amount is an integer amount in base units (satoshis / wei, or raw token units) kept as
+// its decimal digits, stored in a NUMERIC(78,0) column. The empty Amount is an unknown one
+// and is stored as NULL.
+type Amount string
+
+// Value implements driver.Valuer
+func (a Amount) Value() (driver.Value, error) {
+	if a == "" {
+		return nil, nil
+	}
+	return string(a), nil
+}
+
+// Scan implements sql.Scanner
+func (a *Amount) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = ""
+	case string:
+		*a = Amount(v)
+	case []byte:
+		*a = Amount(v)
+	case int64:
+		*a = Amount(strconv.FormatInt(v, 10))
+	default:
+		return fmt.Errorf("cannot scan %T into Amount", src)
+	}
+	return nil
+}
+
+// BigInt returns the amount as a big.Int, or nil when it is unknown or malformed
+func (a Amount) BigInt() *big.Int {
+	v, ok := new(big.Int).SetString(string(a), 10)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+// Format renders the amount in whole coins with every decimal spelled out, the way
+// node RPCs do: 150000000 satoshis with 8 decimals is "1.50000000". Unknown amounts
+// format as "".
+func (a Amount) Format(decimals int) string {
+	v := a.BigInt()
+	if v == nil {
+		return ""
+	}
+	if decimals <= 0 {
+		return v.String()
+	}
+
+	digits := new(big.Int).Abs(v).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	s := digits[:len(digits)-decimals] + "." + digits[len(digits)-decimals:]
+	if v.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
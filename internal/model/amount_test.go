@@ -0,0 +1,104 @@
+package model
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestAmountValue(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want driver.Value
+	}{
+		{"", nil},
+		{"0", "0"},
+		{"150000000", "150000000"},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", "115792089237316195423570985008687907853269984665640564039457584007913129639935"},
+	}
+	for _, tt := range tests {
+		got, err := tt.in.Value()
+		if err != nil {
+			t.Errorf("Amount(%q).Value() error = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Amount(%q).Value() = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAmountScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    Amount
+		wantErr bool
+	}{
+		{name: "null", src: nil, want: ""},
+		{name: "string", src: "12345", want: "12345"},
+		{name: "bytes", src: []byte("98765432109876543210"), want: "98765432109876543210"},
+		{name: "int64", src: int64(-42), want: "-42"},
+		{name: "float", src: 1.5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Amount("stale")
+			err := a.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && a != tt.want {
+				t.Errorf("Scan() = %q, want %q", a, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountFormat(t *testing.T) {
+	tests := []struct {
+		in       Amount
+		decimals int
+		want     string
+	}{
+		{"150000000", 8, "1.50000000"},
+		{"1", 8, "0.00000001"},
+		{"0", 8, "0.00000000"},
+		{"12345678", 8, "0.12345678"},
+		{"-150000000", 8, "-1.50000000"},
+		{"-1", 8, "-0.00000001"},
+		{"1000000000000000000", 18, "1.000000000000000000"},
+		{"123456789012345678901234567890", 18, "123456789012.345678901234567890"},
+		{"42", 0, "42"},
+		{"", 8, ""},
+		{"1.5", 8, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.in.Format(tt.decimals); got != tt.want {
+			t.Errorf("Amount(%q).Format(%d) = %q, want %q", tt.in, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestAmountBigInt(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want string // "" for nil
+	}{
+		{"0", "0"},
+		{"-7", "-7"},
+		{"340282366920938463463374607431768211456", "340282366920938463463374607431768211456"},
+		{"", ""},
+		{"abc", ""},
+		{"1e8", ""},
+	}
+	for _, tt := range tests {
+		v := tt.in.BigInt()
+		got := ""
+		if v != nil {
+			got = v.String()
+		}
+		if got != tt.want {
+			t.Errorf("Amount(%q).BigInt() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -11,13 +11,17 @@ const (
 	ChainETH ChainType = "ethereum"
 )
 
+// Chain-scoped tables: every registered chain gets its own copy named after the chain,
+// e.g. btc_blocks. The schema itself, indexes included, is defined by the SQL migrations
+// of the db package; keep them in step with these structs.
+
 // Block represents the shared block structure
 type Block struct {
 	ID           uint64        `json:"id" gorm:"primaryKey;autoIncrement"`
-	Chain        ChainType     `json:"chain" gorm:"type:varchar(10);uniqueIndex:idx_chain_height"`
-	Height       uint64        `json:"height" gorm:"uniqueIndex:idx_chain_height"`
-	Hash         string        `json:"hash" gorm:"index"`
-	BlockHash    string        `json:"block_hash" gorm:"index"` // This is usually parent hash
+	Chain        ChainType     `json:"chain" gorm:"type:varchar(32)"`
+	Height       uint64        `json:"height"`
+	Hash         string        `json:"hash"`
+	BlockHash    string        `json:"block_hash"` // This is usually parent hash
 	Transactions []Transaction `json:"transactions,omitempty" gorm:"-"`
 	TXCount      uint64        `json:"tx_count"`
 	Timestamp    time.Time     `json:"timestamp"`
@@ -27,38 +31,180 @@ type Block struct {
 // Transaction represents the shared transaction structure
 type Transaction struct {
 	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
-	Chain     ChainType `json:"chain" gorm:"type:varchar(10);index"`
-	Hash      string    `json:"hash" gorm:"index"`
-	BlockHash string    `json:"block_hash" gorm:"index"`
-	Height    uint64    `json:"height" gorm:"column:block_height;index"`
+	Chain     ChainType `json:"chain" gorm:"type:varchar(32)"`
+	Hash      string    `json:"hash"`
+	BlockHash string    `json:"block_hash"`
+	Height    uint64    `json:"height" gorm:"column:block_height"`
 	From      string    `json:"from_address" gorm:"column:from_address"`
 	To        string    `json:"to_address" gorm:"column:to_address"`
-	Value     string    `json:"value"`
+	Value     Amount    `json:"value" gorm:"type:numeric(78,0)"` // base units
 	Status    string    `json:"status"`
 	Timestamp time.Time `json:"timestamp"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// Fee is in base units like Value, unknown while a UTXO transaction's spent outputs
+	// cannot be resolved. The remaining fields come from EVM receipts.
+	Fee             Amount `json:"fee" gorm:"type:numeric(78,0)"`
+	GasUsed         uint64 `json:"gas_used"`
+	GasPrice        Amount `json:"gas_price" gorm:"type:numeric(78,0)"` // effective gas price in wei
+	TxType          uint8  `json:"tx_type"`
+	Nonce           uint64 `json:"nonce"`
+	ContractAddress string `json:"contract_address"`
+
+	// UTXO chains only: full input and output lists, saved to their own tables
+	Inputs  []TxInput  `json:"inputs,omitempty" gorm:"-"`
+	Outputs []TxOutput `json:"outputs,omitempty" gorm:"-"`
+
+	// EVM chains only: token transfers decoded from the receipt logs
+	TokenTransfers []TokenTransfer `json:"token_transfers,omitempty" gorm:"-"`
+	Logs           []Log           `json:"logs,omitempty" gorm:"-"`
 }
 
-// IndexerState tracks the indexing progress
-type IndexerState struct {
-	Chain             ChainType `json:"chain" gorm:"primaryKey;type:varchar(10)"`
-	LastIndexedHeight uint64    `json:"last_indexed_height" gorm:"column:last_indexed_block"`
-	UpdatedAt         time.Time `json:"updated_at"`
+// TxInput is one input of a UTXO transaction together with the output it spends
+type TxInput struct {
+	ID         uint64    `json:"-" gorm:"primaryKey;autoIncrement"`
+	Txid       string    `json:"txid"`
+	Vin        uint32    `json:"vin"`
+	PrevTxid   string    `json:"prev_txid"`
+	PrevVout   uint32    `json:"prev_vout"`
+	Address    string    `json:"address"`
+	Value      int64     `json:"value"` // satoshis
+	ScriptType string    `json:"script_type"`
+	Coinbase   bool      `json:"coinbase"`
+	Height     uint64    `json:"height" gorm:"column:block_height"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TxOutput is one output of a UTXO transaction and, once spent, the input spending it
+type TxOutput struct {
+	ID          uint64    `json:"-" gorm:"primaryKey;autoIncrement"`
+	Txid        string    `json:"txid"`
+	Vout        uint32    `json:"vout"`
+	Address     string    `json:"address"`
+	Value       int64     `json:"value"` // satoshis
+	ScriptType  string    `json:"script_type"`
+	SpentByTxid string    `json:"spent_by_txid,omitempty"`
+	SpentByVin  *uint32   `json:"spent_by_vin,omitempty"`
+	Height      uint64    `json:"height" gorm:"column:block_height"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
-// Table definitions for GORM migration
-type BTCBlock struct{ Block }
+// TokenTransfer is an ERC-20, ERC-721 or ERC-1155 transfer decoded from a receipt log
+type TokenTransfer struct {
+	ID         uint64    `json:"-" gorm:"primaryKey;autoIncrement"`
+	TxHash     string    `json:"tx_hash"`
+	LogIndex   uint      `json:"log_index"`
+	BatchIndex uint      `json:"batch_index"` // position within an ERC-1155 TransferBatch
+	Contract   string    `json:"contract"`
+	Standard   string    `json:"standard" gorm:"type:varchar(10)"`
+	From       string    `json:"from_address" gorm:"column:from_address"`
+	To         string    `json:"to_address" gorm:"column:to_address"`
+	Amount     Amount    `json:"amount" gorm:"type:numeric(78,0)"` // raw token units, "1" for ERC-721
+	TokenID    string    `json:"token_id"`                         // ERC-721 / ERC-1155 only
+	Height     uint64    `json:"height" gorm:"column:block_height"`
+	Timestamp  time.Time `json:"timestamp"`
+	CreatedAt  time.Time `json:"created_at"`
+}
 
-func (BTCBlock) TableName() string { return "btc_blocks" }
+// Log is a raw EVM event log as returned by eth_getLogs
+type Log struct {
+	ID        uint64    `json:"-" gorm:"primaryKey;autoIncrement"`
+	TxHash    string    `json:"tx_hash"`
+	LogIndex  uint      `json:"log_index"`
+	Address   string    `json:"address"`
+	Topic0    string    `json:"topic0"`
+	Topic1    string    `json:"topic1"`
+	Topic2    string    `json:"topic2"`
+	Topic3    string    `json:"topic3"`
+	Data      string    `json:"data"` // 0x-prefixed hex
+	Removed   bool      `json:"removed"`
+	BlockHash string    `json:"block_hash"`
+	Height    uint64    `json:"height" gorm:"column:block_height"`
+	Timestamp time.Time `json:"timestamp"`
+	CreatedAt time.Time `json:"created_at"`
+}
 
-type BTCTransaction struct{ Transaction }
+// AddressTransaction links every address taking part in a transaction to it, so
+// multi-input/output transactions are searchable by any of their addresses
+type AddressTransaction struct {
+	ID        uint64    `json:"-" gorm:"primaryKey;autoIncrement"`
+	Chain     ChainType `json:"chain" gorm:"type:varchar(32);uniqueIndex:idx_address_tx;index:idx_address_height"`
+	Address   string    `json:"address" gorm:"uniqueIndex:idx_address_tx;index:idx_address_height"`
+	TxHash    string    `json:"tx_hash" gorm:"uniqueIndex:idx_address_tx"`
+	Height    uint64    `json:"height" gorm:"column:block_height;index:idx_address_height;index"`
+	Sent      bool      `json:"sent"`
+	Received  bool      `json:"received"`
+	Timestamp time.Time `json:"timestamp"`
+}
 
-func (BTCTransaction) TableName() string { return "btc_transactions" }
+func (AddressTransaction) TableName() string { return "address_transactions" }
 
-type ETHBlock struct{ Block }
+// Balance is the running balance of an address in base units (satoshis / wei)
+type Balance struct {
+	Chain         ChainType `json:"chain" gorm:"primaryKey;type:varchar(32);index:idx_balance_rank"`
+	Address       string    `json:"address" gorm:"primaryKey"`
+	Balance       Amount    `json:"balance" gorm:"type:numeric(78,0);not null;default:0;index:idx_balance_rank,sort:desc"`
+	UpdatedHeight uint64    `json:"updated_height"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
 
-func (ETHBlock) TableName() string { return "eth_blocks" }
+func (Balance) TableName() string { return "balances" }
 
-type ETHTransaction struct{ Transaction }
+// BalanceChange is the net change of an address balance in one block, kept so
+// a rollback can subtract exactly what the orphaned blocks added
+type BalanceChange struct {
+	Chain   ChainType `json:"chain" gorm:"primaryKey;type:varchar(32)"`
+	Address string    `json:"address" gorm:"primaryKey"`
+	Height  uint64    `json:"height" gorm:"column:block_height;primaryKey;index"`
+	Delta   Amount    `json:"delta" gorm:"type:numeric(78,0);not null"`
+}
 
-func (ETHTransaction) TableName() string { return "eth_transactions" }
+func (BalanceChange) TableName() string { return "balance_changes" }
+
+// PendingTransaction is an unconfirmed transaction seen in a node's mempool. It is removed
+// once mined or evicted.
+type PendingTransaction struct {
+	Chain     ChainType `json:"chain" gorm:"primaryKey;type:varchar(32)"`
+	Hash      string    `json:"hash" gorm:"primaryKey"`
+	From      string    `json:"from_address" gorm:"column:from_address"`
+	To        string    `json:"to_address" gorm:"column:to_address"`
+	Value     Amount    `json:"value" gorm:"type:numeric(78,0)"`     // base units
+	Fee       Amount    `json:"fee" gorm:"type:numeric(78,0)"`       // UTXO chains; unknown on EVM chains until mined
+	GasPrice  Amount    `json:"gas_price" gorm:"type:numeric(78,0)"` // EVM chains: gas price or max fee per gas in wei
+	Nonce     uint64    `json:"nonce"`
+	FirstSeen time.Time `json:"first_seen" gorm:"index"`
+}
+
+func (PendingTransaction) TableName() string { return "pending_transactions" }
+
+// Backfill job statuses
+const (
+	BackfillPending   = "pending"
+	BackfillRunning   = "running"
+	BackfillDone      = "done"
+	BackfillCancelled = "cancelled"
+)
+
+// BackfillJob indexes a range of past heights alongside live sync. NextHeight is the
+// resume cursor: everything below it has been indexed or was already stored.
+type BackfillJob struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Chain      ChainType  `json:"chain" gorm:"type:varchar(32);index;not null"`
+	FromHeight uint64     `json:"from_height"`
+	ToHeight   uint64     `json:"to_height"`
+	NextHeight uint64     `json:"next_height"`
+	Status     string     `json:"status" gorm:"type:varchar(16);index;not null"`
+	LastError  string     `json:"last_error"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+func (BackfillJob) TableName() string { return "backfill_jobs" }
+
+// IndexerState tracks the indexing progress
+type IndexerState struct {
+	Chain             ChainType `json:"chain" gorm:"primaryKey;type:varchar(32)"`
+	LastIndexedHeight uint64    `json:"last_indexed_height" gorm:"column:last_indexed_block"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/model"
+	"strings"
+	"time"
+)
+
+// AddressSummary is the aggregate activity of one address
+type AddressSummary struct {
+	Address         string
+	TxCount         int64
+	FirstSeenHeight uint64
+	FirstSeen       time.Time
+	LastSeenHeight  uint64
+	LastSeen        time.Time
+}
+
+// addressRefs builds one address_transactions row per distinct address taking part in each
+// transaction: every input and output address on UTXO chains, sender, recipient and created
+// contract on EVM chains.
+func addressRefs(block *model.Block, txs []*model.Transaction) []model.AddressTransaction {
+	var refs []model.AddressTransaction
+	for _, t := range txs {
+		byAddr := map[string]*model.AddressTransaction{}
+		var order []string
+		add := func(addrs string, sent bool) {
+			for _, addr := range strings.Split(addrs, ",") {
+				if !isIndexableAddress(addr) {
+					continue
+				}
+				ref, ok := byAddr[addr]
+				if !ok {
+					ref = &model.AddressTransaction{
+						Chain:     block.Chain,
+						Address:   addr,
+						TxHash:    t.Hash,
+						Height:    t.Height,
+						Timestamp: t.Timestamp,
+					}
+					byAddr[addr] = ref
+					order = append(order, addr)
+				}
+				if sent {
+					ref.Sent = true
+				} else {
+					ref.Received = true
+				}
+			}
+		}
+
+		if len(t.Inputs) > 0 || len(t.Outputs) > 0 {
+			for _, in := range t.Inputs {
+				add(in.Address, true)
+			}
+			for _, out := range t.Outputs {
+				add(out.Address, false)
+			}
+		} else {
+			add(t.From, true)
+			add(t.To, false)
+			add(t.ContractAddress, false)
+		}
+
+		for _, addr := range order {
+			refs = append(refs, *byAddr[addr])
+		}
+	}
+	return refs
+}
+
+// isIndexableAddress filters out the placeholders the workers use when no address is known
+func isIndexableAddress(addr string) bool {
+	switch addr {
+	case "", "unknown", "coinbase", "non-standard":
+		return false
+	}
+	return true
+}
+
+func (r *repository) GetAddressSummary(ctx context.Context, chain model.ChainType, address string) (*AddressSummary, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	summary := AddressSummary{Address: address}
+
+	err := db.Model(&model.AddressTransaction{}).
+		Where("chain = ? AND address = ?", chain, address).
+		Count(&summary.TxCount).Error
+	if err != nil || summary.TxCount == 0 {
+		return &summary, err
+	}
+
+	var first, last model.AddressTransaction
+	if err := db.Where("chain = ? AND address = ?", chain, address).
+		Order("block_height ASC").First(&first).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("chain = ? AND address = ?", chain, address).
+		Order("block_height DESC").First(&last).Error; err != nil {
+		return nil, err
+	}
+
+	summary.FirstSeenHeight, summary.FirstSeen = first.Height, first.Timestamp
+	summary.LastSeenHeight, summary.LastSeen = last.Height, last.Timestamp
+	return &summary, nil
+}
+
+func (r *repository) GetAddressTransactions(ctx context.Context, chain model.ChainType, address string, limit, offset int) ([]model.Transaction, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var txs []model.Transaction
+	err := db.Table(fmt.Sprintf("%s AS t", r.txTable(chain))).
+		Select("t.*").
+		Joins("JOIN address_transactions a ON a.tx_hash = t.hash AND a.block_height = t.block_height").
+		Where("a.chain = ? AND a.address = ?", chain, address).
+		Order("a.block_height DESC, t.id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&txs).Error
+	return txs, err
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"fmt"
+	"indexer/internal/model"
+	"testing"
+	"time"
+)
+
+func TestAddressRefs(t *testing.T) {
+	block := &model.Block{Chain: model.ChainBTC, Height: 100}
+	ts := time.Unix(1700000000, 0)
+
+	type ref struct {
+		address, tx    string
+		sent, received bool
+	}
+	tests := []struct {
+		name string
+		txs  []*model.Transaction
+		want []ref
+	}{
+		{
+			name: "utxo inputs send and outputs receive",
+			txs: []*model.Transaction{{
+				Hash:    "t1",
+				Inputs:  []model.TxInput{{Address: "alice"}, {Address: "alice"}},
+				Outputs: []model.TxOutput{{Address: "bob"}, {Address: "alice"}},
+			}},
+			want: []ref{{"alice", "t1", true, true}, {"bob", "t1", false, true}},
+		},
+		{
+			name: "multisig outputs list every address",
+			txs: []*model.Transaction{{
+				Hash:    "t1",
+				Outputs: []model.TxOutput{{Address: "carol,dave"}},
+			}},
+			want: []ref{{"carol", "t1", false, true}, {"dave", "t1", false, true}},
+		},
+		{
+			name: "placeholders are skipped",
+			txs: []*model.Transaction{{
+				Hash:    "t1",
+				Inputs:  []model.TxInput{{Coinbase: true}},
+				Outputs: []model.TxOutput{{Address: "miner"}, {Address: ""}, {Address: "non-standard"}},
+			}},
+			want: []ref{{"miner", "t1", false, true}},
+		},
+		{
+			name: "evm sender, recipient and contract",
+			txs: []*model.Transaction{
+				{Hash: "t1", From: "0xa", To: "0xb"},
+				{Hash: "t2", From: "0xa", ContractAddress: "0xc"},
+				{Hash: "t3", From: "0xa", To: "0xa"},
+			},
+			want: []ref{
+				{"0xa", "t1", true, false}, {"0xb", "t1", false, true},
+				{"0xa", "t2", true, false}, {"0xc", "t2", false, true},
+				{"0xa", "t3", true, true},
+			},
+		},
+		{
+			name: "unknown sender",
+			txs:  []*model.Transaction{{Hash: "t1", From: "unknown", To: "0xb"}},
+			want: []ref{{"0xb", "t1", false, true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, tx := range tt.txs {
+				tx.Height = block.Height
+				tx.Timestamp = ts
+			}
+			refs := addressRefs(block, tt.txs)
+
+			got := make([]ref, len(refs))
+			for i, r := range refs {
+				if r.Chain != block.Chain || r.Height != block.Height || !r.Timestamp.Equal(ts) {
+					t.Errorf("ref %+v does not carry the block's chain, height and time", r)
+				}
+				got[i] = ref{r.Address, r.TxHash, r.Sent, r.Received}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("addressRefs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
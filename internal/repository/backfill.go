@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (r *repository) CreateBackfillJob(ctx context.Context, job *model.BackfillJob) error {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	return db.Create(job).Error
+}
+
+func (r *repository) GetBackfillJob(ctx context.Context, chain model.ChainType, id uint) (*model.BackfillJob, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var job model.BackfillJob
+	err := db.Where("chain = ? AND id = ?", chain, id).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetBackfillJobs lists the chain's jobs, newest first
+func (r *repository) GetBackfillJobs(ctx context.Context, chain model.ChainType, limit, offset int) ([]model.BackfillJob, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var jobs []model.BackfillJob
+	err := db.Where("chain = ?", chain).
+		Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// NextBackfillJob returns the oldest job still to be worked on, or nil when there is none
+func (r *repository) NextBackfillJob(ctx context.Context, chain model.ChainType) (*model.BackfillJob, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var jobs []model.BackfillJob
+	err := db.Where("chain = ? AND status IN ?", chain, []string{model.BackfillPending, model.BackfillRunning}).
+		Order("id").
+		Limit(1).
+		Find(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// SetBackfillStatus moves an unfinished job to status, recording lastError
+func (r *repository) SetBackfillStatus(ctx context.Context, id uint, status, lastError string) error {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	updates := map[string]interface{}{
+		"status":     status,
+		"last_error": lastError,
+		"updated_at": time.Now(),
+	}
+	if status == model.BackfillDone || status == model.BackfillCancelled {
+		updates["finished_at"] = time.Now()
+	}
+	res := db.Model(&model.BackfillJob{}).
+		Where("id = ? AND status IN ?", id, []string{model.BackfillPending, model.BackfillRunning}).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetStoredHeights returns which heights between from and to are already indexed
+func (r *repository) GetStoredHeights(ctx context.Context, chain model.ChainType, from, to uint64) ([]uint64, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var heights []uint64
+	err := db.Table(r.blockTable(chain)).
+		Where("height BETWEEN ? AND ?", from, to).
+		Order("height").
+		Pluck("height", &heights).Error
+	return heights, err
+}
+
+// GetMinBlockHeight returns the lowest indexed height, or 0 when nothing is indexed
+func (r *repository) GetMinBlockHeight(ctx context.Context, chain model.ChainType) (uint64, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var min uint64
+	err := db.Table(r.blockTable(chain)).Select("COALESCE(MIN(height), 0)").Scan(&min).Error
+	return min, err
+}
+
+// SaveBackfillBlocks stores a run of past blocks and moves the job's cursor to next in one
+// DB transaction. Blocks that are already stored, or that lie above the live cursor, are
+// skipped: the live worker owns those heights and saving them twice would count their
+// balance changes twice. It returns how many blocks were written.
+func (r *repository) SaveBackfillBlocks(ctx context.Context, jobID uint, batch []BlockWithTransactions, next uint64) (int, error) {
+	db, cancel := r.withWriteContext(ctx)
+	defer cancel()
+
+	if len(batch) > 0 {
+		if err := r.ensureTxPartitions(db, batch[0].Block.Chain, batchHeights(batch)...); err != nil {
+			return 0, err
+		}
+	}
+
+	saved := 0
+	save := func(tx *gorm.DB, cp *copier) error {
+		if len(batch) > 0 {
+			chain := batch[0].Block.Chain
+
+			// Holding the state row keeps a concurrent rollback from moving the cursor under us
+			var last uint64
+			if err := tx.Raw("SELECT last_indexed_block FROM indexer_states WHERE chain = ? FOR SHARE", chain).
+				Scan(&last).Error; err != nil {
+				return err
+			}
+
+			heights := batchHeights(batch)
+			var stored []uint64
+			if err := tx.Table(r.blockTable(chain)).
+				Where("height IN ?", heights).
+				Pluck("height", &stored).Error; err != nil {
+				return err
+			}
+			fresh := freshBlocks(batch, stored, last)
+			if cp != nil {
+				if err := r.bulkSaveBlocks(tx, *cp, fresh); err != nil {
+					return err
+				}
+			} else {
+				for _, b := range fresh {
+					if err := r.saveBlock(tx, b.Block, b.Txs); err != nil {
+						return fmt.Errorf("block %d: %w", b.Block.Height, err)
+					}
+				}
+			}
+			for _, b := range fresh {
+				if err := r.linkLaterSpends(tx, chain, b.Block.Height); err != nil {
+					return fmt.Errorf("block %d: %w", b.Block.Height, err)
+				}
+			}
+			saved = len(fresh)
+		}
+
+		return tx.Model(&model.BackfillJob{}).
+			Where("id = ?", jobID).
+			Updates(map[string]interface{}{
+				"next_height": next,
+				"last_error":  "",
+				"updated_at":  time.Now(),
+			}).Error
+	}
+
+	var err error
+	if r.opts.CopyIngest {
+		err = r.bulkTransaction(db, func(tx *gorm.DB, cp copier) error { return save(tx, &cp) })
+	} else {
+		err = db.Transaction(func(tx *gorm.DB) error { return save(tx, nil) })
+	}
+	return saved, err
+}
+
+// freshBlocks drops the blocks of batch that are stored or lie above the live cursor last
+func freshBlocks(batch []BlockWithTransactions, stored []uint64, last uint64) []BlockWithTransactions {
+	skip := make(map[uint64]bool, len(stored))
+	for _, h := range stored {
+		skip[h] = true
+	}
+
+	var fresh []BlockWithTransactions
+	for _, b := range batch {
+		if !skip[b.Block.Height] && b.Block.Height <= last {
+			fresh = append(fresh, b)
+		}
+	}
+	return fresh
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"fmt"
+	"indexer/internal/model"
+	"testing"
+)
+
+func TestFreshBlocks(t *testing.T) {
+	batch := func(heights ...uint64) []BlockWithTransactions {
+		var res []BlockWithTransactions
+		for _, h := range heights {
+			res = append(res, BlockWithTransactions{Block: &model.Block{Height: h}})
+		}
+		return res
+	}
+	tests := []struct {
+		name   string
+		batch  []BlockWithTransactions
+		stored []uint64
+		last   uint64
+		want   []uint64
+	}{
+		{name: "nothing stored", batch: batch(1, 2, 3), last: 10, want: []uint64{1, 2, 3}},
+		{name: "skips stored heights", batch: batch(1, 2, 3, 4), stored: []uint64{2, 4}, last: 10, want: []uint64{1, 3}},
+		{name: "skips heights above the live cursor", batch: batch(8, 9, 10, 11), last: 9, want: []uint64{8, 9}},
+		{name: "both", batch: batch(5, 6, 7, 8), stored: []uint64{5}, last: 7, want: []uint64{6, 7}},
+		{name: "all stored", batch: batch(1, 2), stored: []uint64{1, 2}, last: 10, want: nil},
+		{name: "empty batch", batch: nil, stored: []uint64{1}, last: 10, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []uint64
+			for _, b := range freshBlocks(tt.batch, tt.stored, tt.last) {
+				got = append(got, b.Block.Height)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("freshBlocks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
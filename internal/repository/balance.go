@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"indexer/internal/model"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// balanceDeltas computes the net balance change per address for one block.
+//
+// UTXO chains: outputs credit their address and inputs debit the address of the output they
+// spend. Outputs paying several addresses at once (bare multisig) are left out.
+// EVM chains: the sender pays the fee and, if the transaction succeeded, the value, which is
+// credited to the recipient or the created contract. Mining rewards, withdrawals and internal
+// transfers are not visible from transactions alone and are not tracked.
+func balanceDeltas(txs []*model.Transaction) map[string]*big.Int {
+	deltas := map[string]*big.Int{}
+	add := func(addr string, amount *big.Int, sign int) {
+		if !isIndexableAddress(addr) || strings.Contains(addr, ",") || amount.Sign() == 0 {
+			return
+		}
+		d, ok := deltas[addr]
+		if !ok {
+			d = new(big.Int)
+			deltas[addr] = d
+		}
+		if sign < 0 {
+			d.Sub(d, amount)
+		} else {
+			d.Add(d, amount)
+		}
+	}
+
+	for _, t := range txs {
+		if len(t.Inputs) > 0 || len(t.Outputs) > 0 {
+			for _, in := range t.Inputs {
+				add(in.Address, big.NewInt(in.Value), -1)
+			}
+			for _, out := range t.Outputs {
+				add(out.Address, big.NewInt(out.Value), 1)
+			}
+			continue
+		}
+
+		value := parseAmount(t.Value)
+		add(t.From, parseAmount(t.Fee), -1)
+		if t.Status != "failed" {
+			add(t.From, value, -1)
+			if t.To != "" {
+				add(t.To, value, 1)
+			} else {
+				add(t.ContractAddress, value, 1)
+			}
+		}
+	}
+
+	for addr, d := range deltas {
+		if d.Sign() == 0 {
+			delete(deltas, addr)
+		}
+	}
+	return deltas
+}
+
+// parseAmount reads an integer base-unit amount, treating unknown ones as zero
+func parseAmount(a model.Amount) *big.Int {
+	if v := a.BigInt(); v != nil {
+		return v
+	}
+	return new(big.Int)
+}
+
+// applyBalanceChanges adds the block's deltas to the running balances and records them
+// in balance_changes so rollbacks can undo them. Whatever an earlier save of the same height
+// applied is reverted first, so saving a block twice leaves the balances as saving it once.
+func (r *repository) applyBalanceChanges(tx *gorm.DB, block *model.Block, txs []*model.Transaction) error {
+	if err := r.revertBalanceChanges(tx, block.Chain, "=", block.Height); err != nil {
+		return err
+	}
+
+	deltas := balanceDeltas(txs)
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	balances := make([]model.Balance, 0, len(deltas))
+	changes := make([]model.BalanceChange, 0, len(deltas))
+	for addr, d := range deltas {
+		balances = append(balances, model.Balance{
+			Chain:         block.Chain,
+			Address:       addr,
+			Balance:       model.Amount(d.String()),
+			UpdatedHeight: block.Height,
+			UpdatedAt:     now,
+		})
+		changes = append(changes, model.BalanceChange{
+			Chain:   block.Chain,
+			Address: addr,
+			Height:  block.Height,
+			Delta:   model.Amount(d.String()),
+		})
+	}
+
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "chain"}, {Name: "address"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "balance"}, Value: gorm.Expr("balances.balance + EXCLUDED.balance")},
+			{Column: clause.Column{Name: "updated_height"}, Value: gorm.Expr("EXCLUDED.updated_height")},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("EXCLUDED.updated_at")},
+		},
+	}).CreateInBatches(balances, 1000).Error; err != nil {
+		return err
+	}
+
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "chain"}, {Name: "address"}, {Name: "block_height"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "delta"}, Value: gorm.Expr("EXCLUDED.delta")},
+		},
+	}).CreateInBatches(changes, 1000).Error
+}
+
+// revertBalanceChanges subtracts everything recorded at heights comparing to height with op,
+// either ">" or "=", and forgets those changes.
+func (r *repository) revertBalanceChanges(tx *gorm.DB, chain model.ChainType, op string, height uint64) error {
+	if err := tx.Exec(`UPDATE balances b SET balance = b.balance - c.total, updated_at = ?
+		FROM (
+			SELECT address, SUM(delta) AS total FROM balance_changes
+			WHERE chain = ? AND block_height `+op+` ?
+			GROUP BY address
+		) c
+		WHERE b.chain = ? AND b.address = c.address`,
+		time.Now(), chain, height, chain).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("chain = ? AND block_height "+op+" ?", chain, height).
+		Delete(&model.BalanceChange{}).Error
+}
+
+func (r *repository) GetBalance(ctx context.Context, chain model.ChainType, address string) (model.Amount, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var balance model.Balance
+	err := db.Where("chain = ? AND address = ?", chain, address).Limit(1).Find(&balance).Error
+	if err != nil {
+		return "", err
+	}
+	if balance.Balance == "" {
+		return "0", nil
+	}
+	return balance.Balance, nil
+}
+
+func (r *repository) GetRichList(ctx context.Context, chain model.ChainType, limit int) ([]model.Balance, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var balances []model.Balance
+	err := db.Where("chain = ?", chain).
+		Order("balance DESC").
+		Limit(limit).
+		Find(&balances).Error
+	return balances, err
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"indexer/internal/model"
+	"testing"
+)
+
+func TestBalanceDeltas(t *testing.T) {
+	tests := []struct {
+		name string
+		txs  []*model.Transaction
+		want map[string]string
+	}{
+		{
+			name: "utxo spend with change",
+			txs: []*model.Transaction{{
+				Inputs:  []model.TxInput{{Address: "alice", Value: 100000}},
+				Outputs: []model.TxOutput{{Address: "bob", Value: 60000}, {Address: "alice", Value: 39000}},
+			}},
+			want: map[string]string{"alice": "-61000", "bob": "60000"},
+		},
+		{
+			name: "coinbase credits the miner only",
+			txs: []*model.Transaction{{
+				Inputs:  []model.TxInput{{Coinbase: true}},
+				Outputs: []model.TxOutput{{Address: "miner", Value: 625000000}, {Address: "", Value: 0}},
+			}},
+			want: map[string]string{"miner": "625000000"},
+		},
+		{
+			name: "bare multisig and placeholders are skipped",
+			txs: []*model.Transaction{{
+				Inputs:  []model.TxInput{{Address: "alice", Value: 5000}},
+				Outputs: []model.TxOutput{{Address: "carol,dave", Value: 3000}, {Address: "non-standard", Value: 1000}},
+			}},
+			want: map[string]string{"alice": "-5000"},
+		},
+		{
+			name: "transfers within a block net out",
+			txs: []*model.Transaction{
+				{Inputs: []model.TxInput{{Address: "alice", Value: 700}}, Outputs: []model.TxOutput{{Address: "bob", Value: 700}}},
+				{Inputs: []model.TxInput{{Address: "bob", Value: 700}}, Outputs: []model.TxOutput{{Address: "alice", Value: 700}}},
+			},
+			want: map[string]string{},
+		},
+		{
+			name: "evm transfer pays value and fee",
+			txs: []*model.Transaction{{
+				From: "0xa", To: "0xb", Value: "1000000000000000000", Fee: "21000000000000", Status: "success",
+			}},
+			want: map[string]string{"0xa": "-1000021000000000000", "0xb": "1000000000000000000"},
+		},
+		{
+			name: "failed evm transaction only pays the fee",
+			txs: []*model.Transaction{{
+				From: "0xa", To: "0xb", Value: "5", Fee: "2", Status: "failed",
+			}},
+			want: map[string]string{"0xa": "-2"},
+		},
+		{
+			name: "contract creation credits the new contract",
+			txs: []*model.Transaction{{
+				From: "0xa", ContractAddress: "0xc", Value: "7", Fee: "3", Status: "success",
+			}},
+			want: map[string]string{"0xa": "-10", "0xc": "7"},
+		},
+		{
+			name: "unknown fee counts as zero",
+			txs: []*model.Transaction{{
+				From: "0xa", To: "0xb", Value: "4", Status: "success",
+			}},
+			want: map[string]string{"0xa": "-4", "0xb": "4"},
+		},
+		{
+			name: "amounts beyond int64",
+			txs: []*model.Transaction{{
+				From: "0xa", To: "0xb", Value: "123456789012345678901234567890", Status: "success",
+			}},
+			want: map[string]string{"0xa": "-123456789012345678901234567890", "0xb": "123456789012345678901234567890"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := balanceDeltas(tt.txs)
+			if len(got) != len(tt.want) {
+				t.Fatalf("balanceDeltas() = %v, want %v", got, tt.want)
+			}
+			for addr, want := range tt.want {
+				if d, ok := got[addr]; !ok || d.String() != want {
+					t.Errorf("delta of %s = %v, want %s", addr, d, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   model.Amount
+		want string
+	}{
+		{"0", "0"},
+		{"42", "42"},
+		{"-42", "-42"},
+		{"", "0"},
+		{"1.5", "0"},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", "115792089237316195423570985008687907853269984665640564039457584007913129639935"},
+	}
+	for _, tt := range tests {
+		if got := parseAmount(tt.in).String(); got != tt.want {
+			t.Errorf("parseAmount(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
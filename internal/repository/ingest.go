@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// Bulk ingest: catch-up and backfill batches are streamed with COPY into temporary
+// staging tables and merged into the real ones with one INSERT ... SELECT per table,
+// instead of one multi-row INSERT per table and block. Tables with a natural key are merged
+// with ON CONFLICT on it, and rows conflicting within the batch keep the highest block's
+// copy, as saving the blocks one after the other would. Blocks and transactions are plain
+// inserts: their only conflict target in saveBlock is the serial id, which new rows never
+// hit, and batches only hold heights that are not stored yet.
+
+// bulkTable describes how rows of one table are staged and merged
+type bulkTable struct {
+	columns  []string
+	conflict []string // unique key updated on conflict; nil for plain inserts
+	keep     []string // columns left alone on conflict besides the key
+}
+
+var (
+	bulkBlocks = bulkTable{
+		columns: []string{"chain", "height", "hash", "block_hash", "tx_count", "timestamp", "created_at"},
+	}
+	bulkTxs = bulkTable{
+		columns: []string{"chain", "hash", "block_hash", "block_height", "from_address", "to_address", "value", "status",
+			"timestamp", "created_at", "fee", "gas_used", "gas_price", "tx_type", "nonce", "contract_address"},
+	}
+	bulkOutputs = bulkTable{
+		columns:  []string{"txid", "vout", "address", "value", "script_type", "spent_by_txid", "spent_by_vin", "block_height", "created_at"},
+		conflict: []string{"txid", "vout"},
+		keep:     []string{"spent_by_txid", "spent_by_vin", "created_at"},
+	}
+	bulkInputs = bulkTable{
+		columns:  []string{"txid", "vin", "prev_txid", "prev_vout", "address", "value", "script_type", "coinbase", "block_height", "created_at"},
+		conflict: []string{"txid", "vin"},
+		keep:     []string{"created_at"},
+	}
+	bulkAddressTxs = bulkTable{
+		columns:  []string{"chain", "address", "tx_hash", "block_height", "sent", "received", "timestamp"},
+		conflict: []string{"chain", "address", "tx_hash"},
+	}
+	bulkTransfers = bulkTable{
+		columns: []string{"tx_hash", "log_index", "batch_index", "contract", "standard", "from_address", "to_address",
+			"amount", "token_id", "block_height", "timestamp", "created_at"},
+		conflict: []string{"tx_hash", "log_index", "batch_index"},
+		keep:     []string{"created_at"},
+	}
+	bulkLogs = bulkTable{
+		columns: []string{"tx_hash", "log_index", "address", "topic0", "topic1", "topic2", "topic3", "data", "removed",
+			"block_hash", "block_height", "timestamp", "created_at"},
+		conflict: []string{"tx_hash", "log_index"},
+		keep:     []string{"created_at"},
+	}
+)
+
+// copier streams rows into the transaction open on conn with the COPY protocol
+type copier struct {
+	conn *sql.Conn
+}
+
+// bulkTransaction runs fn in a DB transaction on a dedicated connection of db, handing it
+// a copier working inside the same transaction
+func (r *repository) bulkTransaction(db *gorm.DB, fn func(tx *gorm.DB, cp copier) error) error {
+	return db.Connection(func(c *gorm.DB) error {
+		conn, ok := c.Statement.ConnPool.(*sql.Conn)
+		if !ok {
+			return errors.New("bulk ingest needs a dedicated connection")
+		}
+		return c.Transaction(func(tx *gorm.DB) error {
+			return fn(tx, copier{conn: conn})
+		})
+	})
+}
+
+func (cp copier) copy(ctx context.Context, table string, columns []string, rows [][]any) error {
+	return cp.conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("bulk ingest needs the pgx driver, got %T", driverConn)
+		}
+		_, err := c.Conn().CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows))
+		return err
+	})
+}
+
+// merge stages rows in a temporary table and moves them into table
+func (cp copier) merge(tx *gorm.DB, table string, t bulkTable, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := tx.Exec(t.stageSQL(table)).Error; err != nil {
+		return err
+	}
+	stage := stageTable(table)
+	if err := cp.copy(tx.Statement.Context, stage, t.columns, rows); err != nil {
+		return fmt.Errorf("copy into %s: %w", stage, err)
+	}
+	if err := tx.Exec(t.mergeSQL(table)).Error; err != nil {
+		return fmt.Errorf("merge into %s: %w", table, err)
+	}
+	return nil
+}
+
+func stageTable(table string) string {
+	return "stage_" + table
+}
+
+// stageSQL creates the empty staging table of table, dropped when the transaction commits
+func (t bulkTable) stageSQL(table string) string {
+	return fmt.Sprintf("CREATE TEMP TABLE %s ON COMMIT DROP AS SELECT %s FROM %s WITH NO DATA",
+		stageTable(table), quoteColumns(t.columns), table)
+}
+
+// mergeSQL moves the staged rows into table, updating the rows already there on conflict
+func (t bulkTable) mergeSQL(table string) string {
+	quoted := quoteColumns(t.columns)
+	stage := stageTable(table)
+	if t.conflict == nil {
+		return fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", table, quoted, quoted, stage)
+	}
+
+	key := strings.Join(t.conflict, ", ")
+	skip := map[string]bool{}
+	for _, c := range t.conflict {
+		skip[c] = true
+	}
+	for _, c := range t.keep {
+		skip[c] = true
+	}
+	var sets []string
+	for _, c := range t.columns {
+		if !skip[c] {
+			sets = append(sets, fmt.Sprintf(`"%s" = EXCLUDED."%s"`, c, c))
+		}
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) SELECT DISTINCT ON (%s) %s FROM %s ORDER BY %s, block_height DESC ON CONFLICT (%s) DO UPDATE SET %s",
+		table, quoted, key, quoted, stage, key, key, strings.Join(sets, ", "))
+}
+
+// quoteColumns joins quoted column names, as "timestamp" is also a type name
+func quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = `"` + c + `"`
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// bulkRows is a batch laid out as rows of the bulk tables' columns
+type bulkRows struct {
+	blocks, txs, outputs, inputs, refs, transfers, logs [][]any
+	hashes                                              []string // of every transaction
+}
+
+func newBulkRows(batch []BlockWithTransactions, now time.Time) bulkRows {
+	var rows bulkRows
+	for _, b := range batch {
+		blk := b.Block
+		rows.blocks = append(rows.blocks, []any{string(blk.Chain), blk.Height, blk.Hash, blk.BlockHash, blk.TXCount, blk.Timestamp, now})
+
+		for _, t := range b.Txs {
+			rows.txs = append(rows.txs, []any{string(t.Chain), t.Hash, t.BlockHash, t.Height, t.From, t.To, t.Value, t.Status,
+				t.Timestamp, now, t.Fee, t.GasUsed, t.GasPrice, t.TxType, t.Nonce, t.ContractAddress})
+			rows.hashes = append(rows.hashes, t.Hash)
+
+			for _, o := range t.Outputs {
+				rows.outputs = append(rows.outputs, []any{o.Txid, o.Vout, o.Address, o.Value, o.ScriptType, o.SpentByTxid, o.SpentByVin, o.Height, now})
+			}
+			for _, in := range t.Inputs {
+				rows.inputs = append(rows.inputs, []any{in.Txid, in.Vin, in.PrevTxid, in.PrevVout, in.Address, in.Value, in.ScriptType, in.Coinbase, in.Height, now})
+			}
+			for _, tt := range t.TokenTransfers {
+				rows.transfers = append(rows.transfers, []any{tt.TxHash, tt.LogIndex, tt.BatchIndex, tt.Contract, tt.Standard, tt.From, tt.To,
+					tt.Amount, tt.TokenID, tt.Height, tt.Timestamp, now})
+			}
+			for _, l := range t.Logs {
+				rows.logs = append(rows.logs, []any{l.TxHash, l.LogIndex, l.Address, l.Topic0, l.Topic1, l.Topic2, l.Topic3, l.Data, l.Removed,
+					l.BlockHash, l.Height, l.Timestamp, now})
+			}
+		}
+		for _, ref := range addressRefs(blk, b.Txs) {
+			rows.refs = append(rows.refs, []any{string(ref.Chain), ref.Address, ref.TxHash, ref.Height, ref.Sent, ref.Received, ref.Timestamp})
+		}
+	}
+	return rows
+}
+
+// bulkSaveBlocks writes a batch of blocks of one chain through the staging tables. Balances
+// and the mempool cleanup go through the regular per-block path.
+func (r *repository) bulkSaveBlocks(tx *gorm.DB, cp copier, batch []BlockWithTransactions) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	chain := batch[0].Block.Chain
+	rows := newBulkRows(batch, time.Now())
+
+	// 1. Blocks, transactions and what hangs off them
+	steps := []struct {
+		table string
+		t     bulkTable
+		rows  [][]any
+	}{
+		{r.blockTable(chain), bulkBlocks, rows.blocks},
+		{r.txTable(chain), bulkTxs, rows.txs},
+		{r.outputTable(chain), bulkOutputs, rows.outputs},
+		{r.inputTable(chain), bulkInputs, rows.inputs},
+		{"address_transactions", bulkAddressTxs, rows.refs},
+		{r.tokenTransferTable(chain), bulkTransfers, rows.transfers},
+		{r.logTable(chain), bulkLogs, rows.logs},
+	}
+	for _, s := range steps {
+		if err := cp.merge(tx, s.table, s.t, s.rows); err != nil {
+			return err
+		}
+	}
+
+	// 2. Mark the outputs the batch's inputs spend, including outputs of the same batch
+	if len(rows.inputs) > 0 {
+		if err := tx.Exec(fmt.Sprintf(`UPDATE %s o SET spent_by_txid = i.txid, spent_by_vin = i.vin
+			FROM %s i
+			WHERE i.block_height IN ? AND NOT i.coinbase
+			AND o.txid = i.prev_txid AND o.vout = i.prev_vout`,
+			r.outputTable(chain), r.inputTable(chain)), batchHeights(batch)).Error; err != nil {
+			return err
+		}
+	}
+
+	// 3. Balances, block by block so every height keeps its own balance change rows
+	for _, b := range batch {
+		if err := r.applyBalanceChanges(tx, b.Block, b.Txs); err != nil {
+			return err
+		}
+	}
+
+	// 4. Drop mined transactions from the mempool
+	if len(rows.hashes) > 0 {
+		return deletePending(tx, chain, rows.hashes)
+	}
+	return nil
+}
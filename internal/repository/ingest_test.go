@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"indexer/internal/model"
+	"testing"
+	"time"
+)
+
+func TestMergeSQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		table string
+		t     bulkTable
+		want  string
+	}{
+		{
+			name:  "plain insert without a conflict key",
+			table: "btc_blocks",
+			t:     bulkBlocks,
+			want: `INSERT INTO btc_blocks ("chain", "height", "hash", "block_hash", "tx_count", "timestamp", "created_at") ` +
+				`SELECT "chain", "height", "hash", "block_hash", "tx_count", "timestamp", "created_at" FROM stage_btc_blocks`,
+		},
+		{
+			name:  "conflict key and kept columns are not updated",
+			table: "btc_tx_outputs",
+			t:     bulkOutputs,
+			want: `INSERT INTO btc_tx_outputs ("txid", "vout", "address", "value", "script_type", "spent_by_txid", "spent_by_vin", "block_height", "created_at") ` +
+				`SELECT DISTINCT ON (txid, vout) "txid", "vout", "address", "value", "script_type", "spent_by_txid", "spent_by_vin", "block_height", "created_at" ` +
+				`FROM stage_btc_tx_outputs ORDER BY txid, vout, block_height DESC ` +
+				`ON CONFLICT (txid, vout) DO UPDATE SET "address" = EXCLUDED."address", "value" = EXCLUDED."value", ` +
+				`"script_type" = EXCLUDED."script_type", "block_height" = EXCLUDED."block_height"`,
+		},
+		{
+			name:  "every column but the key is updated without kept columns",
+			table: "address_transactions",
+			t:     bulkAddressTxs,
+			want: `INSERT INTO address_transactions ("chain", "address", "tx_hash", "block_height", "sent", "received", "timestamp") ` +
+				`SELECT DISTINCT ON (chain, address, tx_hash) "chain", "address", "tx_hash", "block_height", "sent", "received", "timestamp" ` +
+				`FROM stage_address_transactions ORDER BY chain, address, tx_hash, block_height DESC ` +
+				`ON CONFLICT (chain, address, tx_hash) DO UPDATE SET "block_height" = EXCLUDED."block_height", ` +
+				`"sent" = EXCLUDED."sent", "received" = EXCLUDED."received", "timestamp" = EXCLUDED."timestamp"`,
+		},
+		{
+			name:  "three-column key",
+			table: "eth_token_transfers",
+			t:     bulkTransfers,
+			want: `INSERT INTO eth_token_transfers ("tx_hash", "log_index", "batch_index", "contract", "standard", "from_address", "to_address", "amount", "token_id", "block_height", "timestamp", "created_at") ` +
+				`SELECT DISTINCT ON (tx_hash, log_index, batch_index) "tx_hash", "log_index", "batch_index", "contract", "standard", "from_address", "to_address", "amount", "token_id", "block_height", "timestamp", "created_at" ` +
+				`FROM stage_eth_token_transfers ORDER BY tx_hash, log_index, batch_index, block_height DESC ` +
+				`ON CONFLICT (tx_hash, log_index, batch_index) DO UPDATE SET "contract" = EXCLUDED."contract", "standard" = EXCLUDED."standard", ` +
+				`"from_address" = EXCLUDED."from_address", "to_address" = EXCLUDED."to_address", "amount" = EXCLUDED."amount", ` +
+				`"token_id" = EXCLUDED."token_id", "block_height" = EXCLUDED."block_height", "timestamp" = EXCLUDED."timestamp"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.mergeSQL(tt.table); got != tt.want {
+				t.Errorf("mergeSQL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStageSQL(t *testing.T) {
+	want := `CREATE TEMP TABLE stage_eth_logs ON COMMIT DROP AS SELECT "tx_hash", "log_index", "address", "topic0", "topic1", "topic2", "topic3", ` +
+		`"data", "removed", "block_hash", "block_height", "timestamp", "created_at" FROM eth_logs WITH NO DATA`
+	if got := bulkLogs.stageSQL("eth_logs"); got != want {
+		t.Errorf("stageSQL() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// Every conflict target must be staged, and every merged table needs block_height to pick
+// the latest copy of a row
+func TestBulkTablesConflictColumns(t *testing.T) {
+	tables := map[string]bulkTable{
+		"outputs": bulkOutputs, "inputs": bulkInputs, "address_transactions": bulkAddressTxs,
+		"token_transfers": bulkTransfers, "logs": bulkLogs,
+	}
+	for name, table := range tables {
+		staged := map[string]bool{}
+		for _, c := range table.columns {
+			staged[c] = true
+		}
+		for _, c := range append(append([]string{"block_height"}, table.conflict...), table.keep...) {
+			if !staged[c] {
+				t.Errorf("%s: column %s is not staged", name, c)
+			}
+		}
+	}
+}
+
+// columnValues pairs a staged row with its table's columns
+func columnValues(t *testing.T, table bulkTable, row []any) map[string]any {
+	t.Helper()
+	if len(row) != len(table.columns) {
+		t.Fatalf("row has %d values for %d columns %v", len(row), len(table.columns), table.columns)
+	}
+	values := make(map[string]any, len(row))
+	for i, c := range table.columns {
+		values[c] = row[i]
+	}
+	return values
+}
+
+func TestNewBulkRows(t *testing.T) {
+	now := time.Unix(1700000100, 0)
+	ts := time.Unix(1700000000, 0)
+
+	t.Run("utxo", func(t *testing.T) {
+		block := &model.Block{Chain: model.ChainBTC, Height: 7, Hash: "b7", BlockHash: "b6", TXCount: 1, Timestamp: ts}
+		tx := &model.Transaction{
+			Chain: model.ChainBTC, Hash: "t1", BlockHash: "b7", Height: 7, From: "alice", To: "bob",
+			Value: "900", Fee: "100", Status: "success", Timestamp: ts,
+			Inputs:  []model.TxInput{{Txid: "t1", Vin: 0, PrevTxid: "t0", PrevVout: 1, Address: "alice", Value: 1000, ScriptType: "pubkeyhash", Height: 7}},
+			Outputs: []model.TxOutput{{Txid: "t1", Vout: 0, Address: "bob", Value: 900, ScriptType: "witness_v0_keyhash", Height: 7}},
+		}
+		rows := newBulkRows([]BlockWithTransactions{{Block: block, Txs: []*model.Transaction{tx}}}, now)
+
+		if len(rows.blocks) != 1 || len(rows.txs) != 1 || len(rows.inputs) != 1 || len(rows.outputs) != 1 {
+			t.Fatalf("got %d blocks, %d txs, %d inputs, %d outputs, want one each",
+				len(rows.blocks), len(rows.txs), len(rows.inputs), len(rows.outputs))
+		}
+		if len(rows.transfers) != 0 || len(rows.logs) != 0 {
+			t.Errorf("utxo batch staged %d transfers and %d logs", len(rows.transfers), len(rows.logs))
+		}
+
+		b := columnValues(t, bulkBlocks, rows.blocks[0])
+		if b["chain"] != string(model.ChainBTC) || b["height"] != uint64(7) || b["hash"] != "b7" || b["block_hash"] != "b6" ||
+			b["tx_count"] != uint64(1) || b["timestamp"] != ts || b["created_at"] != now {
+			t.Errorf("block row = %v", b)
+		}
+		tr := columnValues(t, bulkTxs, rows.txs[0])
+		if tr["hash"] != "t1" || tr["block_height"] != uint64(7) || tr["from_address"] != "alice" || tr["to_address"] != "bob" ||
+			tr["value"] != model.Amount("900") || tr["fee"] != model.Amount("100") || tr["created_at"] != now {
+			t.Errorf("transaction row = %v", tr)
+		}
+		in := columnValues(t, bulkInputs, rows.inputs[0])
+		if in["txid"] != "t1" || in["prev_txid"] != "t0" || in["prev_vout"] != uint32(1) || in["value"] != int64(1000) ||
+			in["coinbase"] != false || in["block_height"] != uint64(7) {
+			t.Errorf("input row = %v", in)
+		}
+		out := columnValues(t, bulkOutputs, rows.outputs[0])
+		if out["txid"] != "t1" || out["vout"] != uint32(0) || out["address"] != "bob" || out["value"] != int64(900) ||
+			out["script_type"] != "witness_v0_keyhash" || out["block_height"] != uint64(7) {
+			t.Errorf("output row = %v", out)
+		}
+		for _, ref := range rows.refs {
+			columnValues(t, bulkAddressTxs, ref)
+		}
+		if len(rows.refs) != 2 {
+			t.Errorf("got %d address refs, want 2", len(rows.refs))
+		}
+	})
+
+	t.Run("evm", func(t *testing.T) {
+		var batch []BlockWithTransactions
+		for h := uint64(10); h < 12; h++ {
+			block := &model.Block{Chain: model.ChainETH, Height: h, Hash: "b", Timestamp: ts, TXCount: 1}
+			tx := &model.Transaction{
+				Chain: model.ChainETH, Hash: "t", Height: h, From: "0xa", To: "0xc", GasUsed: 21000, GasPrice: "5", TxType: 2, Nonce: h,
+				TokenTransfers: []model.TokenTransfer{{TxHash: "t", LogIndex: 3, BatchIndex: 1, Contract: "0xc", Standard: "erc1155", Amount: "4", TokenID: "9", Height: h}},
+				Logs:           []model.Log{{TxHash: "t", LogIndex: 3, Address: "0xc", Topic0: "0xt0", Data: "0x", BlockHash: "b", Height: h}},
+			}
+			batch = append(batch, BlockWithTransactions{Block: block, Txs: []*model.Transaction{tx}})
+		}
+		rows := newBulkRows(batch, now)
+
+		if len(rows.blocks) != 2 || len(rows.txs) != 2 || len(rows.transfers) != 2 || len(rows.logs) != 2 {
+			t.Fatalf("got %d blocks, %d txs, %d transfers, %d logs, want two each",
+				len(rows.blocks), len(rows.txs), len(rows.transfers), len(rows.logs))
+		}
+		if len(rows.hashes) != 2 {
+			t.Errorf("got %d mined hashes, want 2", len(rows.hashes))
+		}
+		for i, h := range []uint64{10, 11} {
+			tr := columnValues(t, bulkTxs, rows.txs[i])
+			if tr["gas_used"] != uint64(21000) || tr["gas_price"] != model.Amount("5") || tr["tx_type"] != uint8(2) || tr["nonce"] != h {
+				t.Errorf("transaction row = %v", tr)
+			}
+			tt := columnValues(t, bulkTransfers, rows.transfers[i])
+			if tt["log_index"] != uint(3) || tt["batch_index"] != uint(1) || tt["standard"] != "erc1155" ||
+				tt["token_id"] != "9" || tt["block_height"] != h {
+				t.Errorf("token transfer row = %v", tt)
+			}
+			l := columnValues(t, bulkLogs, rows.logs[i])
+			if l["tx_hash"] != "t" || l["topic0"] != "0xt0" || l["removed"] != false || l["block_height"] != h {
+				t.Errorf("log row = %v", l)
+			}
+		}
+	})
+}
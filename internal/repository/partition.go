@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"fmt"
+	"indexer/internal/model"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// partitionBound matches the bounds Postgres reports for a range partition, e.g.
+// "FOR VALUES FROM ('0') TO ('100000')"
+var partitionBound = regexp.MustCompile(`FROM \('?(\d+)'?\) TO \('?(\d+)'?\)`)
+
+// heightRange is the half-open range [from, to) a partition covers
+type heightRange struct {
+	from, to uint64
+}
+
+// txPartitions caches the partitions of each chain's partitioned transactions table, so
+// saving a block only reaches the catalog when it needs a new one
+type txPartitions struct {
+	mu     sync.Mutex
+	ranges map[model.ChainType][]heightRange // sorted by from
+}
+
+// txConflict is the conflict target for transaction inserts; on partitioned tables the
+// primary key includes the height
+func (r *repository) txConflict(chain model.ChainType) clause.OnConflict {
+	if r.info(chain).TxPartitionSize == 0 {
+		return clause.OnConflict{UpdateAll: true}
+	}
+	return clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}, {Name: "block_height"}},
+		UpdateAll: true,
+	}
+}
+
+// ensureTxPartitions creates the transactions table partitions the given heights fall in,
+// unless the chain's table is not partitioned. It runs outside the saving transaction, so
+// the short lock taken on the parent table is released before any rows are written.
+func (r *repository) ensureTxPartitions(db *gorm.DB, chain model.ChainType, heights ...uint64) error {
+	info := r.info(chain)
+	size := info.TxPartitionSize
+	if size == 0 || len(heights) == 0 {
+		return nil
+	}
+
+	p := &r.partitions
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ranges, ok := p.ranges[chain]
+	if !ok {
+		var err error
+		if ranges, err = r.loadTxPartitions(db, info.TxTable()); err != nil {
+			return fmt.Errorf("failed to list partitions of %s: %w", info.TxTable(), err)
+		}
+	}
+
+	for _, h := range heights {
+		if covered(ranges, h) {
+			continue
+		}
+
+		rg := partitionRange(ranges, h, size)
+		name := fmt.Sprintf("%s_p%d", info.TxTable(), rg.from)
+		err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%d) TO (%d)",
+			name, info.TxTable(), rg.from, rg.to)).Error
+		if err != nil {
+			// Another process may have created it first
+			if reloaded, lerr := r.loadTxPartitions(db, info.TxTable()); lerr == nil && covered(reloaded, h) {
+				ranges = reloaded
+				continue
+			}
+			delete(p.ranges, chain)
+			return fmt.Errorf("failed to create partition %s: %w", name, err)
+		}
+		log.Printf("[%s] Created transactions partition %s for blocks %d-%d", strings.ToUpper(info.Name), name, rg.from, rg.to-1)
+
+		ranges = append(ranges, rg)
+		sort.Slice(ranges, func(i, j int) bool { return ranges[i].from < ranges[j].from })
+	}
+
+	if p.ranges == nil {
+		p.ranges = map[model.ChainType][]heightRange{}
+	}
+	p.ranges[chain] = ranges
+	return nil
+}
+
+func (r *repository) loadTxPartitions(db *gorm.DB, table string) ([]heightRange, error) {
+	var bounds []string
+	err := db.Raw(`SELECT pg_get_expr(c.relpartbound, c.oid)
+		FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = to_regclass(?)`, table).Scan(&bounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePartitionBounds(bounds), nil
+}
+
+// parsePartitionBounds reads the ranges of a table's partitions from their bounds, sorted
+func parsePartitionBounds(bounds []string) []heightRange {
+	var ranges []heightRange
+	for _, b := range bounds {
+		m := partitionBound.FindStringSubmatch(b)
+		if m == nil {
+			continue // a DEFAULT partition takes no range
+		}
+		from, _ := strconv.ParseUint(m[1], 10, 64)
+		to, _ := strconv.ParseUint(m[2], 10, 64)
+		ranges = append(ranges, heightRange{from: from, to: to})
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].from < ranges[j].from })
+	return ranges
+}
+
+// partitionRange is the range of the partition to create for h: the one aligned to size,
+// shrunk around partitions that were created with another size
+func partitionRange(ranges []heightRange, h, size uint64) heightRange {
+	rg := heightRange{from: h / size * size, to: h/size*size + size}
+	for _, existing := range ranges {
+		if existing.to <= h && existing.to > rg.from {
+			rg.from = existing.to
+		}
+		if existing.from > h && existing.from < rg.to {
+			rg.to = existing.from
+		}
+	}
+	return rg
+}
+
+func covered(ranges []heightRange, h uint64) bool {
+	for _, rg := range ranges {
+		if rg.from <= h && h < rg.to {
+			return true
+		}
+	}
+	return false
+}
+
+// batchHeights lists the heights of a batch for ensureTxPartitions
+func batchHeights(batch []BlockWithTransactions) []uint64 {
+	heights := make([]uint64, len(batch))
+	for i, b := range batch {
+		heights[i] = b.Block.Height
+	}
+	return heights
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"fmt"
+	"indexer/internal/chains"
+	"indexer/internal/model"
+	"strings"
+	"testing"
+)
+
+func TestParsePartitionBounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		bounds []string
+		want   string
+	}{
+		{
+			name:   "quoted and unquoted bounds, sorted",
+			bounds: []string{"FOR VALUES FROM ('100000') TO ('200000')", "FOR VALUES FROM (0) TO (100000)"},
+			want:   "[{0 100000} {100000 200000}]",
+		},
+		{
+			name:   "default partition is skipped",
+			bounds: []string{"DEFAULT", "FOR VALUES FROM ('5') TO ('10')"},
+			want:   "[{5 10}]",
+		},
+		{
+			name:   "converted plain table with an unaligned upper bound",
+			bounds: []string{"FOR VALUES FROM ('0') TO ('850000')", "FOR VALUES FROM ('850000') TO ('900000')"},
+			want:   "[{0 850000} {850000 900000}]",
+		},
+		{
+			name: "no partitions",
+			want: "[]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(parsePartitionBounds(tt.bounds)); got != tt.want {
+				t.Errorf("parsePartitionBounds() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartitionRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []heightRange
+		h, size  uint64
+		want     heightRange
+	}{
+		{name: "first height of a range", h: 100, size: 100, want: heightRange{100, 200}},
+		{name: "last height of a range", h: 199, size: 100, want: heightRange{100, 200}},
+		{name: "genesis", h: 0, size: 100, want: heightRange{0, 100}},
+		{
+			name:     "shrunk above a converted _p0 partition",
+			existing: []heightRange{{0, 150}},
+			h:        150, size: 100,
+			want: heightRange{150, 200},
+		},
+		{
+			name:     "shrunk below a partition of another size",
+			existing: []heightRange{{250, 500}},
+			h:        210, size: 100,
+			want: heightRange{200, 250},
+		},
+		{
+			name:     "shrunk on both sides",
+			existing: []heightRange{{0, 120}, {180, 240}},
+			h:        150, size: 100,
+			want: heightRange{120, 180},
+		},
+		{
+			name:     "partitions away from h are ignored",
+			existing: []heightRange{{0, 100}, {300, 400}},
+			h:        250, size: 100,
+			want: heightRange{200, 300},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := partitionRange(tt.existing, tt.h, tt.size); got != tt.want {
+				t.Errorf("partitionRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Whatever partitions exist, the created one must hold h and overlap none of them, or
+// Postgres rejects the partition or the row
+func TestPartitionRangeCoversHeight(t *testing.T) {
+	for _, size := range []uint64{1, 7, 100} {
+		for _, existingSize := range []uint64{3, 50, 130} {
+			var existing []heightRange
+			for from := uint64(0); from < 1000; from += 2 * existingSize {
+				existing = append(existing, heightRange{from, from + existingSize})
+			}
+			for h := uint64(0); h < 1000; h++ {
+				if covered(existing, h) {
+					continue
+				}
+				rg := partitionRange(existing, h, size)
+				if !covered([]heightRange{rg}, h) {
+					t.Fatalf("size %d, existing size %d: range %v does not cover %d", size, existingSize, rg, h)
+				}
+				for _, e := range existing {
+					if rg.from < e.to && e.from < rg.to {
+						t.Fatalf("size %d, existing size %d: range %v for %d overlaps %v", size, existingSize, rg, h, e)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCovered(t *testing.T) {
+	ranges := []heightRange{{0, 100}, {200, 300}}
+	tests := []struct {
+		h    uint64
+		want bool
+	}{
+		{0, true}, {99, true}, {100, false}, {199, false}, {200, true}, {299, true}, {300, false},
+	}
+	for _, tt := range tests {
+		if got := covered(ranges, tt.h); got != tt.want {
+			t.Errorf("covered(%d) = %v, want %v", tt.h, got, tt.want)
+		}
+	}
+	if covered(nil, 0) {
+		t.Error("covered() with no partitions = true")
+	}
+}
+
+func TestEnsureTxPartitions(t *testing.T) {
+	parted := chains.UTXONetwork("parted")
+	parted.TxPartitionSize = 1000
+	plain := chains.UTXONetwork("plain")
+	for _, info := range []chains.Info{parted, plain} {
+		if err := chains.Register(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, rec := newDryRunRepository(t, Options{})
+	creates := func() []string {
+		var res []string
+		for _, s := range rec.take() {
+			if strings.HasPrefix(s.sql, "CREATE TABLE") {
+				res = append(res, s.sql)
+			}
+		}
+		return res
+	}
+
+	if err := r.ensureTxPartitions(r.db, plain.Type, 5); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.take(); len(got) != 0 {
+		t.Errorf("plain table touched the catalog: %v", got)
+	}
+
+	// As left by converting a plain table holding blocks up to 1499, dry runs cannot list them
+	r.partitions.ranges = map[model.ChainType][]heightRange{parted.Type: {{0, 1500}}}
+	if err := r.ensureTxPartitions(r.db, parted.Type, 5, 1499, 1500, 1999, 2500); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"CREATE TABLE IF NOT EXISTS parted_transactions_p1500 PARTITION OF parted_transactions FOR VALUES FROM (1500) TO (2000)",
+		"CREATE TABLE IF NOT EXISTS parted_transactions_p2000 PARTITION OF parted_transactions FOR VALUES FROM (2000) TO (3000)",
+	}
+	if got := creates(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("created\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Created partitions are cached
+	if err := r.ensureTxPartitions(r.db, parted.Type, 10, 1999, 2000, 2999); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.take(); len(got) != 0 {
+		t.Errorf("cached partitions created again: %v", got)
+	}
+}
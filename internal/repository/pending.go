@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"indexer/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pendingDeleteChunk keeps IN lists well below the Postgres bind parameter limit
+const pendingDeleteChunk = 5000
+
+func (r *repository) GetPendingHashes(ctx context.Context, chain model.ChainType) ([]string, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var hashes []string
+	err := db.Model(&model.PendingTransaction{}).
+		Where("chain = ?", chain).
+		Pluck("hash", &hashes).Error
+	return hashes, err
+}
+
+// SavePendingTransactions stores newly seen mempool transactions, keeping the first-seen
+// time of those already stored.
+func (r *repository) SavePendingTransactions(ctx context.Context, txs []model.PendingTransaction) error {
+	db, cancel := r.withWriteContext(ctx)
+	defer cancel()
+
+	if len(txs) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(txs, 1000).Error
+}
+
+func (r *repository) DeletePendingTransactions(ctx context.Context, chain model.ChainType, hashes []string) error {
+	db, cancel := r.withWriteContext(ctx)
+	defer cancel()
+
+	return deletePending(db, chain, hashes)
+}
+
+func deletePending(db *gorm.DB, chain model.ChainType, hashes []string) error {
+	for start := 0; start < len(hashes); start += pendingDeleteChunk {
+		end := start + pendingDeleteChunk
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		if err := db.Where("chain = ? AND hash IN ?", chain, hashes[start:end]).
+			Delete(&model.PendingTransaction{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repository) GetPendingTransactions(ctx context.Context, chain model.ChainType, limit, offset int) ([]model.PendingTransaction, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var txs []model.PendingTransaction
+	err := db.Where("chain = ?", chain).
+		Order("first_seen DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&txs).Error
+	return txs, err
+}
+
+func (r *repository) CountPendingTransactions(ctx context.Context, chain model.ChainType) (int64, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var count int64
+	err := db.Model(&model.PendingTransaction{}).Where("chain = ?", chain).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) FindPendingTransaction(ctx context.Context, chain model.ChainType, hash string) (*model.PendingTransaction, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var tx model.PendingTransaction
+	err := db.Where("chain = ? AND hash = ?", chain, hash).First(&tx).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
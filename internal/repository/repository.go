@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+	"indexer/internal/chains"
 	"indexer/internal/model"
 	"log"
 	"time"
@@ -9,52 +12,189 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// Repository is the indexer's storage. Every method runs its queries under ctx, so a
+// cancelled request or a stopping worker does not leave them running. Reads are bounded by
+// Options.QueryTimeout; block writes, rollbacks and verify scans by Options.WriteTimeout.
 type Repository interface {
 	// Blocks & Transactions
-	GetLatestBlocks(chain model.ChainType, limit, offset int) ([]model.Block, error)
-	GetBlockByHeight(chain model.ChainType, height uint64) (*model.Block, error)
-	GetTransactions(chain model.ChainType, limit, offset int) ([]model.Transaction, error)
-	CountTransactions(chain model.ChainType) (int64, error)
+	GetLatestBlocks(ctx context.Context, chain model.ChainType, limit, offset int) ([]model.Block, error)
+	GetBlockByHeight(ctx context.Context, chain model.ChainType, height uint64) (*model.Block, error)
+	GetTransactions(ctx context.Context, chain model.ChainType, filter TransactionFilter, limit, offset int) ([]model.Transaction, error)
+	CountTransactions(ctx context.Context, chain model.ChainType) (int64, error)
 
 	// Sync Logic
-	GetState(chain model.ChainType) (uint64, error)
-	GetOrCreateState(chain model.ChainType, latestBlock uint64, configuredStart int) (uint64, error)
-	SaveBlockWithTransactions(block *model.Block, txs []*model.Transaction) error
+	GetState(ctx context.Context, chain model.ChainType) (uint64, error)
+	GetOrCreateState(ctx context.Context, chain model.ChainType, latestBlock uint64, configuredStart int) (uint64, error)
+	SaveBlockWithTransactions(ctx context.Context, block *model.Block, txs []*model.Transaction) error
+	SaveBlocks(ctx context.Context, batch []BlockWithTransactions) error
+	GetBlockHash(ctx context.Context, chain model.ChainType, height uint64) (string, error)
+	RollbackToHeight(ctx context.Context, chain model.ChainType, height uint64) error
+	ReplaceBlock(ctx context.Context, block *model.Block, txs []*model.Transaction) error
+	GetStoredHeights(ctx context.Context, chain model.ChainType, from, to uint64) ([]uint64, error)
+	GetMinBlockHeight(ctx context.Context, chain model.ChainType) (uint64, error)
+	DeleteBlock(ctx context.Context, chain model.ChainType, height uint64) error
+
+	// Integrity Checks
+	FindBrokenLinks(ctx context.Context, chain model.ChainType, from, to uint64) ([]uint64, error)
+	FindTxCountMismatches(ctx context.Context, chain model.ChainType, from, to uint64) ([]TxCountMismatch, error)
 
 	// Read Logic (New)
-	CountBlocks(chain model.ChainType) (int64, error)
-	GetTransactionsByBlock(chain model.ChainType, height uint64) ([]model.Transaction, error)
-	FindTransactionByHash(chain model.ChainType, hash string) (*model.Transaction, error)
-	GetMaxBlockHeight(chain model.ChainType) (uint64, error)
+	CountBlocks(ctx context.Context, chain model.ChainType) (int64, error)
+	GetTransactionsByBlock(ctx context.Context, chain model.ChainType, height uint64) ([]model.Transaction, error)
+	FindTransactionByHash(ctx context.Context, chain model.ChainType, hash string) (*model.Transaction, error)
+	GetMaxBlockHeight(ctx context.Context, chain model.ChainType) (uint64, error)
+
+	// UTXO Inputs & Outputs
+	GetTxInputs(ctx context.Context, chain model.ChainType, txid string) ([]model.TxInput, error)
+	GetTxOutputs(ctx context.Context, chain model.ChainType, txid string) ([]model.TxOutput, error)
+
+	// Token Transfers
+	GetTokenTransfersByContract(ctx context.Context, chain model.ChainType, contract string, limit, offset int) ([]model.TokenTransfer, error)
+	GetTokenTransfersByAddress(ctx context.Context, chain model.ChainType, address string, limit, offset int) ([]model.TokenTransfer, error)
+	GetTokenTransfersByTx(ctx context.Context, chain model.ChainType, txHash string) ([]model.TokenTransfer, error)
+
+	// Event Logs
+	GetLogs(ctx context.Context, chain model.ChainType, filter LogFilter, limit, offset int) ([]model.Log, error)
+	GetLogsByTx(ctx context.Context, chain model.ChainType, txHash string) ([]model.Log, error)
+
+	// Addresses
+	GetAddressSummary(ctx context.Context, chain model.ChainType, address string) (*AddressSummary, error)
+	GetAddressTransactions(ctx context.Context, chain model.ChainType, address string, limit, offset int) ([]model.Transaction, error)
+
+	// Balances
+	GetBalance(ctx context.Context, chain model.ChainType, address string) (model.Amount, error)
+	GetRichList(ctx context.Context, chain model.ChainType, limit int) ([]model.Balance, error)
+
+	// Backfill Jobs
+	CreateBackfillJob(ctx context.Context, job *model.BackfillJob) error
+	GetBackfillJob(ctx context.Context, chain model.ChainType, id uint) (*model.BackfillJob, error)
+	GetBackfillJobs(ctx context.Context, chain model.ChainType, limit, offset int) ([]model.BackfillJob, error)
+	NextBackfillJob(ctx context.Context, chain model.ChainType) (*model.BackfillJob, error)
+	SetBackfillStatus(ctx context.Context, id uint, status, lastError string) error
+	SaveBackfillBlocks(ctx context.Context, jobID uint, batch []BlockWithTransactions, next uint64) (int, error)
+
+	// Mempool
+	GetPendingHashes(ctx context.Context, chain model.ChainType) ([]string, error)
+	SavePendingTransactions(ctx context.Context, txs []model.PendingTransaction) error
+	DeletePendingTransactions(ctx context.Context, chain model.ChainType, hashes []string) error
+	GetPendingTransactions(ctx context.Context, chain model.ChainType, limit, offset int) ([]model.PendingTransaction, error)
+	CountPendingTransactions(ctx context.Context, chain model.ChainType) (int64, error)
+	FindPendingTransaction(ctx context.Context, chain model.ChainType, hash string) (*model.PendingTransaction, error)
+}
+
+// BlockWithTransactions pairs a block with its transactions for batch saves.
+type BlockWithTransactions struct {
+	Block *model.Block
+	Txs   []*model.Transaction
+}
+
+// LogFilter mirrors eth_getLogs: each non-empty list matches any of its values and
+// all non-empty lists must match. Nil heights leave that end of the range open.
+type LogFilter struct {
+	Addresses []string
+	Topics    [4][]string
+	FromBlock *uint64
+	ToBlock   *uint64
+}
+
+// TransactionFilter narrows transaction listings. Zero values leave a filter unset;
+// MinValue is in base units.
+type TransactionFilter struct {
+	FromHeight *uint64
+	ToHeight   *uint64
+	MinValue   model.Amount
+	Status     string
+}
+
+// Options tune how the repository reads and writes
+type Options struct {
+	// CopyIngest writes catch-up and backfill batches with COPY through staging tables
+	CopyIngest bool
+
+	// QueryTimeout bounds each read; zero leaves reads bounded by their context alone
+	QueryTimeout time.Duration
+
+	// WriteTimeout bounds block writes, rollbacks and verify scans, which grow with batch
+	// size and chain length; zero leaves them bounded by their context alone
+	WriteTimeout time.Duration
 }
 
 type repository struct {
-	db *gorm.DB
+	db         *gorm.DB
+	opts       Options
+	partitions txPartitions
 }
 
-func NewRepository(db *gorm.DB) Repository {
-	return &repository{db: db}
+func NewRepository(db *gorm.DB, opts Options) Repository {
+	return &repository{db: db, opts: opts}
 }
 
-// Table helpers
-func (r *repository) blockTable(chain model.ChainType) string {
-	if chain == model.ChainBTC {
-		return "btc_blocks"
+// withContext returns the DB handle bound to ctx and the query timeout. Every exported
+// read starts with it and runs its queries through the handle.
+func (r *repository) withContext(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return r.bound(ctx, r.opts.QueryTimeout)
+}
+
+// withWriteContext is withContext for block writes, rollbacks and verify scans, bounded by
+// the write timeout instead
+func (r *repository) withWriteContext(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	return r.bound(ctx, r.opts.WriteTimeout)
+}
+
+func (r *repository) bound(ctx context.Context, timeout time.Duration) (*gorm.DB, context.CancelFunc) {
+	if timeout <= 0 {
+		return r.db.WithContext(ctx), func() {}
 	}
-	return "eth_blocks"
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return r.db.WithContext(ctx), cancel
+}
+
+// Table helpers, resolved through the chain registry. Unknown chains resolve to an empty
+// prefix and fail at the database, so callers are expected to validate chain names first.
+func (r *repository) info(chain model.ChainType) chains.Info {
+	info, _ := chains.Get(chain)
+	return info
+}
+
+func (r *repository) blockTable(chain model.ChainType) string {
+	return r.info(chain).BlockTable()
 }
 
 func (r *repository) txTable(chain model.ChainType) string {
-	if chain == model.ChainBTC {
-		return "btc_transactions"
-	}
-	return "eth_transactions"
+	return r.info(chain).TxTable()
+}
+
+func (r *repository) inputTable(chain model.ChainType) string {
+	return r.info(chain).InputTable()
+}
+
+func (r *repository) outputTable(chain model.ChainType) string {
+	return r.info(chain).OutputTable()
+}
+
+func (r *repository) tokenTransferTable(chain model.ChainType) string {
+	return r.info(chain).TokenTransferTable()
+}
+
+func (r *repository) logTable(chain model.ChainType) string {
+	return r.info(chain).LogTable()
+}
+
+func (r *repository) isEVM(chain model.ChainType) bool {
+	return r.info(chain).IsEVM()
+}
+
+func (r *repository) hasUTXO(chain model.ChainType) bool {
+	return r.info(chain).HasUTXO()
 }
 
 // API READ METHODS
-func (r *repository) GetLatestBlocks(chain model.ChainType, limit, offset int) ([]model.Block, error) {
+func (r *repository) GetLatestBlocks(ctx context.Context, chain model.ChainType, limit, offset int) ([]model.Block, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
 	var blocks []model.Block
-	err := r.db.Table(r.blockTable(chain)).
+	err := db.Table(r.blockTable(chain)).
 		Order("height DESC").
 		Limit(limit).
 		Offset(offset).
@@ -62,61 +202,80 @@ func (r *repository) GetLatestBlocks(chain model.ChainType, limit, offset int) (
 	return blocks, err
 }
 
-func (r *repository) GetBlockByHeight(chain model.ChainType, height uint64) (*model.Block, error) {
+func (r *repository) GetBlockByHeight(ctx context.Context, chain model.ChainType, height uint64) (*model.Block, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
 	var block model.Block
-	err := r.db.Table(r.blockTable(chain)).Where("height = ?", height).First(&block).Error
+	err := db.Table(r.blockTable(chain)).Where("height = ?", height).First(&block).Error
 	if err != nil {
 		return nil, err
 	}
 	return &block, nil
 }
 
-func (r *repository) GetTransactions(chain model.ChainType, limit, offset int) ([]model.Transaction, error) {
+func (r *repository) GetTransactions(ctx context.Context, chain model.ChainType, filter TransactionFilter, limit, offset int) ([]model.Transaction, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	q := db.Table(r.txTable(chain))
+	if filter.FromHeight != nil {
+		q = q.Where("block_height >= ?", *filter.FromHeight)
+	}
+	if filter.ToHeight != nil {
+		q = q.Where("block_height <= ?", *filter.ToHeight)
+	}
+	if filter.MinValue != "" {
+		q = q.Where("value >= ?", filter.MinValue)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+
 	var txs []model.Transaction
-	err := r.db.Table(r.txTable(chain)).
-		Order("block_height DESC").
+	err := q.Order("block_height DESC, id DESC").
 		Limit(limit).
 		Offset(offset).
 		Find(&txs).Error
 	return txs, err
 }
 
-func (r *repository) CountTransactions(chain model.ChainType) (int64, error) {
+func (r *repository) CountTransactions(ctx context.Context, chain model.ChainType) (int64, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
 	var count int64
-	err := r.db.Table(r.txTable(chain)).Count(&count).Error
+	err := db.Table(r.txTable(chain)).Count(&count).Error
 	return count, err
 }
 
 // SYNC METHODS
-func (r *repository) GetState(chain model.ChainType) (uint64, error) {
+func (r *repository) GetState(ctx context.Context, chain model.ChainType) (uint64, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
 	var state model.IndexerState
-	err := r.db.Where("chain = ?", chain).Limit(1).Find(&state).Error
+	err := db.Where("chain = ?", chain).Limit(1).Find(&state).Error
 	if err != nil {
 		return 0, err
 	}
 	return state.LastIndexedHeight, nil
 }
 
-func (r *repository) GetOrCreateState(chain model.ChainType, latestBlock uint64, configuredStart int) (uint64, error) {
+func (r *repository) GetOrCreateState(ctx context.Context, chain model.ChainType, latestBlock uint64, configuredStart int) (uint64, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
 	var state model.IndexerState
-	err := r.db.Where("chain = ?", chain).Limit(1).Find(&state).Error
+	err := db.Where("chain = ?", chain).Limit(1).Find(&state).Error
 	if err != nil {
 		return 0, err
 	}
 
-	// 1. If record exists, we might still need to reset if it's invalid (e.g. height > tip)
+	// 1. If record exists, return it as is. A tip below it means the node is lagging, not
+	// that our blocks are gone; the caller waits for the node or another endpoint to catch
+	// up, and state only ever moves back together with a RollbackToHeight.
 	if state.Chain != "" {
-		// User requirement: If height is greater than current chain tip → reset to (latest - 10)
-		if state.LastIndexedHeight > latestBlock {
-			resetHeight := uint64(0)
-			if latestBlock > 10 {
-				resetHeight = latestBlock - 10
-			}
-			state.LastIndexedHeight = resetHeight
-			state.UpdatedAt = time.Now()
-			r.db.Save(&state)
-			log.Printf("[%s] Reset state to %d because last indexed height was greater than tip", chain, resetHeight)
-		}
 		return state.LastIndexedHeight, nil
 	}
 
@@ -146,7 +305,7 @@ func (r *repository) GetOrCreateState(chain model.ChainType, latestBlock uint64,
 		UpdatedAt:         time.Now(),
 	}
 
-	if err := r.db.Create(&state).Error; err != nil {
+	if err := db.Create(&state).Error; err != nil {
 		return 0, err
 	}
 
@@ -154,59 +313,438 @@ func (r *repository) GetOrCreateState(chain model.ChainType, latestBlock uint64,
 	return startHeight, nil
 }
 
-func (r *repository) SaveBlockWithTransactions(block *model.Block, txs []*model.Transaction) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		// 1. Save Block
-		if err := tx.Table(r.blockTable(block.Chain)).Clauses(clause.OnConflict{
+func (r *repository) SaveBlockWithTransactions(ctx context.Context, block *model.Block, txs []*model.Transaction) error {
+	db, cancel := r.withWriteContext(ctx)
+	defer cancel()
+
+	if err := r.ensureTxPartitions(db, block.Chain, block.Height); err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := r.saveBlock(tx, block, txs); err != nil {
+			return err
+		}
+		return r.updateState(tx, block.Chain, block.Height)
+	})
+}
+
+// SaveBlocks writes a height-ordered run of blocks in a single DB transaction and
+// advances the indexer state to the last one. Used by the catch-up pipeline.
+func (r *repository) SaveBlocks(ctx context.Context, batch []BlockWithTransactions) error {
+	db, cancel := r.withWriteContext(ctx)
+	defer cancel()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := r.ensureTxPartitions(db, batch[0].Block.Chain, batchHeights(batch)...); err != nil {
+		return err
+	}
+	last := batch[len(batch)-1].Block
+	if r.opts.CopyIngest {
+		return r.bulkTransaction(db, func(tx *gorm.DB, cp copier) error {
+			if err := r.bulkSaveBlocks(tx, cp, batch); err != nil {
+				return err
+			}
+			return r.updateState(tx, last.Chain, last.Height)
+		})
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, b := range batch {
+			if err := r.saveBlock(tx, b.Block, b.Txs); err != nil {
+				return err
+			}
+		}
+		return r.updateState(tx, last.Chain, last.Height)
+	})
+}
+
+func (r *repository) saveBlock(tx *gorm.DB, block *model.Block, txs []*model.Transaction) error {
+	// 1. Save Block
+	if err := tx.Table(r.blockTable(block.Chain)).Clauses(clause.OnConflict{
+		UpdateAll: true,
+	}).Create(block).Error; err != nil {
+		return err
+	}
+
+	// 2. Save Transactions
+	if len(txs) > 0 {
+		if err := tx.Table(r.txTable(block.Chain)).Clauses(r.txConflict(block.Chain)).Create(txs).Error; err != nil {
+			return err
+		}
+	}
+
+	// 3. Save UTXO Inputs & Outputs
+	if r.hasUTXO(block.Chain) {
+		if err := r.saveInputsOutputs(tx, block, txs); err != nil {
+			return err
+		}
+	}
+
+	// 4. Save Address Mapping
+	if refs := addressRefs(block, txs); len(refs) > 0 {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "chain"}, {Name: "address"}, {Name: "tx_hash"}},
 			UpdateAll: true,
-		}).Create(block).Error; err != nil {
+		}).CreateInBatches(refs, 1000).Error; err != nil {
 			return err
 		}
+	}
+
+	// 5. Update Balances
+	if err := r.applyBalanceChanges(tx, block, txs); err != nil {
+		return err
+	}
 
-		// 2. Save Transactions
-		if len(txs) > 0 {
-			if err := tx.Table(r.txTable(block.Chain)).Clauses(clause.OnConflict{
+	// 6. Save Token Transfers & Logs
+	if r.isEVM(block.Chain) {
+		var transfers []model.TokenTransfer
+		var logs []model.Log
+		for _, t := range txs {
+			transfers = append(transfers, t.TokenTransfers...)
+			logs = append(logs, t.Logs...)
+		}
+		if len(transfers) > 0 {
+			if err := tx.Table(r.tokenTransferTable(block.Chain)).Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "tx_hash"}, {Name: "log_index"}, {Name: "batch_index"}},
+				UpdateAll: true,
+			}).CreateInBatches(transfers, 1000).Error; err != nil {
+				return err
+			}
+		}
+		if len(logs) > 0 {
+			if err := tx.Table(r.logTable(block.Chain)).Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "tx_hash"}, {Name: "log_index"}},
 				UpdateAll: true,
-			}).Create(txs).Error; err != nil {
+			}).CreateInBatches(logs, 1000).Error; err != nil {
 				return err
 			}
 		}
+	}
 
-		// 3. Update State
-		if err := tx.Model(&model.IndexerState{}).
-			Where("chain = ?", block.Chain).
-			Update("last_indexed_block", block.Height).Error; err != nil {
+	// 7. Drop Mined Transactions from the Mempool
+	if len(txs) > 0 {
+		hashes := make([]string, len(txs))
+		for i, t := range txs {
+			hashes[i] = t.Hash
+		}
+		if err := deletePending(tx, block.Chain, hashes); err != nil {
 			return err
 		}
+	}
 
-		return nil
+	return nil
+}
+
+func (r *repository) saveInputsOutputs(tx *gorm.DB, block *model.Block, txs []*model.Transaction) error {
+	var inputs []model.TxInput
+	var outputs []model.TxOutput
+	for _, t := range txs {
+		inputs = append(inputs, t.Inputs...)
+		outputs = append(outputs, t.Outputs...)
+	}
+
+	if len(outputs) > 0 {
+		if err := tx.Table(r.outputTable(block.Chain)).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "txid"}, {Name: "vout"}},
+			DoUpdates: clause.AssignmentColumns([]string{"address", "value", "script_type", "block_height"}),
+		}).CreateInBatches(outputs, 1000).Error; err != nil {
+			return err
+		}
+	}
+
+	if len(inputs) > 0 {
+		if err := tx.Table(r.inputTable(block.Chain)).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "txid"}, {Name: "vin"}},
+			UpdateAll: true,
+		}).CreateInBatches(inputs, 1000).Error; err != nil {
+			return err
+		}
+
+		// Mark the outputs these inputs spend
+		if err := tx.Exec(fmt.Sprintf(`UPDATE %s o SET spent_by_txid = i.txid, spent_by_vin = i.vin
+			FROM %s i
+			WHERE i.block_height = ? AND NOT i.coinbase
+			AND o.txid = i.prev_txid AND o.vout = i.prev_vout`,
+			r.outputTable(block.Chain), r.inputTable(block.Chain)), block.Height).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *repository) updateState(tx *gorm.DB, chain model.ChainType, height uint64) error {
+	return tx.Model(&model.IndexerState{}).
+		Where("chain = ?", chain).
+		Update("last_indexed_block", height).Error
+}
+
+// GetBlockHash returns the stored hash at the given height, or "" if we have not indexed it.
+func (r *repository) GetBlockHash(ctx context.Context, chain model.ChainType, height uint64) (string, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var block model.Block
+	err := db.Table(r.blockTable(chain)).Where("height = ?", height).Limit(1).Find(&block).Error
+	if err != nil {
+		return "", err
+	}
+	return block.Hash, nil
+}
+
+// RollbackToHeight removes every block and transaction above height and rewinds the
+// indexer state so the next sync resumes at height+1. Used when a reorg orphans blocks.
+func (r *repository) RollbackToHeight(ctx context.Context, chain model.ChainType, height uint64) error {
+	db, cancel := r.withWriteContext(ctx)
+	defer cancel()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := r.deleteBlocks(tx, chain, ">", height); err != nil {
+			return err
+		}
+
+		// Rewind State
+		return tx.Model(&model.IndexerState{}).
+			Where("chain = ?", chain).
+			Updates(map[string]interface{}{
+				"last_indexed_block": height,
+				"updated_at":         time.Now(),
+			}).Error
 	})
 }
 
-func (r *repository) CountBlocks(chain model.ChainType) (int64, error) {
+// ReplaceBlock swaps the stored block at block.Height, and everything derived from it,
+// for a freshly fetched copy. Later blocks and the indexer state are left alone.
+func (r *repository) ReplaceBlock(ctx context.Context, block *model.Block, txs []*model.Transaction) error {
+	db, cancel := r.withWriteContext(ctx)
+	defer cancel()
+
+	if err := r.ensureTxPartitions(db, block.Chain, block.Height); err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := r.deleteBlocks(tx, block.Chain, "=", block.Height); err != nil {
+			return err
+		}
+		if err := r.saveBlock(tx, block, txs); err != nil {
+			return err
+		}
+
+		// Outputs of the block spent further up the chain lost their spender with the delete
+		return r.linkLaterSpends(tx, block.Chain, block.Height)
+	})
+}
+
+// linkLaterSpends marks the outputs created at height that are spent by inputs already
+// stored above it, which happens when a block is saved out of height order.
+func (r *repository) linkLaterSpends(tx *gorm.DB, chain model.ChainType, height uint64) error {
+	if !r.hasUTXO(chain) {
+		return nil
+	}
+	return tx.Exec(fmt.Sprintf(`UPDATE %s o SET spent_by_txid = i.txid, spent_by_vin = i.vin
+		FROM %s i
+		WHERE o.block_height = ? AND i.block_height > ? AND NOT i.coinbase
+		AND o.txid = i.prev_txid AND o.vout = i.prev_vout`,
+		r.outputTable(chain), r.inputTable(chain)), height, height).Error
+}
+
+// deleteBlocks removes the blocks whose height compares to height with op, either ">" or
+// "=", along with their transactions and everything derived from them.
+func (r *repository) deleteBlocks(tx *gorm.DB, chain model.ChainType, op string, height uint64) error {
+	heightCond := "block_height " + op + " ?"
+
+	// 1. Unspend and delete orphaned UTXO inputs & outputs
+	if r.hasUTXO(chain) {
+		if err := tx.Exec(fmt.Sprintf(`UPDATE %s o SET spent_by_txid = '', spent_by_vin = NULL
+			FROM %s i
+			WHERE i.%s AND NOT i.coinbase
+			AND o.txid = i.prev_txid AND o.vout = i.prev_vout`,
+			r.outputTable(chain), r.inputTable(chain), heightCond), height).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(r.inputTable(chain)).
+			Where(heightCond, height).
+			Delete(&model.TxInput{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(r.outputTable(chain)).
+			Where(heightCond, height).
+			Delete(&model.TxOutput{}).Error; err != nil {
+			return err
+		}
+	}
+
+	// 2. Delete orphaned token transfers & logs
+	if r.isEVM(chain) {
+		if err := tx.Table(r.tokenTransferTable(chain)).
+			Where(heightCond, height).
+			Delete(&model.TokenTransfer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(r.logTable(chain)).
+			Where(heightCond, height).
+			Delete(&model.Log{}).Error; err != nil {
+			return err
+		}
+	}
+
+	// 3. Revert balances and delete orphaned address mappings
+	if err := r.revertBalanceChanges(tx, chain, op, height); err != nil {
+		return err
+	}
+	if err := tx.Where("chain = ? AND "+heightCond, chain, height).
+		Delete(&model.AddressTransaction{}).Error; err != nil {
+		return err
+	}
+
+	// 4. Delete orphaned transactions
+	if err := tx.Table(r.txTable(chain)).
+		Where(heightCond, height).
+		Delete(&model.Transaction{}).Error; err != nil {
+		return err
+	}
+
+	// 5. Delete orphaned blocks
+	return tx.Table(r.blockTable(chain)).
+		Where("height "+op+" ?", height).
+		Delete(&model.Block{}).Error
+}
+
+func (r *repository) CountBlocks(ctx context.Context, chain model.ChainType) (int64, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
 	var count int64
-	err := r.db.Table(r.blockTable(chain)).Count(&count).Error
+	err := db.Table(r.blockTable(chain)).Count(&count).Error
 	return count, err
 }
 
-func (r *repository) GetTransactionsByBlock(chain model.ChainType, height uint64) ([]model.Transaction, error) {
+func (r *repository) GetTransactionsByBlock(ctx context.Context, chain model.ChainType, height uint64) ([]model.Transaction, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
 	var txs []model.Transaction
-	err := r.db.Table(r.txTable(chain)).Where("block_height = ?", height).Find(&txs).Error
+	err := db.Table(r.txTable(chain)).Where("block_height = ?", height).Find(&txs).Error
 	return txs, err
 }
 
-func (r *repository) FindTransactionByHash(chain model.ChainType, hash string) (*model.Transaction, error) {
+func (r *repository) FindTransactionByHash(ctx context.Context, chain model.ChainType, hash string) (*model.Transaction, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
 	var tx model.Transaction
-	err := r.db.Table(r.txTable(chain)).Where("hash = ?", hash).First(&tx).Error
+	err := db.Table(r.txTable(chain)).Where("hash = ?", hash).First(&tx).Error
 	if err != nil {
 		return nil, err
 	}
 	return &tx, nil
 }
 
-func (r *repository) GetMaxBlockHeight(chain model.ChainType) (uint64, error) {
+func (r *repository) GetMaxBlockHeight(ctx context.Context, chain model.ChainType) (uint64, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
 	var max uint64
 	// Use Scan to handle potential NULL result if table is empty
-	err := r.db.Table(r.blockTable(chain)).Select("COALESCE(MAX(height), 0)").Row().Scan(&max)
+	err := db.Table(r.blockTable(chain)).Select("COALESCE(MAX(height), 0)").Row().Scan(&max)
 	return max, err
 }
+
+func (r *repository) GetTxInputs(ctx context.Context, chain model.ChainType, txid string) ([]model.TxInput, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var inputs []model.TxInput
+	err := db.Table(r.inputTable(chain)).Where("txid = ?", txid).Order("vin ASC").Find(&inputs).Error
+	return inputs, err
+}
+
+func (r *repository) GetTxOutputs(ctx context.Context, chain model.ChainType, txid string) ([]model.TxOutput, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var outputs []model.TxOutput
+	err := db.Table(r.outputTable(chain)).Where("txid = ?", txid).Order("vout ASC").Find(&outputs).Error
+	return outputs, err
+}
+
+func (r *repository) GetTokenTransfersByContract(ctx context.Context, chain model.ChainType, contract string, limit, offset int) ([]model.TokenTransfer, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var transfers []model.TokenTransfer
+	err := db.Table(r.tokenTransferTable(chain)).
+		Where("contract = ?", contract).
+		Order("block_height DESC, log_index DESC, batch_index DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&transfers).Error
+	return transfers, err
+}
+
+func (r *repository) GetTokenTransfersByAddress(ctx context.Context, chain model.ChainType, address string, limit, offset int) ([]model.TokenTransfer, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var transfers []model.TokenTransfer
+	err := db.Table(r.tokenTransferTable(chain)).
+		Where("from_address = ? OR to_address = ?", address, address).
+		Order("block_height DESC, log_index DESC, batch_index DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&transfers).Error
+	return transfers, err
+}
+
+func (r *repository) GetLogs(ctx context.Context, chain model.ChainType, filter LogFilter, limit, offset int) ([]model.Log, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	q := db.Table(r.logTable(chain))
+	if len(filter.Addresses) > 0 {
+		q = q.Where("address IN ?", filter.Addresses)
+	}
+	for i, topics := range filter.Topics {
+		if len(topics) > 0 {
+			q = q.Where(fmt.Sprintf("topic%d IN ?", i), topics)
+		}
+	}
+	if filter.FromBlock != nil {
+		q = q.Where("block_height >= ?", *filter.FromBlock)
+	}
+	if filter.ToBlock != nil {
+		q = q.Where("block_height <= ?", *filter.ToBlock)
+	}
+
+	var logs []model.Log
+	err := q.Order("block_height ASC, log_index ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs).Error
+	return logs, err
+}
+
+func (r *repository) GetTokenTransfersByTx(ctx context.Context, chain model.ChainType, txHash string) ([]model.TokenTransfer, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var transfers []model.TokenTransfer
+	err := db.Table(r.tokenTransferTable(chain)).
+		Where("tx_hash = ?", txHash).
+		Order("log_index ASC, batch_index ASC").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+func (r *repository) GetLogsByTx(ctx context.Context, chain model.ChainType, txHash string) ([]model.Log, error) {
+	db, cancel := r.withContext(ctx)
+	defer cancel()
+
+	var logs []model.Log
+	err := db.Table(r.logTable(chain)).
+		Where("tx_hash = ?", txHash).
+		Order("log_index ASC").
+		Find(&logs).Error
+	return logs, err
+}
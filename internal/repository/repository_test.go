@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"indexer/internal/chains"
+	"indexer/internal/model"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// statement is one query a dry-run repository built, with the deadline of its context
+type statement struct {
+	sql         string
+	deadline    time.Time
+	hasDeadline bool
+}
+
+// recorder is a gorm logger keeping every statement instead of printing it
+type recorder struct {
+	mu    sync.Mutex
+	stmts []statement
+}
+
+func (l *recorder) LogMode(logger.LogLevel) logger.Interface      { return l }
+func (l *recorder) Info(context.Context, string, ...interface{})  {}
+func (l *recorder) Warn(context.Context, string, ...interface{})  {}
+func (l *recorder) Error(context.Context, string, ...interface{}) {}
+func (l *recorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	deadline, ok := ctx.Deadline()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stmts = append(l.stmts, statement{sql: sql, deadline: deadline, hasDeadline: ok})
+}
+
+// take returns the statements recorded since the last call
+func (l *recorder) take() []statement {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stmts := l.stmts
+	l.stmts = nil
+	return stmts
+}
+
+// newDryRunRepository returns a repository building its queries without a database, and
+// the recorder they end up in. Only statements outside DB transactions can be recorded.
+func newDryRunRepository(t *testing.T, opts Options) (*repository, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=invalid.test"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               rec,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &repository{db: db, opts: opts}, rec
+}
+
+// Every network's queries must stay on its own tables and its own rows of the shared ones
+func TestNetworkIsolation(t *testing.T) {
+	networks := []chains.Info{chains.Ethereum, chains.EVMNetwork("polygon", "POL", 137), chains.Bitcoin, chains.UTXONetwork("ltc")}
+	for _, info := range networks {
+		if err := chains.Register(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, rec := newDryRunRepository(t, Options{})
+	ctx := context.Background()
+	for _, info := range networks {
+		chain := info.Type
+		r.GetLatestBlocks(ctx, chain, 10, 0)
+		r.GetBlockHash(ctx, chain, 5)
+		r.GetTransactions(ctx, chain, TransactionFilter{Status: "success"}, 10, 0)
+		r.FindTransactionByHash(ctx, chain, "0xabc")
+		r.GetState(ctx, chain)
+		r.GetRichList(ctx, chain, 10)
+		r.GetPendingHashes(ctx, chain)
+		r.GetAddressTransactions(ctx, chain, "addr", 10, 0)
+		if info.IsEVM() {
+			r.GetLogsByTx(ctx, chain, "0xabc")
+			r.GetTokenTransfersByTx(ctx, chain, "0xabc")
+		} else {
+			r.GetTxInputs(ctx, chain, "abc")
+			r.GetTxOutputs(ctx, chain, "abc")
+		}
+
+		stmts := rec.take()
+		if len(stmts) == 0 {
+			t.Fatalf("%s: no statements recorded", info.Name)
+		}
+		own := func(sql string, other chains.Info) bool {
+			return strings.Contains(sql, other.Name+"_") || strings.Contains(sql, "'"+string(other.Type)+"'")
+		}
+		for _, s := range stmts {
+			if !own(s.sql, info) {
+				t.Errorf("%s: statement does not select the network: %s", info.Name, s.sql)
+			}
+			for _, other := range networks {
+				if other.Type != info.Type && own(s.sql, other) {
+					t.Errorf("%s: statement reaches %s: %s", info.Name, other.Name, s.sql)
+				}
+			}
+		}
+	}
+}
+
+// Reads are bounded by QueryTimeout and writes by WriteTimeout, each only when positive,
+// and never past a deadline the caller already set
+func TestTimeouts(t *testing.T) {
+	if err := chains.Register(chains.Bitcoin); err != nil {
+		t.Fatal(err)
+	}
+	read := func(r *repository, ctx context.Context) {
+		r.GetLatestBlocks(ctx, model.ChainBTC, 10, 0)
+	}
+	write := func(r *repository, ctx context.Context) {
+		// Verify scans run under the write timeout and, unlike block writes, outside a DB
+		// transaction, so the dry run records them
+		r.FindBrokenLinks(ctx, model.ChainBTC, 1, 100)
+	}
+
+	tests := []struct {
+		name   string
+		opts   Options
+		parent time.Duration // deadline the caller sets, if any
+		run    func(*repository, context.Context)
+		want   time.Duration // expected deadline from now, 0 for none
+	}{
+		{name: "read with a query timeout", opts: Options{QueryTimeout: time.Minute, WriteTimeout: time.Hour}, run: read, want: time.Minute},
+		{name: "read without a query timeout", opts: Options{WriteTimeout: time.Hour}, run: read, want: 0},
+		{name: "write with a write timeout", opts: Options{QueryTimeout: time.Minute, WriteTimeout: time.Hour}, run: write, want: time.Hour},
+		{name: "write timeout 0 means no deadline", opts: Options{QueryTimeout: time.Minute}, run: write, want: 0},
+		{name: "negative timeout means no deadline", opts: Options{WriteTimeout: -time.Second}, run: write, want: 0},
+		{name: "caller deadline kept without a timeout", opts: Options{}, parent: 10 * time.Minute, run: write, want: 10 * time.Minute},
+		{name: "shorter caller deadline wins", opts: Options{QueryTimeout: time.Hour}, parent: time.Minute, run: read, want: time.Minute},
+		{name: "shorter timeout wins", opts: Options{WriteTimeout: time.Minute}, parent: time.Hour, run: write, want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, rec := newDryRunRepository(t, tt.opts)
+			before := time.Now()
+			ctx := context.Background()
+			if tt.parent > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.parent)
+				defer cancel()
+			}
+
+			tt.run(r, ctx)
+			after := time.Now()
+
+			stmts := rec.take()
+			if len(stmts) == 0 {
+				t.Fatal("no statements recorded")
+			}
+			for _, s := range stmts {
+				if tt.want == 0 {
+					if s.hasDeadline {
+						t.Errorf("statement has a deadline %s from now: %s", time.Until(s.deadline), s.sql)
+					}
+					continue
+				}
+				if !s.hasDeadline || s.deadline.Before(before.Add(tt.want)) || s.deadline.After(after.Add(tt.want)) {
+					t.Errorf("statement deadline %s from now, want %s: %s", time.Until(s.deadline).Round(time.Second), tt.want, s.sql)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// TxCountMismatch is a block whose stored transactions do not add up to its tx count
+type TxCountMismatch struct {
+	Height   uint64 `json:"height"`
+	Expected uint64 `json:"expected"`
+	Actual   uint64 `json:"actual"`
+}
+
+// FindBrokenLinks returns the heights between from and to whose parent hash does not match
+// the hash of the block stored below them. Heights whose parent is missing are not reported.
+func (r *repository) FindBrokenLinks(ctx context.Context, chain model.ChainType, from, to uint64) ([]uint64, error) {
+	db, cancel := r.withWriteContext(ctx)
+	defer cancel()
+
+	var heights []uint64
+	err := db.Raw(fmt.Sprintf(`SELECT b.height FROM %[1]s b
+		JOIN %[1]s p ON p.height = b.height - 1
+		WHERE b.height BETWEEN ? AND ? AND b.block_hash <> p.hash
+		ORDER BY b.height`, r.blockTable(chain)), from, to).Scan(&heights).Error
+	return heights, err
+}
+
+// FindTxCountMismatches returns the blocks between from and to whose tx_count differs from
+// the number of transactions stored for them. The range is repeated on the transactions
+// side so a partitioned table only scans the partitions it covers.
+func (r *repository) FindTxCountMismatches(ctx context.Context, chain model.ChainType, from, to uint64) ([]TxCountMismatch, error) {
+	db, cancel := r.withWriteContext(ctx)
+	defer cancel()
+
+	var res []TxCountMismatch
+	err := db.Raw(fmt.Sprintf(`SELECT b.height, b.tx_count AS expected, COUNT(t.id) AS actual
+		FROM %s b
+		LEFT JOIN %s t ON t.block_height = b.height AND t.block_height BETWEEN ? AND ?
+		WHERE b.height BETWEEN ? AND ?
+		GROUP BY b.height, b.tx_count
+		HAVING COUNT(t.id) <> b.tx_count
+		ORDER BY b.height`, r.blockTable(chain), r.txTable(chain)), from, to, from, to).Scan(&res).Error
+	return res, err
+}
+
+// DeleteBlock removes the block at height and everything derived from it, leaving the
+// indexer state alone so a backfill can fetch it again
+func (r *repository) DeleteBlock(ctx context.Context, chain model.ChainType, height uint64) error {
+	db, cancel := r.withWriteContext(ctx)
+	defer cancel()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		return r.deleteBlocks(tx, chain, "=", height)
+	})
+}
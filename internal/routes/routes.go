@@ -6,7 +6,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(apiHandler *handlers.APIHandler) *gin.Engine {
+// SetupRouter wires the public API and, when adminHandler is not nil, the token-protected
+// admin endpoints
+func SetupRouter(apiHandler *handlers.APIHandler, adminHandler *handlers.AdminHandler) *gin.Engine {
 	r := gin.Default()
 
 	// CORS or other middleware can be added here
@@ -23,10 +25,39 @@ func SetupRouter(apiHandler *handlers.APIHandler) *gin.Engine {
 
 	api := r.Group("/api")
 	{
+		api.GET("/chains", apiHandler.GetChains)
 		api.GET("/stats", apiHandler.GetStats)
 		api.GET("/search", apiHandler.Search)
+		api.GET("/workers", apiHandler.GetWorkers)
 		api.GET("/:chain/blocks", apiHandler.GetBlocks)
 		api.GET("/:chain/blocks/:height", apiHandler.GetBlockByHeight)
+		api.GET("/:chain/txs", apiHandler.GetTransactions)
+		api.GET("/:chain/tx/:hash", apiHandler.GetTransaction)
+		api.GET("/:chain/tx/:hash/inputs", apiHandler.GetTxInputs)
+		api.GET("/:chain/tx/:hash/outputs", apiHandler.GetTxOutputs)
+		api.GET("/:chain/address/:addr", apiHandler.GetAddress)
+		api.GET("/:chain/address/:addr/token-transfers", apiHandler.GetTokenTransfersByAddress)
+		api.GET("/:chain/richlist", apiHandler.GetRichList)
+		api.GET("/:chain/mempool", apiHandler.GetMempool)
+		api.GET("/:chain/tokens/:contract/transfers", apiHandler.GetTokenTransfersByContract)
+		api.GET("/:chain/logs", apiHandler.GetLogs)
+		api.GET("/:chain/worker", apiHandler.GetWorker)
+		api.GET("/:chain/backfills", apiHandler.GetBackfillJobs)
+		api.GET("/:chain/backfills/:id", apiHandler.GetBackfillJob)
+	}
+
+	if adminHandler != nil {
+		admin := api.Group("/admin", adminHandler.RequireToken)
+		{
+			admin.POST("/:chain/pause", adminHandler.PauseWorker)
+			admin.POST("/:chain/resume", adminHandler.ResumeWorker)
+			admin.POST("/:chain/rewind", adminHandler.RewindWorker)
+			admin.POST("/:chain/reindex", adminHandler.ReindexBlock)
+			admin.POST("/:chain/backfills", adminHandler.StartBackfill)
+			admin.DELETE("/:chain/backfills/:id", adminHandler.CancelBackfill)
+			admin.GET("/:chain/verify", adminHandler.GetVerifyReport)
+			admin.POST("/:chain/verify", adminHandler.RunVerify)
+		}
 	}
 
 	return r
@@ -0,0 +1,334 @@
+// Package rpcpool spreads the RPC traffic of one chain over several node endpoints. It
+// health-checks them in the background, prefers the fastest one whose tip is current, and
+// retries failed calls on the next best endpoint with exponential backoff.
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"indexer/internal/supervisor"
+	"log"
+	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// latencyWeight is the weight of a new sample in the moving latency average
+	latencyWeight = 0.3
+
+	// failurePenalty is added to an endpoint's score for each consecutive failure
+	failurePenalty = 2 * time.Second
+
+	minBackoff = 200 * time.Millisecond
+	maxBackoff = 5 * time.Second
+)
+
+// permanentError marks an error the endpoint answered with on purpose, such as an
+// unsupported method, which another endpoint would most likely answer the same way
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Do returns it right away instead of retrying elsewhere
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// TipFunc returns the height of the newest block the endpoint knows about
+type TipFunc[C any] func(ctx context.Context, client C) (uint64, error)
+
+// Options tune a Pool. Zero values pick the defaults.
+type Options struct {
+	HealthInterval time.Duration // between background checks, default 10s
+	MaxLag         uint64        // blocks an endpoint may trail the best tip and still be used, default 2
+	Attempts       int           // tries per call, default max(3, number of endpoints)
+}
+
+// EndpointStatus is a snapshot of one endpoint's health
+type EndpointStatus struct {
+	URL       string        `json:"url"`
+	Healthy   bool          `json:"healthy"`
+	Lagging   bool          `json:"lagging"`
+	Tip       uint64        `json:"tip"`
+	Latency   time.Duration `json:"latency"`
+	Failures  int           `json:"failures"`
+	LastError string        `json:"lastError,omitempty"`
+	CheckedAt time.Time     `json:"checkedAt"`
+}
+
+type endpoint[C any] struct {
+	client C
+	status EndpointStatus
+}
+
+// Pool is a set of clients for the same chain
+type Pool[C any] struct {
+	tag       string
+	tip       TipFunc[C]
+	opts      Options
+	mu        sync.RWMutex
+	endpoints []*endpoint[C]
+	bestTip   uint64
+	running   atomic.Bool
+}
+
+// New creates a pool over clients, one per entry of urls. tag prefixes log lines, e.g. "[BTC]".
+func New[C any](tag string, urls []string, clients []C, tip TipFunc[C], opts Options) (*Pool[C], error) {
+	if len(clients) == 0 || len(clients) != len(urls) {
+		return nil, fmt.Errorf("need one client per endpoint, got %d clients for %d URLs", len(clients), len(urls))
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = 10 * time.Second
+	}
+	if opts.MaxLag == 0 {
+		opts.MaxLag = 2
+	}
+	if opts.Attempts <= 0 {
+		opts.Attempts = len(clients)
+		if opts.Attempts < 3 {
+			opts.Attempts = 3
+		}
+	}
+
+	p := &Pool[C]{tag: tag, tip: tip, opts: opts}
+	for i, c := range clients {
+		// Unchecked endpoints start out usable so the first calls do not wait for a check
+		p.endpoints = append(p.endpoints, &endpoint[C]{
+			client: c,
+			status: EndpointStatus{URL: Redact(urls[i]), Healthy: true},
+		})
+	}
+	return p, nil
+}
+
+// Redact strips credentials and paths, which often carry API keys, from an endpoint URL
+func Redact(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "endpoint"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// Monitor health-checks every endpoint until ctx is done. Calling it while another
+// Monitor runs is a no-op.
+func (p *Pool[C]) Monitor(ctx context.Context) {
+	if !p.running.CompareAndSwap(false, true) {
+		return
+	}
+	defer p.running.Store(false)
+
+	ticker := time.NewTicker(p.opts.HealthInterval)
+	defer ticker.Stop()
+	for {
+		p.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check asks every endpoint for its tip in parallel, updating latency and health, and
+// marks endpoints trailing the best tip by more than MaxLag as lagging.
+func (p *Pool[C]) Check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.HealthInterval)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, e := range p.endpoints {
+		wg.Add(1)
+		supervisor.Spawn(ctx, func() {
+			defer wg.Done()
+			start := time.Now()
+			tip, err := p.tip(ctx, e.client)
+			p.record(e, time.Since(start), err)
+			if err == nil {
+				p.mu.Lock()
+				e.status.Tip = tip
+				p.mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var best uint64
+	for _, e := range p.endpoints {
+		if e.status.Healthy && e.status.Tip > best {
+			best = e.status.Tip
+		}
+	}
+	p.bestTip = best
+	for _, e := range p.endpoints {
+		lagging := e.status.Healthy && e.status.Tip+p.opts.MaxLag < best
+		if lagging && !e.status.Lagging {
+			log.Printf("%s RPC %s is lagging: tip %d, best %d", p.tag, e.status.URL, e.status.Tip, best)
+		}
+		e.status.Lagging = lagging
+		e.status.CheckedAt = time.Now()
+	}
+}
+
+// Tip returns the highest tip reported by a healthy endpoint at the last check, or 0
+// before the first check.
+func (p *Pool[C]) Tip() uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.bestTip
+}
+
+// Status returns a snapshot of every endpoint
+func (p *Pool[C]) Status() []EndpointStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	res := make([]EndpointStatus, len(p.endpoints))
+	for i, e := range p.endpoints {
+		res[i] = e.status
+	}
+	return res
+}
+
+// Best returns the client of the currently preferred endpoint
+func (p *Pool[C]) Best() C {
+	return p.ranked()[0].client
+}
+
+// BestMatching returns the preferred client for which ok returns true, e.g. the best one
+// able to push subscriptions
+func (p *Pool[C]) BestMatching(ok func(client C) bool) (C, bool) {
+	for _, e := range p.ranked() {
+		if ok(e.client) {
+			return e.client, true
+		}
+	}
+	var zero C
+	return zero, false
+}
+
+// Clients returns every client in configuration order
+func (p *Pool[C]) Clients() []C {
+	res := make([]C, len(p.endpoints))
+	for i, e := range p.endpoints {
+		res[i] = e.client
+	}
+	return res
+}
+
+// Do runs fn against the best endpoint, moving on to the next best one with exponential
+// backoff when it fails. It gives up after Attempts tries or when ctx is done.
+func (p *Pool[C]) Do(ctx context.Context, fn func(client C) error) error {
+	backoff := minBackoff
+	tried := map[*endpoint[C]]bool{}
+	var err error
+	for attempt := 0; attempt < p.opts.Attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return errors.Join(ctx.Err(), err)
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+
+		// Take the best endpoint not tried yet, starting over once all have been
+		ranked := p.ranked()
+		if len(tried) == len(ranked) {
+			tried = map[*endpoint[C]]bool{}
+		}
+		var e *endpoint[C]
+		for _, candidate := range ranked {
+			if !tried[candidate] {
+				e = candidate
+				break
+			}
+		}
+		tried[e] = true
+		start := time.Now()
+		err = fn(e.client)
+		if ctx.Err() != nil {
+			// Our own cancellation says nothing about the endpoint
+			return err
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			p.record(e, time.Since(start), nil)
+			return permanent.err
+		}
+		p.record(e, time.Since(start), err)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// Call is Do for functions returning a value
+func Call[C, T any](ctx context.Context, p *Pool[C], fn func(client C) (T, error)) (T, error) {
+	var res T
+	err := p.Do(ctx, func(client C) error {
+		var err error
+		res, err = fn(client)
+		return err
+	})
+	return res, err
+}
+
+// ranked orders endpoints best first: usable ones (healthy and not lagging) before the
+// rest, then by latency plus a penalty per consecutive failure.
+func (p *Pool[C]) ranked() []*endpoint[C] {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	ranked := make([]*endpoint[C], len(p.endpoints))
+	copy(ranked, p.endpoints)
+	score := func(e *endpoint[C]) time.Duration {
+		return e.status.Latency + time.Duration(e.status.Failures)*failurePenalty
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		ui := ranked[i].status.Healthy && !ranked[i].status.Lagging
+		uj := ranked[j].status.Healthy && !ranked[j].status.Lagging
+		if ui != uj {
+			return ui
+		}
+		return score(ranked[i]) < score(ranked[j])
+	})
+	return ranked
+}
+
+func (p *Pool[C]) record(e *endpoint[C], latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		if e.status.Healthy {
+			log.Printf("%s RPC %s failing: %v", p.tag, e.status.URL, err)
+		}
+		e.status.Failures++
+		e.status.Healthy = false
+		e.status.LastError = err.Error()
+		return
+	}
+	if !e.status.Healthy {
+		log.Printf("%s RPC %s recovered", p.tag, e.status.URL)
+	}
+	e.status.Healthy = true
+	e.status.Failures = 0
+	e.status.LastError = ""
+	if e.status.Latency == 0 {
+		e.status.Latency = latency
+	} else {
+		e.status.Latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(e.status.Latency))
+	}
+}
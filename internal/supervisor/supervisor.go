@@ -0,0 +1,243 @@
+// Package supervisor keeps long-running services such as the sync workers alive. A service
+// that returns an error or panics is restarted with exponential backoff, and its state and
+// last error are tracked for the API instead of taking the process down.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// State is the lifecycle stage of a supervised service
+type State string
+
+const (
+	StateStarting State = "starting"
+	StateSyncing  State = "syncing"
+	StateAtTip    State = "at_tip"
+	StateErroring State = "erroring"
+	StatePaused   State = "paused"
+	StateStopped  State = "stopped"
+)
+
+const (
+	minRestartDelay = time.Second
+	maxRestartDelay = 5 * time.Minute
+
+	// stableRun is how long a service must run before its restart backoff is reset
+	stableRun = time.Minute
+)
+
+// Status is a snapshot of one service
+type Status struct {
+	Name        string     `json:"name"`
+	State       State      `json:"state"`
+	Height      uint64     `json:"height"`
+	Tip         uint64     `json:"tip"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+	Restarts    int        `json:"restarts"`
+	Since       time.Time  `json:"since"` // when State last changed
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// Tracker is the handle a service reports its progress through. It is safe for
+// concurrent use.
+type Tracker struct {
+	mu     sync.RWMutex
+	status Status
+}
+
+// Set moves the service to state
+func (t *Tracker) Set(state State) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.set(state)
+}
+
+func (t *Tracker) set(state State) {
+	now := time.Now()
+	if t.status.State != state {
+		t.status.State = state
+		t.status.Since = now
+	}
+	t.status.UpdatedAt = now
+}
+
+// Fail records err and moves the service to StateErroring
+func (t *Tracker) Fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.status.LastError = err.Error()
+	t.status.LastErrorAt = &now
+	t.set(StateErroring)
+}
+
+// Progress records the last indexed height and the node's tip
+func (t *Tracker) Progress(height, tip uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.Height = height
+	t.status.Tip = tip
+	t.status.UpdatedAt = time.Now()
+}
+
+// Status returns a snapshot
+func (t *Tracker) Status() Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.status
+}
+
+// Supervisor runs services and restarts them when they fail
+type Supervisor struct {
+	mu       sync.RWMutex
+	order    []string
+	trackers map[string]*Tracker
+	wg       sync.WaitGroup
+}
+
+func New() *Supervisor {
+	return &Supervisor{trackers: map[string]*Tracker{}}
+}
+
+// Go runs fn in its own goroutine until ctx is done. When fn returns early, with an
+// error or not, or panics, it is started again after a backoff growing from 1s to 5m.
+func (s *Supervisor) Go(ctx context.Context, name string, fn func(ctx context.Context, t *Tracker) error) {
+	t := &Tracker{status: Status{Name: name}}
+	t.Set(StateStarting)
+
+	s.mu.Lock()
+	if _, ok := s.trackers[name]; !ok {
+		s.order = append(s.order, name)
+	}
+	s.trackers[name] = t
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer t.Set(StateStopped)
+
+		delay := minRestartDelay
+		for {
+			started := time.Now()
+			err := run(ctx, t, fn)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				err = fmt.Errorf("exited unexpectedly")
+			}
+			t.Fail(err)
+
+			if time.Since(started) > stableRun {
+				delay = minRestartDelay
+			}
+			log.Printf("[SUPERVISOR] %s failed: %v. Restarting in %s", name, err, delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+			if delay > maxRestartDelay {
+				delay = maxRestartDelay
+			}
+
+			t.mu.Lock()
+			t.status.Restarts++
+			t.set(StateStarting)
+			t.mu.Unlock()
+		}
+	}()
+}
+
+// serviceKey is the context key under which run stores the running service
+type serviceKey struct{}
+
+// service is what goroutines started through Spawn need to report a panic
+type service struct {
+	tracker *Tracker
+	fail    context.CancelCauseFunc
+}
+
+// panicError is the cause a panicking goroutine cancels its service's context with
+type panicError struct{ value any }
+
+func (e panicError) Error() string { return fmt.Sprintf("panic: %v", e.value) }
+
+// run calls fn, turning a panic, its own or one in a goroutine started through Spawn, into
+// an error
+func run(ctx context.Context, t *Tracker, fn func(ctx context.Context, t *Tracker) error) (err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	ctx = context.WithValue(ctx, serviceKey{}, &service{tracker: t, fail: cancel})
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[SUPERVISOR] %s panicked: %v\n%s", t.Status().Name, r, debug.Stack())
+			err = panicError{r}
+		}
+	}()
+	err = fn(ctx, t)
+	// A goroutine's panic cancels the context, which fn most likely answered by returning nil
+	var p panicError
+	if errors.As(context.Cause(ctx), &p) {
+		return p
+	}
+	return err
+}
+
+// Spawn runs fn in a new goroutine on behalf of the service ctx belongs to. A panic in fn
+// cancels the service's context and makes the service return it as an error, so it is
+// restarted like any other failure. Outside a supervised service fn runs unguarded.
+func Spawn(ctx context.Context, fn func()) {
+	s, ok := ctx.Value(serviceKey{}).(*service)
+	if !ok {
+		go fn()
+		return
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[SUPERVISOR] %s goroutine panicked: %v\n%s", s.tracker.Status().Name, r, debug.Stack())
+				s.fail(panicError{r})
+			}
+		}()
+		fn()
+	}()
+}
+
+// Wait blocks until every service has stopped after its context was cancelled
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+}
+
+// Status returns the snapshot of one service
+func (s *Supervisor) Status(name string) (Status, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.trackers[name]
+	if !ok {
+		return Status{}, false
+	}
+	return t.Status(), true
+}
+
+// Statuses returns a snapshot of every service in start order
+func (s *Supervisor) Statuses() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make([]Status, 0, len(s.order))
+	for _, name := range s.order {
+		res = append(res, s.trackers[name].Status())
+	}
+	return res
+}
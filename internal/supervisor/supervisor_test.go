@@ -0,0 +1,72 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunReportsPanics(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name    string
+		fn      func(ctx context.Context, t *Tracker) error
+		wantErr string
+	}{
+		{
+			name:    "returns error",
+			fn:      func(ctx context.Context, t *Tracker) error { return boom },
+			wantErr: "boom",
+		},
+		{
+			name:    "panics",
+			fn:      func(ctx context.Context, t *Tracker) error { panic("in run") },
+			wantErr: "panic: in run",
+		},
+		{
+			name: "spawned goroutine panics",
+			fn: func(ctx context.Context, t *Tracker) error {
+				Spawn(ctx, func() { panic("in goroutine") })
+				<-ctx.Done()
+				return nil
+			},
+			wantErr: "panic: in goroutine",
+		},
+		{
+			name: "spawned goroutine returns",
+			fn: func(ctx context.Context, t *Tracker) error {
+				done := make(chan struct{})
+				Spawn(ctx, func() { close(done) })
+				<-done
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err := run(ctx, &Tracker{status: Status{Name: "test"}}, tt.fn)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("run() error = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("run() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSpawnOutsideService(t *testing.T) {
+	done := make(chan struct{})
+	Spawn(context.Background(), func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("spawned function did not run")
+	}
+}
@@ -0,0 +1,213 @@
+// Package verify checks the indexed blocks of a chain for missing heights, parent hashes
+// that do not chain up and blocks whose transactions are incomplete, and can queue the
+// affected heights to be fetched again.
+package verify
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/chains"
+	"indexer/internal/model"
+	"indexer/internal/repository"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// chunkSize is how many heights one round of queries covers
+	chunkSize = 10000
+
+	// maxReported caps each list in a report; the counts stay exact
+	maxReported = 1000
+)
+
+// Options select the range to check. Zero From and To default to the lowest indexed block
+// and the live cursor.
+type Options struct {
+	From   uint64
+	To     uint64
+	Repair bool // delete bad blocks and queue backfill jobs for them and for the gaps
+}
+
+// HeightRange is an inclusive run of heights
+type HeightRange struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+// Report lists what a check found
+type Report struct {
+	Chain             string                       `json:"chain"`
+	From              uint64                       `json:"from"`
+	To                uint64                       `json:"to"`
+	Missing           []HeightRange                `json:"missing"`
+	MissingCount      uint64                       `json:"missingCount"`
+	BrokenLinks       []uint64                     `json:"brokenLinks"`
+	BrokenLinkCount   int                          `json:"brokenLinkCount"`
+	TxCountMismatches []repository.TxCountMismatch `json:"txCountMismatches"`
+	MismatchCount     int                          `json:"mismatchCount"`
+	RepairJobs        []uint                       `json:"repairJobs,omitempty"` // backfill jobs queued by the repair
+	StartedAt         time.Time                    `json:"startedAt"`
+	FinishedAt        time.Time                    `json:"finishedAt"`
+}
+
+// OK reports whether nothing was found
+func (r *Report) OK() bool {
+	return r.MissingCount == 0 && r.BrokenLinkCount == 0 && r.MismatchCount == 0
+}
+
+// Summary is a one-line description for logs
+func (r *Report) Summary() string {
+	s := fmt.Sprintf("blocks %d-%d: %d missing in %d gaps, %d broken links, %d tx count mismatches",
+		r.From, r.To, r.MissingCount, len(r.Missing), r.BrokenLinkCount, r.MismatchCount)
+	if len(r.RepairJobs) > 0 {
+		s += fmt.Sprintf(", %d repair jobs queued", len(r.RepairJobs))
+	}
+	return s
+}
+
+// Run checks the chain's blocks in opts' range, working through it in chunks so it stays
+// cheap on the database and can be cancelled through ctx
+func Run(ctx context.Context, repo repository.Repository, info chains.Info, opts Options) (*Report, error) {
+	report := &Report{Chain: info.Name, From: opts.From, To: opts.To, StartedAt: time.Now()}
+
+	if report.From == 0 {
+		lowest, err := repo.GetMinBlockHeight(ctx, info.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get lowest block: %w", err)
+		}
+		report.From = lowest
+	}
+	if report.To == 0 {
+		last, err := repo.GetState(ctx, info.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get state: %w", err)
+		}
+		report.To = last
+	}
+	if report.From > report.To {
+		report.FinishedAt = time.Now()
+		return report, nil
+	}
+
+	var refetch []uint64
+	for start := report.From; start <= report.To; start += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := min(start+chunkSize-1, report.To)
+
+		// 1. Missing heights
+		stored, err := repo.GetStoredHeights(ctx, info.Type, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list heights %d-%d: %w", start, end, err)
+		}
+		next := start
+		for _, h := range append(stored, end+1) {
+			if h > next {
+				report.MissingCount += h - next
+				report.Missing = appendRange(report.Missing, HeightRange{From: next, To: h - 1})
+			}
+			next = h + 1
+		}
+
+		// 2. Parent hashes; either side of a broken link may be the stale one
+		links, err := repo.FindBrokenLinks(ctx, info.Type, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check hash links %d-%d: %w", start, end, err)
+		}
+		report.BrokenLinkCount += len(links)
+		for _, h := range links {
+			if len(report.BrokenLinks) < maxReported {
+				report.BrokenLinks = append(report.BrokenLinks, h)
+			}
+			refetch = append(refetch, h-1, h)
+		}
+
+		// 3. Transaction counts
+		mismatches, err := repo.FindTxCountMismatches(ctx, info.Type, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check tx counts %d-%d: %w", start, end, err)
+		}
+		report.MismatchCount += len(mismatches)
+		for _, m := range mismatches {
+			if len(report.TxCountMismatches) < maxReported {
+				report.TxCountMismatches = append(report.TxCountMismatches, m)
+			}
+			refetch = append(refetch, m.Height)
+		}
+	}
+
+	if opts.Repair && !report.OK() {
+		if err := repair(ctx, repo, info, report, refetch); err != nil {
+			return report, fmt.Errorf("repair failed: %w", err)
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// appendRange adds r, merging it into the last range when they touch. Past maxReported
+// ranges only the last one keeps growing, so the list stays bounded.
+func appendRange(ranges []HeightRange, r HeightRange) []HeightRange {
+	if n := len(ranges); n > 0 && (ranges[n-1].To+1 >= r.From || n >= maxReported) {
+		ranges[n-1].To = r.To
+		return ranges
+	}
+	return append(ranges, r)
+}
+
+// repair deletes the blocks in refetch and queues one backfill job per gap, old and new,
+// so the chain's worker fetches them all again
+func repair(ctx context.Context, repo repository.Repository, info chains.Info, report *Report, refetch []uint64) error {
+	tag := "[" + strings.ToUpper(info.Name) + "]"
+
+	sort.Slice(refetch, func(i, j int) bool { return refetch[i] < refetch[j] })
+	var ranges []HeightRange
+	deleted := map[uint64]bool{}
+	for _, h := range refetch {
+		if deleted[h] || h < report.From {
+			continue
+		}
+		if err := repo.DeleteBlock(ctx, info.Type, h); err != nil {
+			return fmt.Errorf("failed to delete block %d: %w", h, err)
+		}
+		deleted[h] = true
+		ranges = append(ranges, HeightRange{From: h, To: h})
+	}
+	if len(deleted) > 0 {
+		log.Printf("%s Deleted %d inconsistent blocks for re-fetching", tag, len(deleted))
+	}
+
+	// Gaps beyond maxReported were merged into one wide range, which backfill handles
+	// fine since it skips whatever is stored
+	ranges = append(ranges, report.Missing...)
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].From < ranges[j].From })
+	var merged []HeightRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && merged[n-1].To+1 >= r.From {
+			merged[n-1].To = max(merged[n-1].To, r.To)
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	for _, r := range merged {
+		job := &model.BackfillJob{
+			Chain:      info.Type,
+			FromHeight: r.From,
+			ToHeight:   r.To,
+			NextHeight: r.From,
+			Status:     model.BackfillPending,
+		}
+		if err := repo.CreateBackfillJob(ctx, job); err != nil {
+			return fmt.Errorf("failed to queue backfill for %d-%d: %w", r.From, r.To, err)
+		}
+		report.RepairJobs = append(report.RepairJobs, job.ID)
+	}
+	log.Printf("%s Queued %d backfill jobs to repair the chain", tag, len(merged))
+	return nil
+}
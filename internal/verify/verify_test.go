@@ -0,0 +1,244 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/chains"
+	"indexer/internal/model"
+	"indexer/internal/repository"
+	"sort"
+	"testing"
+)
+
+// fakeRepo is a chain of blocks lowest..state with the given heights missing and the given
+// broken links and tx count mismatches. Methods Run does not use panic through the nil
+// embedded interface.
+type fakeRepo struct {
+	repository.Repository
+
+	lowest, state uint64
+	missing       map[uint64]bool
+	brokenLinks   []uint64
+	mismatches    []uint64
+
+	deleted []uint64
+	jobs    []HeightRange
+}
+
+func (r *fakeRepo) GetMinBlockHeight(ctx context.Context, chain model.ChainType) (uint64, error) {
+	return r.lowest, nil
+}
+
+func (r *fakeRepo) GetState(ctx context.Context, chain model.ChainType) (uint64, error) {
+	return r.state, nil
+}
+
+func (r *fakeRepo) GetStoredHeights(ctx context.Context, chain model.ChainType, from, to uint64) ([]uint64, error) {
+	var heights []uint64
+	for h := max(from, r.lowest); h <= min(to, r.state); h++ {
+		if !r.missing[h] {
+			heights = append(heights, h)
+		}
+	}
+	return heights, nil
+}
+
+func inRange(heights []uint64, from, to uint64) []uint64 {
+	var res []uint64
+	for _, h := range heights {
+		if h >= from && h <= to {
+			res = append(res, h)
+		}
+	}
+	return res
+}
+
+func (r *fakeRepo) FindBrokenLinks(ctx context.Context, chain model.ChainType, from, to uint64) ([]uint64, error) {
+	return inRange(r.brokenLinks, from, to), nil
+}
+
+func (r *fakeRepo) FindTxCountMismatches(ctx context.Context, chain model.ChainType, from, to uint64) ([]repository.TxCountMismatch, error) {
+	var res []repository.TxCountMismatch
+	for _, h := range inRange(r.mismatches, from, to) {
+		res = append(res, repository.TxCountMismatch{Height: h, Expected: 2, Actual: 1})
+	}
+	return res, nil
+}
+
+func (r *fakeRepo) DeleteBlock(ctx context.Context, chain model.ChainType, height uint64) error {
+	r.deleted = append(r.deleted, height)
+	return nil
+}
+
+func (r *fakeRepo) CreateBackfillJob(ctx context.Context, job *model.BackfillJob) error {
+	r.jobs = append(r.jobs, HeightRange{From: job.FromHeight, To: job.ToHeight})
+	job.ID = uint(len(r.jobs))
+	return nil
+}
+
+func heights(hs ...uint64) map[uint64]bool {
+	m := map[uint64]bool{}
+	for _, h := range hs {
+		m[h] = true
+	}
+	return m
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		repo         *fakeRepo
+		opts         Options
+		wantFrom     uint64
+		wantTo       uint64
+		wantMissing  string
+		wantCount    uint64
+		wantLinks    int
+		wantMismatch int
+		wantDeleted  string
+		wantJobs     string
+	}{
+		{
+			name:        "consistent chain",
+			repo:        &fakeRepo{lowest: 100, state: 200},
+			wantFrom:    100,
+			wantTo:      200,
+			wantMissing: "[]",
+		},
+		{
+			name:        "gaps are merged into ranges",
+			repo:        &fakeRepo{lowest: 0, state: 50, missing: heights(5, 6, 7, 20)},
+			wantTo:      50,
+			wantMissing: "[{5 7} {20 20}]",
+			wantCount:   4,
+		},
+		{
+			name:        "a gap across a chunk boundary is one range",
+			repo:        &fakeRepo{lowest: 1, state: chunkSize + 100, missing: heights(chunkSize-1, chunkSize, chunkSize+1)},
+			wantFrom:    1,
+			wantTo:      chunkSize + 100,
+			wantMissing: fmt.Sprintf("[{%d %d}]", chunkSize-1, chunkSize+1),
+			wantCount:   3,
+		},
+		{
+			name:        "explicit range",
+			repo:        &fakeRepo{lowest: 0, state: 100, missing: heights(5, 60)},
+			opts:        Options{From: 50, To: 70},
+			wantFrom:    50,
+			wantTo:      70,
+			wantMissing: "[{60 60}]",
+			wantCount:   1,
+		},
+		{
+			name:        "empty range",
+			repo:        &fakeRepo{lowest: 100, state: 50},
+			wantFrom:    100,
+			wantTo:      50,
+			wantMissing: "[]",
+		},
+		{
+			name:         "repair deletes both sides of broken links and mismatched blocks",
+			repo:         &fakeRepo{lowest: 10, state: 100, missing: heights(20, 21), brokenLinks: []uint64{50}, mismatches: []uint64{52, 70}},
+			opts:         Options{Repair: true},
+			wantFrom:     10,
+			wantTo:       100,
+			wantMissing:  "[{20 21}]",
+			wantCount:    2,
+			wantLinks:    1,
+			wantMismatch: 2,
+			wantDeleted:  "[49 50 52 70]",
+			wantJobs:     "[{20 21} {49 50} {52 52} {70 70}]",
+		},
+		{
+			name:        "repair merges touching ranges and leaves blocks below the range alone",
+			repo:        &fakeRepo{lowest: 10, state: 100, missing: heights(48), brokenLinks: []uint64{10, 50}},
+			opts:        Options{Repair: true},
+			wantFrom:    10,
+			wantTo:      100,
+			wantMissing: "[{48 48}]",
+			wantCount:   1,
+			wantLinks:   2,
+			wantDeleted: "[10 49 50]",
+			wantJobs:    "[{10 10} {48 50}]",
+		},
+		{
+			name:         "no repair without the option",
+			repo:         &fakeRepo{lowest: 0, state: 100, mismatches: []uint64{5}},
+			wantTo:       100,
+			wantMissing:  "[]",
+			wantMismatch: 1,
+			wantDeleted:  "[]",
+			wantJobs:     "[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Run(context.Background(), tt.repo, chains.Bitcoin, tt.opts)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if report.From != tt.wantFrom || report.To != tt.wantTo {
+				t.Errorf("range = %d-%d, want %d-%d", report.From, report.To, tt.wantFrom, tt.wantTo)
+			}
+			if got := fmt.Sprint(report.Missing); got != tt.wantMissing {
+				t.Errorf("missing = %s, want %s", got, tt.wantMissing)
+			}
+			if report.MissingCount != tt.wantCount || report.BrokenLinkCount != tt.wantLinks || report.MismatchCount != tt.wantMismatch {
+				t.Errorf("counts = %d missing, %d links, %d mismatches, want %d, %d, %d",
+					report.MissingCount, report.BrokenLinkCount, report.MismatchCount, tt.wantCount, tt.wantLinks, tt.wantMismatch)
+			}
+			wantOK := tt.wantCount == 0 && tt.wantLinks == 0 && tt.wantMismatch == 0
+			if report.OK() != wantOK {
+				t.Errorf("OK() = %v, want %v", report.OK(), wantOK)
+			}
+			if tt.wantDeleted != "" {
+				sort.Slice(tt.repo.deleted, func(i, j int) bool { return tt.repo.deleted[i] < tt.repo.deleted[j] })
+				if got := fmt.Sprint(tt.repo.deleted); got != tt.wantDeleted {
+					t.Errorf("deleted = %s, want %s", got, tt.wantDeleted)
+				}
+			}
+			if tt.wantJobs != "" {
+				if got := fmt.Sprint(tt.repo.jobs); got != tt.wantJobs {
+					t.Errorf("jobs = %s, want %s", got, tt.wantJobs)
+				}
+				if len(report.RepairJobs) != len(tt.repo.jobs) {
+					t.Errorf("report lists %d repair jobs, %d were queued", len(report.RepairJobs), len(tt.repo.jobs))
+				}
+			}
+		})
+	}
+}
+
+func TestAppendRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		ranges []HeightRange
+		add    HeightRange
+		want   string
+	}{
+		{name: "first range", add: HeightRange{5, 7}, want: "[{5 7}]"},
+		{name: "touching range is merged", ranges: []HeightRange{{5, 7}}, add: HeightRange{8, 9}, want: "[{5 9}]"},
+		{name: "separate range is appended", ranges: []HeightRange{{5, 7}}, add: HeightRange{9, 9}, want: "[{5 7} {9 9}]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(appendRange(tt.ranges, tt.add)); got != tt.want {
+				t.Errorf("appendRange() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("stays bounded", func(t *testing.T) {
+		var ranges []HeightRange
+		for i := uint64(0); i < maxReported+10; i++ {
+			ranges = appendRange(ranges, HeightRange{i * 10, i * 10})
+		}
+		if len(ranges) != maxReported {
+			t.Fatalf("%d ranges, want %d", len(ranges), maxReported)
+		}
+		if last := ranges[len(ranges)-1]; last.To != (maxReported+9)*10 {
+			t.Errorf("last range %v does not reach the last gap", last)
+		}
+	})
+}
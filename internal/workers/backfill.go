@@ -0,0 +1,271 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"indexer/internal/model"
+	"indexer/internal/repository"
+	"indexer/internal/supervisor"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrJobNotFound is returned when cancelling a backfill job that does not exist or has finished
+var ErrJobNotFound = errors.New("no such unfinished backfill job")
+
+const (
+	// backfillIdlePoll is how often an idle worker looks for new jobs it was not woken for
+	backfillIdlePoll = 30 * time.Second
+
+	backfillRetryMin = 5 * time.Second
+	backfillRetryMax = 5 * time.Minute
+)
+
+// StartBackfill queues a job indexing heights from..to, which must lie at or below the live
+// cursor, and wakes the backfill goroutine
+func (w *Worker) StartBackfill(ctx context.Context, from, to uint64) (*model.BackfillJob, error) {
+	if from > to {
+		return nil, fmt.Errorf("%w: from %d is above to %d", ErrInvalidHeight, from, to)
+	}
+	last, err := w.repo.GetState(ctx, w.info.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state: %w", err)
+	}
+	if to > last {
+		return nil, fmt.Errorf("%w: %d is above the last indexed block %d, which live sync will reach on its own", ErrInvalidHeight, to, last)
+	}
+
+	job := &model.BackfillJob{
+		Chain:      w.info.Type,
+		FromHeight: from,
+		ToHeight:   to,
+		NextHeight: from,
+		Status:     model.BackfillPending,
+	}
+	if err := w.repo.CreateBackfillJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create backfill job: %w", err)
+	}
+	log.Printf("%s Backfill job %d queued for blocks %d-%d", w.tag, job.ID, from, to)
+
+	select {
+	case w.backfillWake <- struct{}{}:
+	default: // a wake-up is already pending
+	}
+	return job, nil
+}
+
+// CancelBackfill stops an unfinished job; a window in flight is still committed
+func (w *Worker) CancelBackfill(ctx context.Context, id uint) error {
+	if _, err := w.repo.GetBackfillJob(ctx, w.info.Type, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrJobNotFound
+		}
+		return err
+	}
+	if err := w.repo.SetBackfillStatus(ctx, id, model.BackfillCancelled, ""); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrJobNotFound
+		}
+		return err
+	}
+	log.Printf("%s Backfill job %d cancelled", w.tag, id)
+	return nil
+}
+
+// queueStartBackfill turns a configured start height below what is already indexed into a
+// backfill job, since the live cursor only moves forward. It runs once per job: a job
+// starting at the same height is not queued again.
+func (w *Worker) queueStartBackfill(ctx context.Context) error {
+	if w.startHeight <= 0 {
+		return nil
+	}
+	if count, err := w.repo.CountBlocks(ctx, w.info.Type); err != nil || count == 0 {
+		return err
+	}
+	lowest, err := w.repo.GetMinBlockHeight(ctx, w.info.Type)
+	if err != nil {
+		return err
+	}
+	// The live cursor starts after the configured height, so backfill does too
+	from := uint64(w.startHeight) + 1
+	if lowest <= from {
+		return nil
+	}
+
+	jobs, err := w.repo.GetBackfillJobs(ctx, w.info.Type, 1000, 0)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.FromHeight == from && job.Status != model.BackfillCancelled {
+			return nil
+		}
+	}
+	_, err = w.StartBackfill(ctx, from, lowest-1)
+	return err
+}
+
+// runBackfills works through the chain's backfill jobs, oldest first, until ctx is done.
+// Failed jobs are retried with backoff from where they stopped.
+func (w *Worker) runBackfills(ctx context.Context) {
+	backoff := backfillRetryMin
+	for {
+		wait := backfillIdlePoll
+		if !w.paused.Load() {
+			job, err := w.repo.NextBackfillJob(ctx, w.info.Type)
+			switch {
+			case err != nil:
+				log.Printf("%s Failed to load backfill jobs: %v", w.tag, err)
+			case job != nil:
+				err := w.runBackfill(ctx, job)
+				if err == nil {
+					backoff = backfillRetryMin
+					continue
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				log.Printf("%s Backfill job %d failed, retrying in %s: %v", w.tag, job.ID, backoff, err)
+				if err := w.repo.SetBackfillStatus(ctx, job.ID, model.BackfillRunning, err.Error()); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					log.Printf("%s Failed to record backfill error: %v", w.tag, err)
+				}
+				wait = backoff
+				backoff *= 2
+				if backoff > backfillRetryMax {
+					backoff = backfillRetryMax
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.backfillWake:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// runBackfill indexes the job's remaining heights window by window. It returns nil early
+// when the job is cancelled or the worker paused.
+func (w *Worker) runBackfill(ctx context.Context, job *model.BackfillJob) error {
+	if job.Status == model.BackfillPending {
+		if err := w.repo.SetBackfillStatus(ctx, job.ID, model.BackfillRunning, ""); err != nil {
+			return err
+		}
+		log.Printf("%s Backfill job %d started: blocks %d-%d", w.tag, job.ID, job.FromHeight, job.ToHeight)
+	}
+
+	window := syncWindow(w.concurrency, w.batchSize)
+	for next := job.NextHeight; next <= job.ToHeight; {
+		if w.paused.Load() {
+			return nil
+		}
+		current, err := w.repo.GetBackfillJob(ctx, w.info.Type, job.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status == model.BackfillCancelled {
+			return nil
+		}
+
+		end := windowEnd(next, job.ToHeight, window)
+
+		// Only fetch what neither live sync nor an earlier job has stored
+		stored, err := w.repo.GetStoredHeights(ctx, w.info.Type, next, end)
+		if err != nil {
+			return err
+		}
+		have := make(map[uint64]bool, len(stored))
+		for _, h := range stored {
+			have[h] = true
+		}
+		var missing []uint64
+		for h := next; h <= end; h++ {
+			if !have[h] {
+				missing = append(missing, h)
+			}
+		}
+
+		blocks, err := fetchBlocks(ctx, missing, w.concurrency, w.adapter.FetchBlock)
+		if err != nil {
+			return err
+		}
+		batchSize := max(w.batchSize, 1)
+		saved := 0
+		for start := 0; ; start += batchSize {
+			stop := min(start+batchSize, len(blocks))
+			// The last chunk, possibly empty, moves the cursor past the whole window
+			cursor := end + 1
+			if stop < len(blocks) {
+				cursor = blocks[stop-1].Block.Height + 1
+			}
+			n, err := w.repo.SaveBackfillBlocks(ctx, job.ID, blocks[start:stop], cursor)
+			if err != nil {
+				return fmt.Errorf("failed to save backfill blocks: %w", err)
+			}
+			saved += n
+			if stop == len(blocks) {
+				break
+			}
+		}
+
+		log.Printf("%s Backfill job %d: blocks %d-%d done (%d new) / %d", w.tag, job.ID, next, end, saved, job.ToHeight)
+		next = end + 1
+	}
+
+	if err := w.repo.SetBackfillStatus(ctx, job.ID, model.BackfillDone, ""); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	log.Printf("%s Backfill job %d finished", w.tag, job.ID)
+	return nil
+}
+
+// fetchBlocks fetches the given heights with `concurrency` parallel fetchers and returns
+// them in the same order, failing on the first error
+func fetchBlocks(ctx context.Context, heights []uint64, concurrency int, fetch blockFetcher) ([]repository.BlockWithTransactions, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	blocks := make([]repository.BlockWithTransactions, len(heights))
+	errs := make(chan error, len(heights))
+	sem := make(chan struct{}, concurrency)
+	for i, h := range heights {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case err := <-errs:
+			return nil, err
+		}
+		supervisor.Spawn(ctx, func() {
+			defer func() { <-sem }()
+			block, txs, err := fetch(ctx, h)
+			if err != nil {
+				errs <- fmt.Errorf("failed to fetch block %d: %w", h, err)
+				return
+			}
+			blocks[i] = repository.BlockWithTransactions{Block: block, Txs: txs}
+		})
+	}
+	// Wait for the stragglers by taking every slot back
+	for i := 0; i < concurrency; i++ {
+		sem <- struct{}{}
+	}
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+	// A fetcher that panicked left its slot empty and cancelled the context
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
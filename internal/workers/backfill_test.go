@@ -0,0 +1,269 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/chains"
+	"indexer/internal/model"
+	"indexer/internal/repository"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAdapter serves blocks through a blockFetcher and records which heights were fetched
+type fakeAdapter struct {
+	info  chains.Info
+	fetch blockFetcher
+
+	mu      sync.Mutex
+	fetched []uint64
+}
+
+func (a *fakeAdapter) Info() chains.Info { return a.info }
+
+func (a *fakeAdapter) GetTip(ctx context.Context) (uint64, error) { return 0, nil }
+
+func (a *fakeAdapter) GetBlockHash(ctx context.Context, height uint64) (string, error) {
+	return blockHash(height), nil
+}
+
+func (a *fakeAdapter) FetchBlock(ctx context.Context, height uint64) (*model.Block, []*model.Transaction, error) {
+	a.mu.Lock()
+	a.fetched = append(a.fetched, height)
+	a.mu.Unlock()
+	return a.fetch(ctx, height)
+}
+
+// backfillRepo keeps one job and the stored heights in memory. SaveBackfillBlocks skips
+// stored heights and heights above the live cursor, as the real one does.
+type backfillRepo struct {
+	repository.Repository
+
+	job    model.BackfillJob
+	stored map[uint64]bool
+	last   uint64
+
+	// cancelAt cancels the job once its cursor moves past this height
+	cancelAt uint64
+
+	saves    []string
+	written  []uint64
+	statuses []string
+}
+
+func (r *backfillRepo) GetBackfillJob(ctx context.Context, chain model.ChainType, id uint) (*model.BackfillJob, error) {
+	job := r.job
+	return &job, nil
+}
+
+func (r *backfillRepo) SetBackfillStatus(ctx context.Context, id uint, status, lastError string) error {
+	r.job.Status = status
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+func (r *backfillRepo) GetStoredHeights(ctx context.Context, chain model.ChainType, from, to uint64) ([]uint64, error) {
+	var heights []uint64
+	for h := from; h <= to; h++ {
+		if r.stored[h] {
+			heights = append(heights, h)
+		}
+	}
+	return heights, nil
+}
+
+func (r *backfillRepo) SaveBackfillBlocks(ctx context.Context, jobID uint, batch []repository.BlockWithTransactions, next uint64) (int, error) {
+	var heights []string
+	n := 0
+	for _, b := range batch {
+		h := b.Block.Height
+		heights = append(heights, fmt.Sprint(h))
+		if !r.stored[h] && h <= r.last {
+			r.stored[h] = true
+			r.written = append(r.written, h)
+			n++
+		}
+	}
+	r.saves = append(r.saves, strings.Join(heights, ",")+"->"+fmt.Sprint(next))
+	r.job.NextHeight = next
+	if r.cancelAt != 0 && next > r.cancelAt {
+		r.job.Status = model.BackfillCancelled
+	}
+	return n, nil
+}
+
+func TestRunBackfill(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to uint64
+		next     uint64
+		status   string
+		stored   []uint64
+		last     uint64
+		cancelAt uint64
+		paused   bool
+		fail     map[uint64]bool
+
+		wantFetched  []uint64
+		wantSaves    []string
+		wantWritten  []uint64
+		wantNext     uint64
+		wantStatuses []string
+		wantErr      string
+	}{
+		{
+			name: "splits windows into batches and moves the cursor per batch",
+			from: 1, to: 10, next: 1, status: model.BackfillPending, last: 100,
+			wantFetched:  heightRange(1, 10),
+			wantSaves:    []string{"1,2->3", "3,4->5", "5,6->7", "7,8->9", "9,10->11"},
+			wantWritten:  heightRange(1, 10),
+			wantNext:     11,
+			wantStatuses: []string{model.BackfillRunning, model.BackfillDone},
+		},
+		{
+			name: "does not fetch stored heights",
+			from: 1, to: 10, next: 1, status: model.BackfillPending, stored: []uint64{2, 3, 4, 9}, last: 100,
+			wantFetched:  []uint64{1, 5, 6, 7, 8, 10},
+			wantSaves:    []string{"1,5->6", "6,7->8", "8->9", "10->11"},
+			wantWritten:  []uint64{1, 5, 6, 7, 8, 10},
+			wantNext:     11,
+			wantStatuses: []string{model.BackfillRunning, model.BackfillDone},
+		},
+		{
+			name: "a fully stored window still moves the cursor past it",
+			from: 1, to: 10, next: 1, status: model.BackfillPending, stored: heightRange(1, 8), last: 100,
+			wantFetched:  []uint64{9, 10},
+			wantSaves:    []string{"->9", "9,10->11"},
+			wantWritten:  []uint64{9, 10},
+			wantNext:     11,
+			wantStatuses: []string{model.BackfillRunning, model.BackfillDone},
+		},
+		{
+			name: "resumes from the job's next height",
+			from: 1, to: 10, next: 6, status: model.BackfillRunning, last: 100,
+			wantFetched:  heightRange(6, 10),
+			wantSaves:    []string{"6,7->8", "8,9->10", "10->11"},
+			wantWritten:  heightRange(6, 10),
+			wantNext:     11,
+			wantStatuses: []string{model.BackfillDone},
+		},
+		{
+			name: "does not write above the live cursor",
+			from: 1, to: 10, next: 1, status: model.BackfillPending, last: 8,
+			wantFetched:  heightRange(1, 10),
+			wantSaves:    []string{"1,2->3", "3,4->5", "5,6->7", "7,8->9", "9,10->11"},
+			wantWritten:  heightRange(1, 8),
+			wantNext:     11,
+			wantStatuses: []string{model.BackfillRunning, model.BackfillDone},
+		},
+		{
+			name: "a failed fetch leaves the cursor after the last saved window",
+			from: 1, to: 20, next: 1, status: model.BackfillPending, last: 100, fail: map[uint64]bool{16: true},
+			wantFetched:  heightRange(1, 16),
+			wantSaves:    []string{"1,2->3", "3,4->5", "5,6->7", "7,8->9"},
+			wantWritten:  heightRange(1, 8),
+			wantNext:     9,
+			wantStatuses: []string{model.BackfillRunning},
+			wantErr:      "failed to fetch block 16",
+		},
+		{
+			name: "stops after the window in flight when cancelled",
+			from: 1, to: 20, next: 1, status: model.BackfillPending, last: 100, cancelAt: 4,
+			wantFetched:  heightRange(1, 8),
+			wantSaves:    []string{"1,2->3", "3,4->5", "5,6->7", "7,8->9"},
+			wantWritten:  heightRange(1, 8),
+			wantNext:     9,
+			wantStatuses: []string{model.BackfillRunning},
+		},
+		{
+			name: "does nothing while paused",
+			from: 1, to: 10, next: 1, status: model.BackfillRunning, last: 100, paused: true,
+			wantNext: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &backfillRepo{
+				job: model.BackfillJob{
+					ID: 1, Chain: model.ChainBTC, FromHeight: tt.from, ToHeight: tt.to, NextHeight: tt.next, Status: tt.status,
+				},
+				stored:   map[uint64]bool{},
+				last:     tt.last,
+				cancelAt: tt.cancelAt,
+			}
+			for _, h := range tt.stored {
+				repo.stored[h] = true
+			}
+			adapter := &fakeAdapter{info: chains.Bitcoin, fetch: chainFetcher(nil, tt.fail)}
+			// One fetcher and batches of two make windows of eight heights
+			w := NewWorker(repo, adapter, SyncOptions{Concurrency: 1, BatchSize: 2})
+			w.paused.Store(tt.paused)
+
+			job := repo.job
+			err := w.runBackfill(context.Background(), &job)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("runBackfill() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("runBackfill() error = %v, want %q", err, tt.wantErr)
+			}
+
+			slices.Sort(adapter.fetched)
+			if fmt.Sprint(adapter.fetched) != fmt.Sprint(tt.wantFetched) {
+				t.Errorf("fetched %v, want %v", adapter.fetched, tt.wantFetched)
+			}
+			if fmt.Sprint(repo.saves) != fmt.Sprint(tt.wantSaves) {
+				t.Errorf("saves %v, want %v", repo.saves, tt.wantSaves)
+			}
+			if fmt.Sprint(repo.written) != fmt.Sprint(tt.wantWritten) {
+				t.Errorf("wrote %v, want %v", repo.written, tt.wantWritten)
+			}
+			if repo.job.NextHeight != tt.wantNext {
+				t.Errorf("next height = %d, want %d", repo.job.NextHeight, tt.wantNext)
+			}
+			if fmt.Sprint(repo.statuses) != fmt.Sprint(tt.wantStatuses) {
+				t.Errorf("statuses %v, want %v", repo.statuses, tt.wantStatuses)
+			}
+		})
+	}
+}
+
+// A job that failed mid-way picks up at its cursor and fetches nothing it already saved
+func TestRunBackfillRetry(t *testing.T) {
+	repo := &backfillRepo{
+		job:    model.BackfillJob{ID: 1, Chain: model.ChainBTC, FromHeight: 1, ToHeight: 12, NextHeight: 1, Status: model.BackfillPending},
+		stored: map[uint64]bool{},
+		last:   100,
+	}
+	fail := map[uint64]bool{12: true}
+	adapter := &fakeAdapter{info: chains.Bitcoin, fetch: chainFetcher(nil, fail)}
+	w := NewWorker(repo, adapter, SyncOptions{Concurrency: 1, BatchSize: 2})
+
+	job := repo.job
+	if err := w.runBackfill(context.Background(), &job); err == nil {
+		t.Fatal("runBackfill() succeeded with a failing node")
+	}
+	if repo.job.NextHeight != 9 {
+		t.Fatalf("next height after the failure = %d, want 9", repo.job.NextHeight)
+	}
+
+	delete(fail, 12)
+	adapter.fetched = nil
+	job = repo.job
+	if err := w.runBackfill(context.Background(), &job); err != nil {
+		t.Fatalf("runBackfill() retry error = %v", err)
+	}
+	slices.Sort(adapter.fetched)
+	if fmt.Sprint(adapter.fetched) != fmt.Sprint(heightRange(9, 12)) {
+		t.Errorf("retry fetched %v, want %v", adapter.fetched, heightRange(9, 12))
+	}
+	if fmt.Sprint(repo.written) != fmt.Sprint(heightRange(1, 12)) {
+		t.Errorf("wrote %v, want every height once", repo.written)
+	}
+	if repo.job.NextHeight != 13 || repo.job.Status != model.BackfillDone {
+		t.Errorf("job ended at %d with status %s", repo.job.NextHeight, repo.job.Status)
+	}
+}
@@ -0,0 +1,768 @@
+package workers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"indexer/internal/chains"
+	"indexer/internal/model"
+	"indexer/internal/rpcpool"
+	"indexer/internal/supervisor"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// BTCAdapter indexes a Bitcoin Core node, or a node of a chain speaking the same JSON-RPC
+// such as Litecoin or Dogecoin
+type BTCAdapter struct {
+	info         chains.Info
+	pool         *rpcpool.Pool[btcNode]
+	addressField string
+	zmqURL       string
+	client       *http.Client
+	prevouts     *lruCache[string, prevout]
+	strict       bool // fail blocks with inputs whose spent output cannot be resolved
+
+	// set once the first unresolved input has been logged
+	unresolvedLogged atomic.Bool
+	// set once the node has rejected getblock verbosity 2, as Dogecoin Core does
+	noVerboseBlocks atomic.Bool
+}
+
+// btcNode is one JSON-RPC endpoint of the network
+type btcNode struct {
+	url  string
+	user string
+	pass string
+}
+
+// Which scriptPubKey field output addresses are read from. Bitcoin Core 22+ only fills
+// address, older and forked nodes only the legacy addresses list.
+const (
+	addressFieldAuto   = ""
+	addressFieldSingle = "address"
+	addressFieldLegacy = "addresses"
+)
+
+// BTCOptions are the node and dialect settings of one Bitcoin-family network
+type BTCOptions struct {
+	RPCURLs          []string // several URLs are used as a failover pool
+	RPCUser          string
+	RPCPass          string
+	Pool             rpcpool.Options
+	PrevoutCacheSize int
+	AddressField     string
+	ZMQURL           string // hashblock publisher, e.g. tcp://127.0.0.1:28332
+	StrictPrevouts   bool   // retry blocks with unresolvable inputs instead of storing them without a fee
+}
+
+// NewBTCAdapter creates the adapter of one Bitcoin-family network. info selects the
+// network's tables and state and its coin decimals; Bitcoin mainnet is chains.Bitcoin.
+func NewBTCAdapter(info chains.Info, opts BTCOptions) (*BTCAdapter, error) {
+	if len(opts.RPCURLs) == 0 {
+		return nil, fmt.Errorf("no RPC URL configured for %s", info.Name)
+	}
+	switch opts.AddressField {
+	case addressFieldAuto, addressFieldSingle, addressFieldLegacy:
+	default:
+		return nil, fmt.Errorf("invalid address field %q, expected %q or %q", opts.AddressField, addressFieldSingle, addressFieldLegacy)
+	}
+
+	w := &BTCAdapter{
+		info:         info,
+		addressField: opts.AddressField,
+		zmqURL:       opts.ZMQURL,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		prevouts:     newLRUCache[string, prevout](opts.PrevoutCacheSize),
+		strict:       opts.StrictPrevouts,
+	}
+
+	nodes := make([]btcNode, len(opts.RPCURLs))
+	for i, url := range opts.RPCURLs {
+		nodes[i] = btcNode{url: url, user: opts.RPCUser, pass: opts.RPCPass}
+	}
+	pool, err := rpcpool.New("["+strings.ToUpper(info.Name)+"]", opts.RPCURLs, nodes, w.nodeTip, opts.Pool)
+	if err != nil {
+		return nil, err
+	}
+	w.pool = pool
+	return w, nil
+}
+
+func (w *BTCAdapter) Info() chains.Info {
+	return w.info
+}
+
+// Init starts health-checking the RPC endpoints for as long as the worker runs
+func (w *BTCAdapter) Init(ctx context.Context) error {
+	supervisor.Spawn(ctx, func() { w.pool.Monitor(ctx) })
+	return nil
+}
+
+// Endpoints reports the health of every RPC endpoint
+func (w *BTCAdapter) Endpoints() []rpcpool.EndpointStatus {
+	return w.pool.Status()
+}
+
+// rpcBatchSize caps how many calls we pack into one JSON-RPC batch request.
+const rpcBatchSize = 100
+
+type btcScriptPubKey struct {
+	Type      string   `json:"type"`
+	Address   string   `json:"address"`
+	Addresses []string `json:"addresses"`
+}
+
+// addresses returns the output's addresses from the configured field. By default that is
+// address, falling back to the legacy addresses list.
+func (s btcScriptPubKey) addresses(field string) []string {
+	switch field {
+	case addressFieldLegacy:
+		return s.Addresses
+	case addressFieldSingle:
+		if s.Address == "" {
+			return nil
+		}
+		return []string{s.Address}
+	}
+	if s.Address != "" {
+		return []string{s.Address}
+	}
+	return s.Addresses
+}
+
+type btcVout struct {
+	Value        json.Number     `json:"value"`
+	ScriptPubKey btcScriptPubKey `json:"scriptPubKey"`
+}
+
+type btcTx struct {
+	Txid string `json:"txid"`
+	Vin  []struct {
+		Txid string `json:"txid"`
+		Vout int    `json:"vout"`
+	} `json:"vin"`
+	Vout []btcVout `json:"vout"`
+}
+
+func (t btcTx) isCoinbase() bool {
+	return len(t.Vin) > 0 && t.Vin[0].Txid == ""
+}
+
+// prevout is what we remember about a transaction output so later inputs spending it
+// can be resolved without asking the node.
+type prevout struct {
+	addresses  []string
+	value      int64
+	scriptType string
+}
+
+func (w *BTCAdapter) toPrevout(v btcVout) (prevout, error) {
+	value, err := toBaseUnits(v.Value, w.info.Decimals)
+	if err != nil {
+		return prevout{}, err
+	}
+	return prevout{
+		addresses:  v.ScriptPubKey.addresses(w.addressField),
+		value:      value,
+		scriptType: v.ScriptPubKey.Type,
+	}, nil
+}
+
+// toBaseUnits converts a decimal coin amount from the RPC to base units (satoshis for
+// Bitcoin) without going through float64. Output values are never negative, and the sign
+// of "-0.5" would be lost with its whole part, so a leading minus is rejected.
+func toBaseUnits(n json.Number, decimals int) (int64, error) {
+	if strings.HasPrefix(n.String(), "-") {
+		return 0, fmt.Errorf("negative amount %s", n)
+	}
+	whole, frac, _ := strings.Cut(n.String(), ".")
+	if len(frac) > decimals {
+		return 0, fmt.Errorf("amount %s has more than %d decimals", n, decimals)
+	}
+	frac += strings.Repeat("0", decimals-len(frac))
+
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %s: %w", n, err)
+	}
+	if frac == "" {
+		return w, nil
+	}
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %s: %w", n, err)
+	}
+	return w*pow10(decimals) + f, nil
+}
+
+func pow10(n int) int64 {
+	res := int64(1)
+	for i := 0; i < n; i++ {
+		res *= 10
+	}
+	return res
+}
+
+func outpointKey(txid string, vout int) string {
+	return fmt.Sprintf("%s:%d", txid, vout)
+}
+
+type jsonRPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      int           `json:"id"`
+}
+
+type jsonRPCResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *jsonRPCError   `json:"error"`
+	ID     int             `json:"id"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *jsonRPCError) Error() string {
+	return fmt.Sprintf("RPC error: %d %s", e.Code, e.Message)
+}
+
+// Bitcoin Core error codes we react to
+const (
+	rpcTypeError        = -3
+	rpcInvalidParameter = -8
+	rpcMethodNotFound   = -32601
+)
+
+// callRPC runs one call on the best endpoint, failing over to the others. Errors the node
+// answers deliberately, such as unknown methods or parameter types, are not retried.
+func (w *BTCAdapter) callRPC(ctx context.Context, method string, params []interface{}) (json.RawMessage, error) {
+	return rpcpool.Call(ctx, w.pool, func(node btcNode) (json.RawMessage, error) {
+		res, err := w.callNode(ctx, node, method, params)
+		var rpcErr *jsonRPCError
+		if errors.As(err, &rpcErr) && (rpcErr.Code == rpcTypeError || rpcErr.Code == rpcMethodNotFound) {
+			return nil, rpcpool.Permanent(err)
+		}
+		return res, err
+	})
+}
+
+func (w *BTCAdapter) callNode(ctx context.Context, node btcNode, method string, params []interface{}) (json.RawMessage, error) {
+	reqBody, _ := json.Marshal(jsonRPCRequest{
+		JSONRPC: "1.0",
+		Method:  method,
+		Params:  params,
+		ID:      1,
+	})
+
+	var rpcResp jsonRPCResponse
+	if err := w.post(ctx, node, reqBody, &rpcResp); err != nil {
+		return nil, err
+	}
+
+	if rpcResp.Error != nil {
+		return nil, rpcResp.Error
+	}
+
+	return rpcResp.Result, nil
+}
+
+// callRPCBatch sends every request in a single HTTP round trip. Responses are returned in
+// request order; per-call errors are left on each response for the caller to inspect.
+func (w *BTCAdapter) callRPCBatch(ctx context.Context, reqs []jsonRPCRequest) ([]jsonRPCResponse, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+
+	reqBody, _ := json.Marshal(reqs)
+
+	var rpcResps []jsonRPCResponse
+	err := w.pool.Do(ctx, func(node btcNode) error {
+		rpcResps = nil
+		return w.post(ctx, node, reqBody, &rpcResps)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The spec allows responses in any order, so match them back up by ID
+	byID := make(map[int]jsonRPCResponse, len(rpcResps))
+	for _, r := range rpcResps {
+		byID[r.ID] = r
+	}
+	ordered := make([]jsonRPCResponse, len(reqs))
+	for i, req := range reqs {
+		r, ok := byID[req.ID]
+		if !ok {
+			return nil, fmt.Errorf("RPC batch missing response for id %d (%s)", req.ID, req.Method)
+		}
+		ordered[i] = r
+	}
+
+	return ordered, nil
+}
+
+func (w *BTCAdapter) post(ctx context.Context, node btcNode, reqBody []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", node.url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if node.user != "" || node.pass != "" {
+		req.SetBasicAuth(node.user, node.pass)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// Bitcoin Core answers failed calls with a 500 carrying the JSON-RPC error
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode != http.StatusInternalServerError || json.Unmarshal(body, out) != nil {
+			return fmt.Errorf("RPC returned status %d: %s", resp.StatusCode, body)
+		}
+		return nil
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode RPC response: %w", err)
+	}
+
+	return nil
+}
+
+func (w *BTCAdapter) nodeTip(ctx context.Context, node btcNode) (uint64, error) {
+	res, err := w.callNode(ctx, node, "getblockcount", nil)
+	if err != nil {
+		return 0, err
+	}
+	var count uint64
+	if err := json.Unmarshal(res, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (w *BTCAdapter) GetTip(ctx context.Context) (uint64, error) {
+	return rpcpool.Call(ctx, w.pool, func(node btcNode) (uint64, error) {
+		return w.nodeTip(ctx, node)
+	})
+}
+
+func (w *BTCAdapter) GetBlockHash(ctx context.Context, height uint64) (string, error) {
+	res, err := w.callRPC(ctx, "getblockhash", []interface{}{height})
+	if err != nil {
+		return "", err
+	}
+	var hash string
+	if err := json.Unmarshal(res, &hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+func (w *BTCAdapter) FetchBlock(ctx context.Context, height uint64) (*model.Block, []*model.Transaction, error) {
+	// 1. Get block hash
+	hash, err := w.GetBlockHash(ctx, height)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 2. Get block details with full tx details
+	rpcBlock, err := w.getBlock(ctx, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 3. Resolve the outputs spent by every input
+	prevouts, err := w.resolvePrevouts(ctx, rpcBlock.Tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve prevouts: %w", err)
+	}
+	// Unresolved inputs are stored without address or value, leaving the fee unknown and the
+	// spend out of the balances. Strict mode retries the block instead.
+	if err := unresolvedInput(rpcBlock.Tx, prevouts); err != nil {
+		if w.strict {
+			return nil, nil, err
+		}
+		if !w.unresolvedLogged.Swap(true) {
+			log.Printf("[%s] %v; storing such inputs without address, value or fee", strings.ToUpper(w.info.Name), err)
+		}
+	}
+
+	block := &model.Block{
+		Chain:     w.info.Type,
+		Height:    rpcBlock.Height,
+		Hash:      rpcBlock.Hash,
+		BlockHash: rpcBlock.PreviousBlockHash,
+		TXCount:   uint64(len(rpcBlock.Tx)),
+		Timestamp: time.Unix(rpcBlock.Time, 0),
+	}
+
+	txs := make([]*model.Transaction, 0, len(rpcBlock.Tx))
+	for _, rt := range rpcBlock.Tx {
+		tx, err := w.toTransaction(rt, prevouts, rpcBlock.Height)
+		if err != nil {
+			return nil, nil, err
+		}
+		tx.BlockHash = rpcBlock.Hash
+		tx.Timestamp = block.Timestamp
+		txs = append(txs, tx)
+	}
+
+	return block, txs, nil
+}
+
+// unresolvedInput reports the first non-coinbase input whose spent output is not in prevouts
+func unresolvedInput(txs []btcTx, prevouts map[string]prevout) error {
+	for _, tx := range txs {
+		if tx.isCoinbase() {
+			continue
+		}
+		for i, vin := range tx.Vin {
+			if _, ok := prevouts[outpointKey(vin.Txid, vin.Vout)]; !ok {
+				return fmt.Errorf("tx %s input %d: prevout %s:%d not found, is txindex=1 set on the node?", tx.Txid, i, vin.Txid, vin.Vout)
+			}
+		}
+	}
+	return nil
+}
+
+// toTransaction converts a decoded RPC transaction, with the outputs its inputs spend, into
+// our model. The caller fills in the block hash and timestamp.
+func (w *BTCAdapter) toTransaction(rt btcTx, prevouts map[string]prevout, height uint64) (*model.Transaction, error) {
+	isCoinbase := rt.isCoinbase()
+
+	// ---------------- INPUTS / FROM ADDRESSES ----------------
+	fromSet := map[string]bool{}
+	if isCoinbase {
+		fromSet["coinbase"] = true
+	}
+
+	var inputValue int64
+	inputsResolved := !isCoinbase
+	inputs := make([]model.TxInput, 0, len(rt.Vin))
+	for i, vin := range rt.Vin {
+		input := model.TxInput{
+			Txid:     rt.Txid,
+			Vin:      uint32(i),
+			Coinbase: isCoinbase,
+			Height:   height,
+		}
+		if !isCoinbase {
+			input.PrevTxid = vin.Txid
+			input.PrevVout = uint32(vin.Vout)
+			// Missing entries usually mean txindex=1 is not set on the node
+			if prev, ok := prevouts[outpointKey(vin.Txid, vin.Vout)]; ok {
+				input.Address = strings.Join(prev.addresses, ",")
+				input.Value = prev.value
+				input.ScriptType = prev.scriptType
+				inputValue += prev.value
+				for _, addr := range prev.addresses {
+					fromSet[addr] = true
+				}
+			} else {
+				inputsResolved = false
+			}
+		}
+		inputs = append(inputs, input)
+	}
+
+	var fromList []string
+	for a := range fromSet {
+		fromList = append(fromList, a)
+	}
+	from := "unknown"
+	if len(fromList) > 0 {
+		// Use the first address as primary and indicate if there are more
+		from = fromList[0]
+		if len(fromList) > 1 {
+			from = fmt.Sprintf("%s,+%d others", fromList[0], len(fromList)-1)
+		}
+	} else if isCoinbase {
+		from = "coinbase"
+	}
+
+	// ---------------- OUTPUTS / TO ADDRESSES ----------------
+	toSet := map[string]bool{}
+	var value int64
+
+	outputs := make([]model.TxOutput, 0, len(rt.Vout))
+	for i, v := range rt.Vout {
+		sats, err := toBaseUnits(v.Value, w.info.Decimals)
+		if err != nil {
+			return nil, fmt.Errorf("tx %s vout %d: %w", rt.Txid, i, err)
+		}
+		value += sats
+
+		addrs := v.ScriptPubKey.addresses(w.addressField)
+		for _, addr := range addrs {
+			toSet[addr] = true
+		}
+		outputs = append(outputs, model.TxOutput{
+			Txid:       rt.Txid,
+			Vout:       uint32(i),
+			Address:    strings.Join(addrs, ","),
+			Value:      sats,
+			ScriptType: v.ScriptPubKey.Type,
+			Height:     height,
+		})
+	}
+
+	var toList []string
+	for a := range toSet {
+		toList = append(toList, a)
+	}
+	to := "unknown"
+	if len(toList) > 0 {
+		to = toList[0]
+		if len(toList) > 1 {
+			to = fmt.Sprintf("%s,+%d others", toList[0], len(toList)-1)
+		}
+	} else if len(rt.Vout) > 0 {
+		to = "non-standard"
+	}
+
+	// The fee is only known when every spent output could be resolved
+	var fee model.Amount
+	if inputsResolved {
+		fee = model.Amount(strconv.FormatInt(inputValue-value, 10))
+	}
+
+	return &model.Transaction{
+		Chain:   w.info.Type,
+		Hash:    rt.Txid,
+		Height:  height,
+		From:    from,
+		To:      to,
+		Value:   model.Amount(strconv.FormatInt(value, 10)),
+		Status:  "success",
+		Fee:     fee,
+		Inputs:  inputs,
+		Outputs: outputs,
+	}, nil
+}
+
+type btcBlock struct {
+	Hash              string  `json:"hash"`
+	Height            uint64  `json:"height"`
+	Time              int64   `json:"time"`
+	PreviousBlockHash string  `json:"previousblockhash"`
+	Tx                []btcTx `json:"tx"`
+}
+
+// getBlock fetches a block with its decoded transactions. Nodes without getblock verbosity 2
+// only return the txids, so the transactions are then fetched with batched getrawtransaction
+// calls, which like prevout resolution needs txindex=1.
+func (w *BTCAdapter) getBlock(ctx context.Context, hash string) (*btcBlock, error) {
+	if !w.noVerboseBlocks.Load() {
+		res, err := w.callRPC(ctx, "getblock", []interface{}{hash, 2})
+		if err == nil {
+			var block btcBlock
+			if err := json.Unmarshal(res, &block); err != nil {
+				return nil, err
+			}
+			return &block, nil
+		}
+		var rpcErr *jsonRPCError
+		if !errors.As(err, &rpcErr) || (rpcErr.Code != rpcTypeError && rpcErr.Code != rpcInvalidParameter) {
+			return nil, err
+		}
+		log.Printf("[%s] getblock verbosity 2 not supported by node, falling back to getrawtransaction", strings.ToUpper(w.info.Name))
+		w.noVerboseBlocks.Store(true)
+	}
+
+	res, err := w.callRPC(ctx, "getblock", []interface{}{hash, true})
+	if err != nil {
+		return nil, err
+	}
+	var header struct {
+		Hash              string   `json:"hash"`
+		Height            uint64   `json:"height"`
+		Time              int64    `json:"time"`
+		PreviousBlockHash string   `json:"previousblockhash"`
+		Tx                []string `json:"tx"`
+	}
+	if err := json.Unmarshal(res, &header); err != nil {
+		return nil, err
+	}
+
+	block := &btcBlock{
+		Hash:              header.Hash,
+		Height:            header.Height,
+		Time:              header.Time,
+		PreviousBlockHash: header.PreviousBlockHash,
+		Tx:                make([]btcTx, 0, len(header.Tx)),
+	}
+	for start := 0; start < len(header.Tx); start += rpcBatchSize {
+		end := start + rpcBatchSize
+		if end > len(header.Tx) {
+			end = len(header.Tx)
+		}
+
+		reqs := make([]jsonRPCRequest, 0, end-start)
+		for i, txid := range header.Tx[start:end] {
+			reqs = append(reqs, jsonRPCRequest{
+				JSONRPC: "1.0",
+				Method:  "getrawtransaction",
+				Params:  []interface{}{txid, true},
+				ID:      i,
+			})
+		}
+
+		resps, err := w.callRPCBatch(ctx, reqs)
+		if err != nil {
+			return nil, err
+		}
+		for i, resp := range resps {
+			if resp.Error != nil {
+				// The genesis coinbase is not in the transaction index
+				if header.Height == 0 {
+					tx, err := w.genesisCoinbase(ctx, hash)
+					if err != nil {
+						return nil, fmt.Errorf("genesis coinbase: %w", err)
+					}
+					block.Tx = append(block.Tx, tx)
+					continue
+				}
+				return nil, fmt.Errorf("tx %s: %w", header.Tx[start+i], resp.Error)
+			}
+			var tx btcTx
+			if err := json.Unmarshal(resp.Result, &tx); err != nil {
+				return nil, fmt.Errorf("tx %s: %w", header.Tx[start+i], err)
+			}
+			block.Tx = append(block.Tx, tx)
+		}
+	}
+
+	return block, nil
+}
+
+// genesisCoinbase decodes the only transaction of the genesis block from the raw block, as
+// getrawtransaction cannot find it
+func (w *BTCAdapter) genesisCoinbase(ctx context.Context, hash string) (btcTx, error) {
+	res, err := w.callRPC(ctx, "getblock", []interface{}{hash, false})
+	if err != nil {
+		return btcTx{}, err
+	}
+	var raw string
+	if err := json.Unmarshal(res, &raw); err != nil {
+		return btcTx{}, err
+	}
+	// An 80-byte header and a one-byte transaction count of 1, in hex
+	if len(raw) <= 162 || raw[160:162] != "01" {
+		return btcTx{}, fmt.Errorf("unexpected genesis block encoding")
+	}
+
+	res, err = w.callRPC(ctx, "decoderawtransaction", []interface{}{raw[162:]})
+	if err != nil {
+		return btcTx{}, err
+	}
+	var tx btcTx
+	if err := json.Unmarshal(res, &tx); err != nil {
+		return btcTx{}, err
+	}
+	return tx, nil
+}
+
+// resolvePrevouts looks up the output spent by every non-coinbase input in txs. Outputs created
+// in this block or in recently indexed ones come from the cache; the rest are fetched with
+// batched getrawtransaction calls. Inputs the node cannot resolve are left out of the map.
+func (w *BTCAdapter) resolvePrevouts(ctx context.Context, txs []btcTx) (map[string]prevout, error) {
+	// Inputs may spend outputs created earlier in the same block
+	resolved := make(map[string]prevout)
+	for _, tx := range txs {
+		for i, v := range tx.Vout {
+			p, err := w.toPrevout(v)
+			if err != nil {
+				return nil, fmt.Errorf("tx %s vout %d: %w", tx.Txid, i, err)
+			}
+			w.prevouts.Add(outpointKey(tx.Txid, i), p)
+			resolved[outpointKey(tx.Txid, i)] = p
+		}
+	}
+
+	missing := make(map[string]bool)
+	var missingTxids []string
+	for _, tx := range txs {
+		if tx.isCoinbase() {
+			continue
+		}
+		for _, vin := range tx.Vin {
+			key := outpointKey(vin.Txid, vin.Vout)
+			if _, ok := resolved[key]; ok {
+				continue
+			}
+			if prev, ok := w.prevouts.Get(key); ok {
+				resolved[key] = prev
+				continue
+			}
+			if !missing[vin.Txid] {
+				missing[vin.Txid] = true
+				missingTxids = append(missingTxids, vin.Txid)
+			}
+		}
+	}
+
+	for start := 0; start < len(missingTxids); start += rpcBatchSize {
+		end := start + rpcBatchSize
+		if end > len(missingTxids) {
+			end = len(missingTxids)
+		}
+
+		reqs := make([]jsonRPCRequest, 0, end-start)
+		for i, txid := range missingTxids[start:end] {
+			reqs = append(reqs, jsonRPCRequest{
+				JSONRPC: "1.0",
+				Method:  "getrawtransaction",
+				Params:  []interface{}{txid, true},
+				ID:      i,
+			})
+		}
+
+		resps, err := w.callRPCBatch(ctx, reqs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resp := range resps {
+			if resp.Error != nil {
+				continue
+			}
+			var prevTx struct {
+				Txid string    `json:"txid"`
+				Vout []btcVout `json:"vout"`
+			}
+			if err := json.Unmarshal(resp.Result, &prevTx); err != nil {
+				continue
+			}
+			for i, v := range prevTx.Vout {
+				p, err := w.toPrevout(v)
+				if err != nil {
+					continue
+				}
+				w.prevouts.Add(outpointKey(prevTx.Txid, i), p)
+				resolved[outpointKey(prevTx.Txid, i)] = p
+			}
+		}
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,260 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"indexer/internal/chains"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestToBaseUnits(t *testing.T) {
+	tests := []struct {
+		in       string
+		decimals int
+		want     int64
+		wantErr  bool
+	}{
+		{in: "1.5", decimals: 8, want: 150000000},
+		{in: "0.00000001", decimals: 8, want: 1},
+		{in: "50", decimals: 8, want: 5000000000},
+		{in: "0", decimals: 8, want: 0},
+		{in: "20999999.97690000", decimals: 8, want: 2099999997690000},
+		// inexact in binary floating point
+		{in: "0.29", decimals: 8, want: 29000000},
+		{in: "123.456", decimals: 3, want: 123456},
+		{in: "7", decimals: 0, want: 7},
+		{in: "0.000000001", decimals: 8, wantErr: true},
+		{in: "1.5", decimals: 0, wantErr: true},
+		{in: "abc", decimals: 8, wantErr: true},
+		{in: "1.2x", decimals: 8, wantErr: true},
+		// "-0" parses to 0, so the sign of a negative fraction would be dropped
+		{in: "-0.5", decimals: 8, wantErr: true},
+		{in: "-0.00000001", decimals: 8, wantErr: true},
+		{in: "-1.5", decimals: 8, wantErr: true},
+		{in: "-7", decimals: 0, wantErr: true},
+		{in: "-0", decimals: 8, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := toBaseUnits(json.Number(tt.in), tt.decimals)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("toBaseUnits(%s, %d) error = %v, wantErr %v", tt.in, tt.decimals, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("toBaseUnits(%s, %d) = %d, want %d", tt.in, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestScriptPubKeyAddresses(t *testing.T) {
+	// Bitcoin Core 22+ reports address; older nodes and Dogecoin only addresses
+	modern := `{"type": "witness_v0_keyhash", "address": "bc1qnew"}`
+	legacy := `{"type": "pubkeyhash", "addresses": ["1Old"]}`
+	both := `{"type": "pubkeyhash", "address": "1New", "addresses": ["1Old"]}`
+	multisig := `{"type": "multisig", "addresses": ["1A", "1B"]}`
+	none := `{"type": "nulldata"}`
+
+	tests := []struct {
+		name   string
+		script string
+		field  string
+		want   []string
+	}{
+		{name: "auto prefers address", script: both, field: addressFieldAuto, want: []string{"1New"}},
+		{name: "auto reads address", script: modern, field: addressFieldAuto, want: []string{"bc1qnew"}},
+		{name: "auto falls back to addresses", script: legacy, field: addressFieldAuto, want: []string{"1Old"}},
+		{name: "auto keeps every multisig address", script: multisig, field: addressFieldAuto, want: []string{"1A", "1B"}},
+		{name: "auto without an address", script: none, field: addressFieldAuto, want: nil},
+		{name: "single reads address", script: both, field: addressFieldSingle, want: []string{"1New"}},
+		{name: "single ignores addresses", script: legacy, field: addressFieldSingle, want: nil},
+		{name: "legacy reads addresses", script: both, field: addressFieldLegacy, want: []string{"1Old"}},
+		{name: "legacy ignores address", script: modern, field: addressFieldLegacy, want: nil},
+		{name: "legacy multisig", script: multisig, field: addressFieldLegacy, want: []string{"1A", "1B"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s btcScriptPubKey
+			if err := json.Unmarshal([]byte(tt.script), &s); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.addresses(tt.field); !slices.Equal(got, tt.want) {
+				t.Errorf("addresses(%q) = %v, want %v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnresolvedInput(t *testing.T) {
+	var txs []btcTx
+	if err := json.Unmarshal([]byte(`[
+		{"txid": "cb", "vin": [{"coinbase": "03a0860100"}], "vout": []},
+		{"txid": "t1", "vin": [{"txid": "p1", "vout": 0}, {"txid": "cb", "vout": 1}], "vout": []}
+	]`), &txs); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		prevouts map[string]prevout
+		wantErr  string
+	}{
+		{
+			name:     "every input resolved",
+			prevouts: map[string]prevout{outpointKey("p1", 0): {}, outpointKey("cb", 1): {}},
+		},
+		{
+			name:     "missing prevout",
+			prevouts: map[string]prevout{outpointKey("p1", 0): {}},
+			wantErr:  "tx t1 input 1: prevout cb:1 not found",
+		},
+		{
+			name:    "coinbase inputs need no prevout",
+			wantErr: "tx t1 input 0: prevout p1:0 not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := unresolvedInput(txs, tt.prevouts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unresolvedInput() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Fatalf("unresolvedInput() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// batchNode answers a JSON-RPC batch with whatever respond makes of the requests. Each
+// result is "r<id>".
+func batchNode(t *testing.T, calls *atomic.Int32, respond func([]jsonRPCResponse) []jsonRPCResponse) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var reqs []jsonRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resps := make([]jsonRPCResponse, len(reqs))
+		for i, req := range reqs {
+			resps[i] = jsonRPCResponse{ID: req.ID, Result: json.RawMessage(fmt.Sprintf(`"r%d"`, req.ID))}
+		}
+		json.NewEncoder(w).Encode(respond(resps))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestCallRPCBatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func([]jsonRPCResponse) []jsonRPCResponse
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "in order",
+			respond: func(r []jsonRPCResponse) []jsonRPCResponse { return r },
+			want:    []string{`"r1"`, `"r2"`, `"r3"`, `"r4"`},
+		},
+		{
+			name: "reversed",
+			respond: func(r []jsonRPCResponse) []jsonRPCResponse {
+				slices.Reverse(r)
+				return r
+			},
+			want: []string{`"r1"`, `"r2"`, `"r3"`, `"r4"`},
+		},
+		{
+			name: "shuffled with a failed call",
+			respond: func(r []jsonRPCResponse) []jsonRPCResponse {
+				r[2] = jsonRPCResponse{ID: 3, Error: &jsonRPCError{Code: -5, Message: "No such mempool or blockchain transaction"}}
+				return []jsonRPCResponse{r[2], r[0], r[3], r[1]}
+			},
+			want: []string{`"r1"`, `"r2"`, "RPC error: -5 No such mempool or blockchain transaction", `"r4"`},
+		},
+		{
+			name: "unknown IDs are ignored",
+			respond: func(r []jsonRPCResponse) []jsonRPCResponse {
+				return append(r, jsonRPCResponse{ID: 99, Result: json.RawMessage(`"stray"`)})
+			},
+			want: []string{`"r1"`, `"r2"`, `"r3"`, `"r4"`},
+		},
+		{
+			name:    "missing ID",
+			respond: func(r []jsonRPCResponse) []jsonRPCResponse { return []jsonRPCResponse{r[3], r[0], r[2]} },
+			wantErr: "RPC batch missing response for id 2 (getrawtransaction)",
+		},
+		{
+			name:    "empty answer",
+			respond: func(r []jsonRPCResponse) []jsonRPCResponse { return nil },
+			wantErr: "RPC batch missing response for id 1 (getrawtransaction)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			w, err := NewBTCAdapter(chains.Bitcoin, BTCOptions{RPCURLs: []string{batchNode(t, &calls, tt.respond)}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var reqs []jsonRPCRequest
+			for id := 1; id <= 4; id++ {
+				reqs = append(reqs, jsonRPCRequest{JSONRPC: "1.0", Method: "getrawtransaction", Params: []interface{}{fmt.Sprintf("tx%d", id), true}, ID: id})
+			}
+
+			resps, err := w.callRPCBatch(context.Background(), reqs)
+			if calls.Load() != 1 {
+				t.Errorf("made %d HTTP calls, want 1", calls.Load())
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("callRPCBatch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("callRPCBatch() error = %v", err)
+			}
+			var got []string
+			for i, resp := range resps {
+				if resp.ID != reqs[i].ID {
+					t.Errorf("response %d has id %d, want %d", i, resp.ID, reqs[i].ID)
+				}
+				if resp.Error != nil {
+					got = append(got, resp.Error.Error())
+				} else {
+					got = append(got, string(resp.Result))
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("callRPCBatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("no requests", func(t *testing.T) {
+		var calls atomic.Int32
+		w, err := NewBTCAdapter(chains.Bitcoin, BTCOptions{RPCURLs: []string{batchNode(t, &calls, nil)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resps, err := w.callRPCBatch(context.Background(), nil); resps != nil || err != nil {
+			t.Errorf("callRPCBatch(nil) = %v, %v", resps, err)
+		}
+		if calls.Load() != 0 {
+			t.Errorf("made %d HTTP calls for no requests", calls.Load())
+		}
+	})
+}
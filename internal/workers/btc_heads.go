@@ -0,0 +1,49 @@
+package workers
+
+import (
+	"context"
+	"indexer/internal/supervisor"
+	"indexer/internal/zmq"
+	"log"
+	"strings"
+)
+
+// SubscribeHeads follows the node's ZMQ hashblock notifications (bitcoind -zmqpubhashblock)
+// when a ZMQ endpoint is configured.
+func (w *BTCAdapter) SubscribeHeads(ctx context.Context) (<-chan struct{}, error) {
+	if w.zmqURL == "" {
+		return nil, nil
+	}
+
+	sub, err := zmq.Dial(ctx, w.zmqURL, "hashblock")
+	if err != nil {
+		return nil, err
+	}
+
+	// Closing the socket is the only way to interrupt a blocked Recv
+	stop := context.AfterFunc(ctx, func() { sub.Close() })
+
+	heads := make(chan struct{}, 1)
+	supervisor.Spawn(ctx, func() {
+		defer close(heads)
+		defer stop()
+		defer sub.Close()
+		for {
+			msg, err := sub.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("[%s] ZMQ hashblock subscription dropped: %v", strings.ToUpper(w.info.Name), err)
+				}
+				return
+			}
+			if len(msg) == 0 || string(msg[0]) != "hashblock" {
+				continue
+			}
+			select {
+			case heads <- struct{}{}:
+			default: // a signal is already pending
+			}
+		}
+	})
+	return heads, nil
+}
@@ -0,0 +1,111 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"indexer/internal/model"
+	"strconv"
+	"time"
+)
+
+// mempoolFetchLimit caps how many new mempool transactions one pass fetches details for,
+// so a node with a large backlog is caught up over several passes.
+const mempoolFetchLimit = 1000
+
+type btcMempoolEntry struct {
+	Time int64 `json:"time"`
+	Fees struct {
+		Base json.Number `json:"base"`
+	} `json:"fees"`
+	Fee json.Number `json:"fee"` // pre-0.19 nodes, Litecoin and Dogecoin
+}
+
+// FetchMempool lists the mempool with getrawmempool and fetches every new transaction with
+// getmempoolentry, for the time the node first saw it and its fee, and getrawtransaction.
+func (w *BTCAdapter) FetchMempool(ctx context.Context, known map[string]bool) ([]string, []model.PendingTransaction, error) {
+	res, err := w.callRPC(ctx, "getrawmempool", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var txids []string
+	if err := json.Unmarshal(res, &txids); err != nil {
+		return nil, nil, err
+	}
+
+	var newTxids []string
+	for _, txid := range txids {
+		if !known[txid] {
+			newTxids = append(newTxids, txid)
+			if len(newTxids) == mempoolFetchLimit {
+				break
+			}
+		}
+	}
+
+	var fresh []model.PendingTransaction
+	for start := 0; start < len(newTxids); start += rpcBatchSize / 2 {
+		end := start + rpcBatchSize/2
+		if end > len(newTxids) {
+			end = len(newTxids)
+		}
+
+		reqs := make([]jsonRPCRequest, 0, 2*(end-start))
+		for i, txid := range newTxids[start:end] {
+			reqs = append(reqs,
+				jsonRPCRequest{JSONRPC: "1.0", Method: "getmempoolentry", Params: []interface{}{txid}, ID: 2 * i},
+				jsonRPCRequest{JSONRPC: "1.0", Method: "getrawtransaction", Params: []interface{}{txid, true}, ID: 2*i + 1},
+			)
+		}
+		resps, err := w.callRPCBatch(ctx, reqs)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Transactions mined or evicted since getrawmempool answer with an error and are skipped
+		entries := map[string]btcMempoolEntry{}
+		var txs []btcTx
+		for i := 0; i < len(resps); i += 2 {
+			if resps[i].Error != nil || resps[i+1].Error != nil {
+				continue
+			}
+			var entry btcMempoolEntry
+			var tx btcTx
+			if json.Unmarshal(resps[i].Result, &entry) != nil || json.Unmarshal(resps[i+1].Result, &tx) != nil {
+				continue
+			}
+			entries[tx.Txid] = entry
+			txs = append(txs, tx)
+		}
+
+		prevouts, err := w.resolvePrevouts(ctx, txs)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, rt := range txs {
+			tx, err := w.toTransaction(rt, prevouts, 0)
+			if err != nil {
+				continue
+			}
+			entry := entries[rt.Txid]
+			fee := tx.Fee
+			entryFee := entry.Fees.Base
+			if entryFee == "" {
+				entryFee = entry.Fee
+			}
+			if v, err := toBaseUnits(entryFee, w.info.Decimals); entryFee != "" && err == nil {
+				fee = model.Amount(strconv.FormatInt(v, 10))
+			}
+			fresh = append(fresh, model.PendingTransaction{
+				Chain:     w.info.Type,
+				Hash:      tx.Hash,
+				From:      tx.From,
+				To:        tx.To,
+				Value:     tx.Value,
+				Fee:       fee,
+				FirstSeen: time.Unix(entry.Time, 0),
+			})
+		}
+	}
+
+	return txids, fresh, nil
+}
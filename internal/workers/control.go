@@ -0,0 +1,157 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	// ErrWorkerUnavailable is returned when the worker did not pick up a command in time,
+	// usually because it is restarting after a failure
+	ErrWorkerUnavailable = errors.New("worker is not running")
+
+	// ErrInvalidHeight is returned for rewind and reindex targets above the indexed range
+	ErrInvalidHeight = errors.New("height is not indexed")
+
+	// ErrHashMismatch is returned when reindexing a block the node has since replaced
+	ErrHashMismatch = errors.New("node has a different block at this height")
+)
+
+// commandTimeout bounds how long a caller waits for the worker to finish its current pass
+const commandTimeout = time.Minute
+
+type commandKind int
+
+const (
+	cmdPause commandKind = iota
+	cmdResume
+	cmdRewind
+	cmdReindex
+)
+
+// command is an operator request run by the worker goroutine between sync passes, so it
+// never races with blocks being written
+type command struct {
+	kind   commandKind
+	height uint64
+	done   chan error
+}
+
+// Pause stops syncing after the current pass. The worker stays paused across restarts
+// until Resume.
+func (w *Worker) Pause(ctx context.Context) error {
+	return w.setPaused(ctx, cmdPause, true)
+}
+
+// Resume lets a paused worker sync again
+func (w *Worker) Resume(ctx context.Context) error {
+	return w.setPaused(ctx, cmdResume, false)
+}
+
+// setPaused goes through the sync loop when it runs, so a pause returns only once no
+// pass is in flight. A worker waiting to be restarted just picks the flag up when it does.
+func (w *Worker) setPaused(ctx context.Context, kind commandKind, paused bool) error {
+	if !w.running.Load() {
+		w.paused.Store(paused)
+		return nil
+	}
+	return w.do(ctx, command{kind: kind})
+}
+
+// Rewind deletes every block above height and resumes syncing from height+1
+func (w *Worker) Rewind(ctx context.Context, height uint64) error {
+	return w.do(ctx, command{kind: cmdRewind, height: height})
+}
+
+// Reindex fetches the block at height again and replaces the stored copy
+func (w *Worker) Reindex(ctx context.Context, height uint64) error {
+	return w.do(ctx, command{kind: cmdReindex, height: height})
+}
+
+// Paused reports whether the worker has been paused
+func (w *Worker) Paused() bool {
+	return w.paused.Load()
+}
+
+// do hands cmd to the worker goroutine and waits for its result
+func (w *Worker) do(ctx context.Context, cmd command) error {
+	cmd.done = make(chan error, 1)
+	timer := time.NewTimer(commandTimeout)
+	defer timer.Stop()
+
+	select {
+	case w.commands <- cmd:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return ErrWorkerUnavailable
+	}
+
+	select {
+	case err := <-cmd.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) handle(ctx context.Context, cmd command) {
+	var err error
+	switch cmd.kind {
+	case cmdPause:
+		w.paused.Store(true)
+		log.Printf("%s Worker paused", w.tag)
+	case cmdResume:
+		w.paused.Store(false)
+		log.Printf("%s Worker resumed", w.tag)
+	case cmdRewind:
+		err = w.rewind(ctx, cmd.height)
+	case cmdReindex:
+		err = w.reindex(ctx, cmd.height)
+	}
+	cmd.done <- err
+}
+
+func (w *Worker) rewind(ctx context.Context, height uint64) error {
+	last, err := w.repo.GetState(ctx, w.info.Type)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	if height > last {
+		return fmt.Errorf("%w: %d is above the last indexed block %d", ErrInvalidHeight, height, last)
+	}
+
+	log.Printf("%s Rewinding from %d to %d on operator request", w.tag, last, height)
+	if err := w.repo.RollbackToHeight(ctx, w.info.Type, height); err != nil {
+		return fmt.Errorf("failed to roll back to %d: %w", height, err)
+	}
+	return nil
+}
+
+func (w *Worker) reindex(ctx context.Context, height uint64) error {
+	stored, err := w.repo.GetBlockHash(ctx, w.info.Type, height)
+	if err != nil {
+		return fmt.Errorf("failed to read stored hash at %d: %w", height, err)
+	}
+	if stored == "" {
+		return fmt.Errorf("%w: no block stored at %d", ErrInvalidHeight, height)
+	}
+
+	block, txs, err := w.adapter.FetchBlock(ctx, height)
+	if err != nil {
+		return fmt.Errorf("failed to fetch block %d: %w", height, err)
+	}
+	// Swapping in another block would break the hash chain; that takes a rewind
+	if block.Hash != stored {
+		return fmt.Errorf("%w: stored %s, node %s; rewind to %d instead", ErrHashMismatch, stored, block.Hash, height-1)
+	}
+
+	log.Printf("%s Reindexing block %d on operator request", w.tag, height)
+	if err := w.repo.ReplaceBlock(ctx, block, txs); err != nil {
+		return fmt.Errorf("failed to replace block %d: %w", height, err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/chains"
+	"indexer/internal/model"
+	"indexer/internal/rpcpool"
+	"indexer/internal/supervisor"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ETHAdapter indexes an Ethereum or other EVM node over JSON-RPC
+type ETHAdapter struct {
+	info      chains.Info
+	pool      *rpcpool.Pool[ethNode]
+	logFilter logFilter
+
+	// set once the node has told us it does not implement eth_getBlockReceipts
+	noBlockReceipts atomic.Bool
+
+	// mempool tracking without txpool_content: transactions announced by the
+	// newPendingTransactions subscription and when we first saw them
+	noTxpool    atomic.Bool
+	pendingMu   sync.Mutex
+	pendingSub  *rpc.ClientSubscription
+	pendingSeen map[string]time.Time
+}
+
+// ethNode is one RPC endpoint of the network
+type ethNode struct {
+	url    string
+	client *ethclient.Client
+}
+
+// canPush reports whether the endpoint is reached over a websocket and can serve subscriptions
+func (n ethNode) canPush() bool {
+	return strings.HasPrefix(n.url, "ws://") || strings.HasPrefix(n.url, "wss://")
+}
+
+// NewETHAdapter connects to the nodes of one EVM network; several URLs are used as a
+// failover pool. info selects the network's tables and state; Ethereum mainnet is
+// chains.Ethereum.
+func NewETHAdapter(info chains.Info, rpcURLs []string, poolOpts rpcpool.Options, logAddresses, logTopics []string) (*ETHAdapter, error) {
+	if len(rpcURLs) == 0 {
+		return nil, fmt.Errorf("no RPC URL configured for %s", info.Name)
+	}
+	nodes := make([]ethNode, len(rpcURLs))
+	for i, url := range rpcURLs {
+		// Dialing HTTP never fails on an unreachable node, and websockets reconnect per call
+		client, err := ethclient.Dial(url)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rpcpool.Redact(url), err)
+		}
+		nodes[i] = ethNode{url: url, client: client}
+	}
+	pool, err := rpcpool.New("["+strings.ToUpper(info.Name)+"]", rpcURLs, nodes, nodeBlockNumber, poolOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &ETHAdapter{
+		info:      info,
+		pool:      pool,
+		logFilter: newLogFilter(logAddresses, logTopics),
+	}, nil
+}
+
+func nodeBlockNumber(ctx context.Context, node ethNode) (uint64, error) {
+	return node.client.BlockNumber(ctx)
+}
+
+func (w *ETHAdapter) Info() chains.Info {
+	return w.info
+}
+
+// Endpoints reports the health of every RPC endpoint
+func (w *ETHAdapter) Endpoints() []rpcpool.EndpointStatus {
+	return w.pool.Status()
+}
+
+// Init makes sure every reachable node serves the network we were configured for, by
+// comparing their chain IDs, and starts health-checking the endpoints for as long as the
+// worker runs
+func (w *ETHAdapter) Init(ctx context.Context) error {
+	var chainID *big.Int
+	var lastErr error
+	for _, node := range w.pool.Clients() {
+		id, err := node.client.ChainID(ctx)
+		if err != nil {
+			log.Printf("[%s] Cannot fetch chain ID from %s: %v", strings.ToUpper(w.info.Name), rpcpool.Redact(node.url), err)
+			lastErr = err
+			continue
+		}
+		if w.info.ChainID != 0 && id.Uint64() != w.info.ChainID {
+			return fmt.Errorf("node %s reports chain ID %s, expected %d", rpcpool.Redact(node.url), id, w.info.ChainID)
+		}
+		if chainID != nil && id.Cmp(chainID) != 0 {
+			return fmt.Errorf("node %s reports chain ID %s, other nodes %s", rpcpool.Redact(node.url), id, chainID)
+		}
+		chainID = id
+	}
+	if chainID == nil {
+		return fmt.Errorf("failed to fetch chain ID: %w", lastErr)
+	}
+	supervisor.Spawn(ctx, func() { w.pool.Monitor(ctx) })
+	return nil
+}
+
+func (w *ETHAdapter) GetTip(ctx context.Context) (uint64, error) {
+	return rpcpool.Call(ctx, w.pool, func(node ethNode) (uint64, error) {
+		return node.client.BlockNumber(ctx)
+	})
+}
+
+func (w *ETHAdapter) FetchBlock(ctx context.Context, height uint64) (*model.Block, []*model.Transaction, error) {
+	block, err := rpcpool.Call(ctx, w.pool, func(node ethNode) (*rpcBlock, error) {
+		return node.getBlock(ctx, height, true)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	modelBlock := &model.Block{
+		Chain:     w.info.Type,
+		Height:    uint64(block.Number),
+		Hash:      block.Hash.Hex(),
+		BlockHash: block.ParentHash.Hex(),
+		TXCount:   uint64(len(block.Transactions)),
+		Timestamp: time.Unix(int64(block.Timestamp), 0),
+	}
+
+	receipts, err := w.fetchReceipts(ctx, block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch receipts: %w", err)
+	}
+
+	var baseFee *big.Int
+	if block.BaseFee != nil {
+		baseFee = block.BaseFee.ToInt()
+	}
+
+	var txs []*model.Transaction
+	for i, tx := range block.Transactions {
+		to := ""
+		if tx.To != nil {
+			to = tx.To.Hex()
+		}
+
+		receipt := receipts[i]
+		gasPrice := effectiveGasPrice(tx, receipt, baseFee)
+		contractAddress := ""
+		if tx.To == nil {
+			contractAddress = receipt.ContractAddress.Hex()
+		}
+
+		txs = append(txs, &model.Transaction{
+			Chain:           w.info.Type,
+			Hash:            tx.Hash.Hex(),
+			BlockHash:       modelBlock.Hash,
+			Height:          modelBlock.Height,
+			From:            tx.From.Hex(),
+			To:              to,
+			Value:           model.Amount(bigOrZero(tx.Value).String()),
+			Status:          receiptStatus(receipt),
+			Timestamp:       modelBlock.Timestamp,
+			Fee:             model.Amount(feePaid(receipt, gasPrice).String()),
+			GasUsed:         receipt.GasUsed,
+			GasPrice:        model.Amount(gasPrice.String()),
+			TxType:          uint8(tx.Type),
+			Nonce:           uint64(tx.Nonce),
+			ContractAddress: contractAddress,
+			TokenTransfers:  decodeTokenTransfers(receipt, modelBlock.Timestamp),
+			Logs:            w.logFilter.decodeLogs(receipt, modelBlock.Timestamp),
+		})
+	}
+
+	return modelBlock, txs, nil
+}
+
+func (w *ETHAdapter) GetBlockHash(ctx context.Context, height uint64) (string, error) {
+	block, err := rpcpool.Call(ctx, w.pool, func(node ethNode) (*rpcBlock, error) {
+		return node.getBlock(ctx, height, false)
+	})
+	if err != nil {
+		return "", err
+	}
+	return block.Hash.Hex(), nil
+}
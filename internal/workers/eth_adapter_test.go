@@ -0,0 +1,275 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"indexer/internal/chains"
+	"indexer/internal/rpcpool"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fakeETHNode answers JSON-RPC calls from a fixture block and its receipts, as a node that
+// has that one block would
+type fakeETHNode struct {
+	block    json.RawMessage
+	receipts []json.RawMessage
+
+	// answer eth_getBlockReceipts with method not found, as older nodes do
+	noBlockReceipts bool
+}
+
+func loadFakeETHNode(t *testing.T, name string) *fakeETHNode {
+	t.Helper()
+	block, err := os.ReadFile(filepath.Join("testdata", name+"_block.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(filepath.Join("testdata", name+"_receipts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &fakeETHNode{block: block}
+	if err := json.Unmarshal(raw, &n.receipts); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+type fakeRPCRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type fakeRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+func (n *fakeETHNode) answer(req fakeRPCRequest) fakeRPCResponse {
+	resp := fakeRPCResponse{JSONRPC: "2.0", ID: req.ID}
+	switch req.Method {
+	case "eth_getBlockByNumber":
+		var head struct {
+			Number string `json:"number"`
+		}
+		json.Unmarshal(n.block, &head)
+		if len(req.Params) > 0 && strings.Trim(string(req.Params[0]), `"`) == head.Number {
+			resp.Result = n.block
+		} else {
+			resp.Result = json.RawMessage("null")
+		}
+	case "eth_getBlockReceipts":
+		if n.noBlockReceipts {
+			resp.Error = &jsonRPCError{Code: rpcMethodNotFound, Message: "the method eth_getBlockReceipts does not exist/is not available"}
+			break
+		}
+		resp.Result = n.receipts
+	case "eth_getTransactionReceipt":
+		resp.Result = json.RawMessage("null")
+		for _, r := range n.receipts {
+			var head struct {
+				TxHash json.RawMessage `json:"transactionHash"`
+			}
+			json.Unmarshal(r, &head)
+			if len(req.Params) > 0 && strings.EqualFold(string(head.TxHash), string(req.Params[0])) {
+				resp.Result = r
+			}
+		}
+	default:
+		resp.Error = &jsonRPCError{Code: rpcMethodNotFound, Message: "method not found"}
+	}
+	return resp
+}
+
+func (n *fakeETHNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		var reqs []fakeRPCRequest
+		json.Unmarshal(body, &reqs)
+		resps := make([]fakeRPCResponse, len(reqs))
+		for i, req := range reqs {
+			resps[i] = n.answer(req)
+		}
+		json.NewEncoder(w).Encode(resps)
+		return
+	}
+	var req fakeRPCRequest
+	json.Unmarshal(body, &req)
+	json.NewEncoder(w).Encode(n.answer(req))
+}
+
+func newTestETHAdapter(t *testing.T, info chains.Info, node http.Handler) *ETHAdapter {
+	t.Helper()
+	srv := httptest.NewServer(node)
+	t.Cleanup(srv.Close)
+	w, err := NewETHAdapter(info, []string{srv.URL}, rpcpool.Options{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// wantETHTx is what FetchBlock should make of one fixture transaction
+type wantETHTx struct {
+	txType   uint8
+	from, to string
+	value    string
+	status   string
+	gasUsed  uint64
+	gasPrice string
+	fee      string
+	nonce    uint64
+	contract string
+}
+
+const (
+	arbOS       = "0x00000000000000000000000000000000000A4B05"
+	arbUser     = "0x1111111111111111111111111111111111111111"
+	arbAliased  = "0x2222222222222222222222222222222222223333"
+	arbToken    = "0x3333333333333333333333333333333333333333"
+	opDepositor = "0xDeaDDEaDDeAdDeAdDEAdDEaddeAddEAdDEAd0001"
+	opL1Block   = "0x4200000000000000000000000000000000000015"
+)
+
+// The fixtures are blocks as Arbitrum Nitro and op-geth nodes return them, with every
+// chain-specific field left in, such as requestId, ticketId, sourceHash, mint or l1Fee
+func TestFetchBlockRollupTypes(t *testing.T) {
+	tests := []struct {
+		fixture string
+		height  uint64
+		want    []wantETHTx
+	}{
+		{
+			fixture: "arbitrum",
+			height:  250000000,
+			want: []wantETHTx{
+				// 0x6a internal: ArbOS start-block bookkeeping
+				{txType: 0x6a, from: arbOS, to: "0x0000000000000000000000000000000000000064", value: "0", status: "success", gasPrice: "10000000", fee: "0"},
+				// 0x64 deposit of ETH from L1
+				{txType: 0x64, from: arbAliased, to: arbUser, value: "50000000000000000", status: "success", gasPrice: "10000000", fee: "0"},
+				// 0x69 retryable ticket submission
+				{txType: 0x69, from: arbAliased, to: "0x000000000000000000000000000000000000006E", value: "3000000000000000", status: "success", gasPrice: "10000000", fee: "0"},
+				// 0x68 retryable redeem
+				{txType: 0x68, from: arbAliased, to: arbToken, value: "0", status: "success", gasUsed: 65000, gasPrice: "10000000", fee: "650000000000", nonce: 7},
+				// 0x65 unsigned L1 contract call
+				{txType: 0x65, from: arbAliased, to: arbUser, value: "1000", status: "success", gasUsed: 21000, gasPrice: "10000000", fee: "210000000000", nonce: 3},
+				// 0x66 L1 contract call with a request ID, reverted
+				{txType: 0x66, from: arbAliased, to: arbUser, value: "2000", status: "failed", gasUsed: 21000, gasPrice: "10000000", fee: "210000000000"},
+				{txType: 0x02, from: arbUser, value: "0", status: "success", gasUsed: 300000, gasPrice: "11000000", fee: "3300000000000", nonce: 42,
+					contract: "0x4444444444444444444444444444444444444444"},
+			},
+		},
+		{
+			fixture: "optimism",
+			height:  124000000,
+			want: []wantETHTx{
+				// 0x7e L1 attributes deposit, which has no signature and pays no gas
+				{txType: 0x7e, from: opDepositor, to: opL1Block, value: "0", status: "success", gasUsed: 46264, gasPrice: "0", fee: "0", nonce: 124000000},
+				// 0x7e user deposit minting ETH
+				{txType: 0x7e, from: arbAliased, to: arbUser, value: "1000000000000000000", status: "success", gasUsed: 21000, gasPrice: "0", fee: "0", nonce: 881},
+				{txType: 0x02, from: arbUser, to: arbToken, value: "0", status: "success", gasUsed: 35000, gasPrice: "1250", fee: "43750000", nonce: 9},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, perTx := range []bool{false, true} {
+			name := tt.fixture
+			if perTx {
+				name += "/per-tx receipts"
+			}
+			t.Run(name, func(t *testing.T) {
+				node := loadFakeETHNode(t, tt.fixture)
+				node.noBlockReceipts = perTx
+				w := newTestETHAdapter(t, chains.EVMNetwork(tt.fixture, "", 0), node)
+
+				block, txs, err := w.FetchBlock(context.Background(), tt.height)
+				if err != nil {
+					t.Fatalf("FetchBlock() error = %v", err)
+				}
+				if block.Height != tt.height || block.TXCount != uint64(len(tt.want)) || len(txs) != len(tt.want) {
+					t.Fatalf("block %d with %d txs (%d decoded), want %d with %d", block.Height, block.TXCount, len(txs), tt.height, len(tt.want))
+				}
+				for i, want := range tt.want {
+					got := txs[i]
+					gotTx := wantETHTx{
+						txType: got.TxType, from: got.From, to: got.To, value: string(got.Value), status: got.Status,
+						gasUsed: got.GasUsed, gasPrice: string(got.GasPrice), fee: string(got.Fee), nonce: got.Nonce, contract: got.ContractAddress,
+					}
+					want.from = common.HexToAddress(want.from).Hex()
+					if want.to != "" {
+						want.to = common.HexToAddress(want.to).Hex()
+					}
+					if gotTx != want {
+						t.Errorf("tx %d = %+v, want %+v", i, gotTx, want)
+					}
+					if got.BlockHash != block.Hash || got.Height != tt.height || !got.Timestamp.Equal(block.Timestamp) {
+						t.Errorf("tx %d not tied to its block: %+v", i, got)
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("retryable redeem token transfer", func(t *testing.T) {
+		w := newTestETHAdapter(t, chains.EVMNetwork("arbitrum", "", 0), loadFakeETHNode(t, "arbitrum"))
+		_, txs, err := w.FetchBlock(context.Background(), 250000000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		transfers := txs[3].TokenTransfers
+		if len(transfers) != 1 || transfers[0].Amount != "5000000" || transfers[0].Contract != common.HexToAddress(arbToken).Hex() ||
+			transfers[0].From != common.HexToAddress(arbAliased).Hex() || transfers[0].To != common.HexToAddress(arbUser).Hex() {
+			t.Errorf("token transfers = %+v", transfers)
+		}
+		if len(txs[3].Logs) != 1 {
+			t.Errorf("got %d logs, want 1", len(txs[3].Logs))
+		}
+	})
+}
+
+func TestGetBlockHashRollup(t *testing.T) {
+	w := newTestETHAdapter(t, chains.EVMNetwork("optimism", "", 0), loadFakeETHNode(t, "optimism"))
+	hash, err := w.GetBlockHash(context.Background(), 124000000)
+	if err != nil {
+		t.Fatalf("GetBlockHash() error = %v", err)
+	}
+	if hash != "0xe2a898f9b8b73d254921cbe8b60f6de76122a1bd4f251e2cf9ea251d150ddb9b" {
+		t.Errorf("GetBlockHash() = %s", hash)
+	}
+	if _, err := w.GetBlockHash(context.Background(), 124000001); err == nil {
+		t.Error("GetBlockHash() of a block the node does not have succeeded")
+	}
+}
+
+// Two EVM networks served by identical nodes must still write their own chain's rows
+func TestFetchBlockNetworkIsolation(t *testing.T) {
+	ctx := context.Background()
+	for _, info := range []chains.Info{chains.Ethereum, chains.EVMNetwork("polygon", "POL", 137)} {
+		w := newTestETHAdapter(t, info, loadFakeETHNode(t, "optimism"))
+		block, txs, err := w.FetchBlock(ctx, 124000000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if block.Chain != info.Type {
+			t.Errorf("%s block tagged %s", info.Name, block.Chain)
+		}
+		for _, tx := range txs {
+			if tx.Chain != info.Type {
+				t.Errorf("%s transaction %s tagged %s", info.Name, tx.Hash, tx.Chain)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package workers
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// rpcBlock is the part of an eth_getBlockByNumber result we index. It is decoded by hand
+// rather than into go-ethereum's types.Block, which rejects transaction types it does not
+// know, such as the Arbitrum system transactions (0x64-0x6a) and OP-stack deposits (0x7e).
+// Hashes are taken as the node reports them instead of being recomputed.
+type rpcBlock struct {
+	Number       hexutil.Uint64 `json:"number"`
+	Hash         common.Hash    `json:"hash"`
+	ParentHash   common.Hash    `json:"parentHash"`
+	Timestamp    hexutil.Uint64 `json:"timestamp"`
+	BaseFee      *hexutil.Big   `json:"baseFeePerGas"`
+	Transactions []rpcTx        `json:"transactions"`
+}
+
+// rpcTx is the part of an RPC transaction object we index. The sender comes from the node,
+// so transactions without a signature, like deposits, need no special casing.
+type rpcTx struct {
+	Hash                 common.Hash     `json:"hash"`
+	Type                 hexutil.Uint64  `json:"type"`
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to"`
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+}
+
+// getBlock fetches the block at height from one node, with full transaction objects when
+// fullTxs is set. A node that does not have the block yet returns ethereum.NotFound.
+func (n ethNode) getBlock(ctx context.Context, height uint64, fullTxs bool) (*rpcBlock, error) {
+	var block *rpcBlock
+	if err := n.client.Client().CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.EncodeUint64(height), fullTxs); err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, ethereum.NotFound
+	}
+	return block, nil
+}
+
+// bigOrZero returns the value of an optional RPC quantity, treating a missing one as zero
+func bigOrZero(v *hexutil.Big) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return v.ToInt()
+}
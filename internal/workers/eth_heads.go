@@ -0,0 +1,48 @@
+package workers
+
+import (
+	"context"
+	"indexer/internal/supervisor"
+	"log"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SubscribeHeads follows newHeads over the best websocket endpoint. Nodes reached over HTTP
+// cannot push, so without a websocket endpoint the worker keeps polling.
+func (w *ETHAdapter) SubscribeHeads(ctx context.Context) (<-chan struct{}, error) {
+	node, ok := w.pool.BestMatching(ethNode.canPush)
+	if !ok {
+		return nil, nil
+	}
+
+	headers := make(chan *types.Header, 16)
+	sub, err := node.client.SubscribeNewHead(ctx, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	heads := make(chan struct{}, 1)
+	supervisor.Spawn(ctx, func() {
+		defer close(heads)
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case <-headers:
+				select {
+				case heads <- struct{}{}:
+				default: // a signal is already pending
+				}
+			case err := <-sub.Err():
+				if err != nil {
+					log.Printf("[%s] newHeads subscription dropped: %v", strings.ToUpper(w.info.Name), err)
+				}
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return heads, nil
+}
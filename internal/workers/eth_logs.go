@@ -0,0 +1,74 @@
+package workers
+
+import (
+	"indexer/internal/model"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// logFilter restricts which logs are persisted. An empty address or topic set matches
+// everything; when both are set a log has to match both, like eth_getLogs.
+type logFilter struct {
+	addresses map[common.Address]bool
+	topics    map[common.Hash]bool
+}
+
+func newLogFilter(addresses, topics []string) logFilter {
+	f := logFilter{}
+	if len(addresses) > 0 {
+		f.addresses = make(map[common.Address]bool, len(addresses))
+		for _, a := range addresses {
+			f.addresses[common.HexToAddress(a)] = true
+		}
+	}
+	if len(topics) > 0 {
+		f.topics = make(map[common.Hash]bool, len(topics))
+		for _, t := range topics {
+			f.topics[common.HexToHash(t)] = true
+		}
+	}
+	return f
+}
+
+func (f logFilter) match(l *types.Log) bool {
+	if f.addresses != nil && !f.addresses[l.Address] {
+		return false
+	}
+	if f.topics != nil && (len(l.Topics) == 0 || !f.topics[l.Topics[0]]) {
+		return false
+	}
+	return true
+}
+
+// decodeLogs converts the receipt logs that pass the filter into rows for eth_logs.
+func (f logFilter) decodeLogs(receipt *types.Receipt, timestamp time.Time) []model.Log {
+	var logs []model.Log
+	for _, l := range receipt.Logs {
+		if !f.match(l) {
+			continue
+		}
+
+		row := model.Log{
+			TxHash:    l.TxHash.Hex(),
+			LogIndex:  l.Index,
+			Address:   l.Address.Hex(),
+			Data:      hexutil.Encode(l.Data),
+			Removed:   l.Removed,
+			BlockHash: l.BlockHash.Hex(),
+			Height:    l.BlockNumber,
+			Timestamp: timestamp,
+		}
+		topics := []*string{&row.Topic0, &row.Topic1, &row.Topic2, &row.Topic3}
+		for i, t := range l.Topics {
+			if i >= len(topics) {
+				break
+			}
+			*topics[i] = t.Hex()
+		}
+		logs = append(logs, row)
+	}
+	return logs
+}
@@ -0,0 +1,87 @@
+package workers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	transferSig = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	approvalSig = "0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"
+	usdc        = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	weth        = "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+)
+
+func TestLogFilter(t *testing.T) {
+	logAt := func(address string, topics ...string) *types.Log {
+		l := &types.Log{Address: common.HexToAddress(address)}
+		for _, topic := range topics {
+			l.Topics = append(l.Topics, common.HexToHash(topic))
+		}
+		return l
+	}
+	tests := []struct {
+		name      string
+		addresses []string
+		topics    []string
+		log       *types.Log
+		want      bool
+	}{
+		{name: "no filter matches everything", log: logAt(weth, approvalSig), want: true},
+		{name: "no filter matches anonymous logs", log: logAt(weth), want: true},
+		{name: "address in any case", addresses: []string{"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"}, log: logAt(usdc, transferSig), want: true},
+		{name: "address without 0x", addresses: []string{"A0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"}, log: logAt(usdc, transferSig), want: true},
+		{name: "other address", addresses: []string{usdc}, log: logAt(weth, transferSig), want: false},
+		{name: "any listed address", addresses: []string{weth, usdc}, log: logAt(usdc), want: true},
+		{name: "topic0", topics: []string{transferSig}, log: logAt(weth, transferSig), want: true},
+		{name: "topic in upper case", topics: []string{"0xDDF252AD1BE2C89B69C2B068FC378DAA952BA7F163C4A11628F55A4DF523B3EF"}, log: logAt(weth, transferSig), want: true},
+		{name: "other topic0", topics: []string{transferSig}, log: logAt(weth, approvalSig), want: false},
+		{name: "only topic0 is matched", topics: []string{transferSig}, log: logAt(weth, approvalSig, transferSig), want: false},
+		{name: "anonymous log with a topic filter", topics: []string{transferSig}, log: logAt(weth), want: false},
+		{name: "address and topic both match", addresses: []string{usdc}, topics: []string{transferSig}, log: logAt(usdc, transferSig), want: true},
+		{name: "address matches, topic does not", addresses: []string{usdc}, topics: []string{transferSig}, log: logAt(usdc, approvalSig), want: false},
+		{name: "topic matches, address does not", addresses: []string{usdc}, topics: []string{transferSig}, log: logAt(weth, transferSig), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newLogFilter(tt.addresses, tt.topics).match(tt.log); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeLogs(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	blockHash := common.HexToHash("0xb1")
+	receipt := &types.Receipt{Logs: []*types.Log{
+		{
+			Address: common.HexToAddress(usdc), Index: 4, TxHash: common.HexToHash("0x11"), BlockHash: blockHash, BlockNumber: 19000000,
+			Topics: []common.Hash{common.HexToHash(transferSig), common.HexToHash("0x01"), common.HexToHash("0x02")},
+			Data:   []byte{0x00, 0x2a},
+		},
+		{Address: common.HexToAddress(weth), Index: 5, Topics: []common.Hash{common.HexToHash(approvalSig)}},
+		{Address: common.HexToAddress(usdc), Index: 6, Removed: true},
+	}}
+
+	logs := newLogFilter([]string{usdc}, nil).decodeLogs(receipt, ts)
+	if len(logs) != 2 {
+		t.Fatalf("got %d logs, want the 2 of %s", len(logs), usdc)
+	}
+	got := logs[0]
+	if got.Address != usdc || got.LogIndex != 4 || got.Height != 19000000 || got.BlockHash != blockHash.Hex() || !got.Timestamp.Equal(ts) {
+		t.Errorf("log = %+v", got)
+	}
+	if got.Topic0 != transferSig || got.Topic1 != common.HexToHash("0x01").Hex() || got.Topic2 != common.HexToHash("0x02").Hex() || got.Topic3 != "" {
+		t.Errorf("topics = %s %s %s %q", got.Topic0, got.Topic1, got.Topic2, got.Topic3)
+	}
+	if got.Data != "0x002a" {
+		t.Errorf("data = %s, want 0x002a", got.Data)
+	}
+	if anon := logs[1]; anon.Topic0 != "" || anon.Data != "0x" || !anon.Removed {
+		t.Errorf("anonymous removed log = %+v", anon)
+	}
+}
@@ -0,0 +1,244 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/model"
+	"indexer/internal/rpcpool"
+	"indexer/internal/supervisor"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// pendingSeenTTL is how long a hash announced by the newPendingTransactions subscription is
+// remembered without being fetched. Announcements outpace what one pass checks on busy
+// networks, and the node has usually dropped or mined the rest by then.
+const pendingSeenTTL = 10 * time.Minute
+
+// rpcPendingTx is the part of an RPC transaction object kept for pending transactions
+type rpcPendingTx struct {
+	Hash         common.Hash     `json:"hash"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to"`
+	Value        *hexutil.Big    `json:"value"`
+	GasPrice     *hexutil.Big    `json:"gasPrice"`
+	MaxFeePerGas *hexutil.Big    `json:"maxFeePerGas"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	BlockNumber  *hexutil.Big    `json:"blockNumber"`
+}
+
+func (t *rpcPendingTx) toPending(chain model.ChainType, firstSeen time.Time) model.PendingTransaction {
+	p := model.PendingTransaction{
+		Chain:     chain,
+		Hash:      t.Hash.Hex(),
+		From:      t.From.Hex(),
+		Nonce:     uint64(t.Nonce),
+		FirstSeen: firstSeen,
+	}
+	if t.To != nil {
+		p.To = t.To.Hex()
+	}
+	if t.Value != nil {
+		p.Value = model.Amount(t.Value.ToInt().String())
+	}
+	if t.MaxFeePerGas != nil {
+		p.GasPrice = model.Amount(t.MaxFeePerGas.ToInt().String())
+	} else if t.GasPrice != nil {
+		p.GasPrice = model.Amount(t.GasPrice.ToInt().String())
+	}
+	return p
+}
+
+// FetchMempool reads the node's executable transactions from txpool_content. Nodes that do
+// not expose the txpool namespace are followed through a newPendingTransactions
+// subscription instead, which needs a websocket RPC URL.
+func (w *ETHAdapter) FetchMempool(ctx context.Context, known map[string]bool) ([]string, []model.PendingTransaction, error) {
+	if !w.noTxpool.Load() {
+		var content struct {
+			Pending map[string]map[string]*rpcPendingTx `json:"pending"`
+		}
+		err := w.pool.Do(ctx, func(node ethNode) error {
+			err := node.client.Client().CallContext(ctx, &content, "txpool_content")
+			if isMethodNotFound(err) {
+				return rpcpool.Permanent(err)
+			}
+			return err
+		})
+		if err == nil {
+			now := time.Now()
+			var pending []string
+			var fresh []model.PendingTransaction
+			for _, byNonce := range content.Pending {
+				for _, tx := range byNonce {
+					hash := tx.Hash.Hex()
+					pending = append(pending, hash)
+					if !known[hash] {
+						fresh = append(fresh, tx.toPending(w.info.Type, now))
+					}
+				}
+			}
+			return pending, fresh, nil
+		}
+		if !isMethodNotFound(err) {
+			return nil, nil, err
+		}
+		log.Printf("[%s] txpool_content not supported by node, subscribing to newPendingTransactions", strings.ToUpper(w.info.Name))
+		w.noTxpool.Store(true)
+	}
+
+	return w.fetchSubscribedMempool(ctx, known)
+}
+
+// fetchSubscribedMempool checks transactions announced by the subscription, and those
+// already stored, with eth_getTransactionByHash: unknown ones were evicted and those with a
+// block number were mined. One pass checks at most mempoolFetchLimit of them, picked in map
+// order, which Go randomizes; stored ones left unchecked are reported as still pending.
+func (w *ETHAdapter) fetchSubscribedMempool(ctx context.Context, known map[string]bool) ([]string, []model.PendingTransaction, error) {
+	if err := w.subscribePending(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	w.pendingMu.Lock()
+	expired := time.Now().Add(-pendingSeenTTL)
+	firstSeen := make(map[string]time.Time, len(w.pendingSeen))
+	for hash, seen := range w.pendingSeen {
+		if seen.Before(expired) {
+			delete(w.pendingSeen, hash)
+			continue
+		}
+		firstSeen[hash] = seen
+	}
+	w.pendingMu.Unlock()
+
+	var pending, gone []string
+	candidates := make([]string, 0, min(len(firstSeen)+len(known), mempoolFetchLimit))
+	picked := make(map[string]bool, cap(candidates))
+	for hash := range firstSeen {
+		if len(candidates) == mempoolFetchLimit {
+			break
+		}
+		candidates = append(candidates, hash)
+		picked[hash] = true
+	}
+	for hash := range known {
+		switch {
+		case picked[hash]:
+		case len(candidates) < mempoolFetchLimit:
+			candidates = append(candidates, hash)
+		default:
+			pending = append(pending, hash)
+		}
+	}
+
+	var fresh []model.PendingTransaction
+	for start := 0; start < len(candidates); start += rpcBatchSize {
+		end := start + rpcBatchSize
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+
+		txs := make([]*rpcPendingTx, end-start)
+		batch := make([]rpc.BatchElem, 0, end-start)
+		for i, hash := range candidates[start:end] {
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getTransactionByHash",
+				Args:   []interface{}{hash},
+				Result: &txs[i],
+			})
+		}
+		err := w.pool.Do(ctx, func(node ethNode) error {
+			return node.client.Client().BatchCallContext(ctx, batch)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i, elem := range batch {
+			hash := candidates[start+i]
+			tx := txs[i]
+			if elem.Error != nil {
+				// Keep what we have and ask again next time
+				if known[hash] {
+					pending = append(pending, hash)
+				}
+				continue
+			}
+			if tx == nil || tx.BlockNumber != nil {
+				gone = append(gone, hash)
+				continue
+			}
+			pending = append(pending, hash)
+			if !known[hash] {
+				seen, ok := firstSeen[hash]
+				if !ok {
+					seen = time.Now()
+				}
+				fresh = append(fresh, tx.toPending(w.info.Type, seen))
+			}
+		}
+	}
+
+	w.pendingMu.Lock()
+	for _, hash := range gone {
+		delete(w.pendingSeen, hash)
+	}
+	w.pendingMu.Unlock()
+
+	return pending, fresh, nil
+}
+
+// subscribePending starts the newPendingTransactions subscription unless it is running. It
+// ends with ctx, and is restarted by the next call if the connection drops.
+func (w *ETHAdapter) subscribePending(ctx context.Context) error {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+	if w.pendingSub != nil {
+		return nil
+	}
+
+	node, ok := w.pool.BestMatching(ethNode.canPush)
+	if !ok {
+		return fmt.Errorf("txpool_content unavailable and no websocket RPC URL to subscribe to pending transactions")
+	}
+	hashes := make(chan common.Hash, 1024)
+	sub, err := node.client.Client().EthSubscribe(ctx, hashes, "newPendingTransactions")
+	if err != nil {
+		return fmt.Errorf("txpool_content unavailable and cannot subscribe to pending transactions: %w", err)
+	}
+	w.pendingSub = sub
+	if w.pendingSeen == nil {
+		w.pendingSeen = make(map[string]time.Time)
+	}
+
+	supervisor.Spawn(ctx, func() {
+		defer func() {
+			w.pendingMu.Lock()
+			w.pendingSub = nil
+			w.pendingMu.Unlock()
+		}()
+		for {
+			select {
+			case hash := <-hashes:
+				w.pendingMu.Lock()
+				if _, ok := w.pendingSeen[hash.Hex()]; !ok {
+					w.pendingSeen[hash.Hex()] = time.Now()
+				}
+				w.pendingMu.Unlock()
+			case err := <-sub.Err():
+				if err != nil {
+					log.Printf("[%s] Pending transaction subscription dropped: %v", strings.ToUpper(w.info.Name), err)
+				}
+				return
+			case <-ctx.Done():
+				sub.Unsubscribe()
+				return
+			}
+		}
+	})
+	return nil
+}
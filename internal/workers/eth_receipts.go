@@ -0,0 +1,123 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/rpcpool"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fetchReceipts returns the receipts of every transaction in block, in transaction order.
+// It uses eth_getBlockReceipts when the node supports it and otherwise falls back to a
+// batch of eth_getTransactionReceipt calls.
+func (w *ETHAdapter) fetchReceipts(ctx context.Context, block *rpcBlock) ([]*types.Receipt, error) {
+	txs := block.Transactions
+	if len(txs) == 0 {
+		return nil, nil
+	}
+
+	if !w.noBlockReceipts.Load() {
+		receipts, err := rpcpool.Call(ctx, w.pool, func(node ethNode) ([]*types.Receipt, error) {
+			var receipts []*types.Receipt
+			err := node.client.Client().CallContext(ctx, &receipts, "eth_getBlockReceipts",
+				rpc.BlockNumberOrHashWithHash(block.Hash, false))
+			if isMethodNotFound(err) {
+				return nil, rpcpool.Permanent(err)
+			}
+			return receipts, err
+		})
+		if err == nil && len(receipts) == len(txs) {
+			return receipts, nil
+		}
+		if err != nil && isMethodNotFound(err) {
+			log.Printf("[%s] eth_getBlockReceipts not supported by node, falling back to per-tx receipts", strings.ToUpper(w.info.Name))
+			w.noBlockReceipts.Store(true)
+		}
+	}
+
+	receipts := make([]*types.Receipt, len(txs))
+	for start := 0; start < len(txs); start += rpcBatchSize {
+		end := start + rpcBatchSize
+		if end > len(txs) {
+			end = len(txs)
+		}
+
+		batch := make([]rpc.BatchElem, 0, end-start)
+		for i := start; i < end; i++ {
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{txs[i].Hash},
+				Result: &receipts[i],
+			})
+		}
+		// A node that has not seen the block yet answers null, so try the others
+		err := w.pool.Do(ctx, func(node ethNode) error {
+			if err := node.client.Client().BatchCallContext(ctx, batch); err != nil {
+				return err
+			}
+			for i, elem := range batch {
+				if elem.Error != nil {
+					return fmt.Errorf("receipt for %s: %w", txs[start+i].Hash.Hex(), elem.Error)
+				}
+				if receipts[start+i] == nil {
+					return fmt.Errorf("receipt for %s not found", txs[start+i].Hash.Hex())
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return receipts, nil
+}
+
+// isMethodNotFound reports whether the node rejected the call as an unknown method.
+func isMethodNotFound(err error) bool {
+	if rpcErr, ok := err.(rpc.Error); ok {
+		return rpcErr.ErrorCode() == -32601
+	}
+	return false
+}
+
+// receiptStatus maps the receipt status to our status strings. Pre-Byzantium receipts carry a
+// state root instead of a status, and a mined transaction then counts as successful.
+func receiptStatus(r *types.Receipt) string {
+	if len(r.PostState) > 0 || r.Status == types.ReceiptStatusSuccessful {
+		return "success"
+	}
+	return "failed"
+}
+
+// effectiveGasPrice returns the price per gas the sender actually paid. Older nodes omit it
+// from receipts, so derive it from the transaction and the block base fee. Transactions
+// without fee caps pay their gas price, or nothing when they carry none at all.
+func effectiveGasPrice(tx rpcTx, r *types.Receipt, baseFee *big.Int) *big.Int {
+	if r.EffectiveGasPrice != nil {
+		return r.EffectiveGasPrice
+	}
+	if baseFee == nil || tx.MaxFeePerGas == nil {
+		return bigOrZero(tx.GasPrice)
+	}
+	feeCap := tx.MaxFeePerGas.ToInt()
+	price := new(big.Int).Add(bigOrZero(tx.MaxPriorityFeePerGas), baseFee)
+	if price.Cmp(feeCap) > 0 {
+		price = feeCap
+	}
+	return price
+}
+
+// feePaid is gas used times the effective price, plus the blob fee for blob transactions.
+func feePaid(r *types.Receipt, gasPrice *big.Int) *big.Int {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(r.GasUsed), gasPrice)
+	if r.BlobGasPrice != nil && r.BlobGasUsed > 0 {
+		fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(r.BlobGasUsed), r.BlobGasPrice))
+	}
+	return fee
+}
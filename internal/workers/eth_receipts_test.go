@@ -0,0 +1,133 @@
+package workers
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestReceiptStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		receipt *types.Receipt
+		want    string
+	}{
+		{name: "successful", receipt: &types.Receipt{Status: types.ReceiptStatusSuccessful}, want: "success"},
+		{name: "reverted", receipt: &types.Receipt{Status: types.ReceiptStatusFailed}, want: "failed"},
+		{name: "pre-Byzantium state root", receipt: &types.Receipt{PostState: []byte{0xab, 0xcd}}, want: "success"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := receiptStatus(tt.receipt); got != tt.want {
+				t.Errorf("receiptStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEffectiveGasPrice(t *testing.T) {
+	quantity := func(v int64) *hexutil.Big { return (*hexutil.Big)(new(big.Int).SetInt64(v)) }
+	tests := []struct {
+		name    string
+		tx      rpcTx
+		receipt *types.Receipt
+		baseFee *big.Int
+		want    int64
+	}{
+		{
+			name:    "taken from the receipt",
+			tx:      rpcTx{GasPrice: quantity(50), MaxFeePerGas: quantity(100)},
+			receipt: &types.Receipt{EffectiveGasPrice: new(big.Int).SetInt64(42)},
+			baseFee: new(big.Int).SetInt64(30),
+			want:    42,
+		},
+		{
+			name:    "legacy transaction pays its gas price",
+			tx:      rpcTx{GasPrice: quantity(50)},
+			receipt: &types.Receipt{},
+			baseFee: new(big.Int).SetInt64(30),
+			want:    50,
+		},
+		{
+			name:    "pre-London block",
+			tx:      rpcTx{GasPrice: quantity(50), MaxFeePerGas: quantity(100)},
+			receipt: &types.Receipt{},
+			want:    50,
+		},
+		{
+			name:    "base fee plus tip below the cap",
+			tx:      rpcTx{MaxFeePerGas: quantity(100), MaxPriorityFeePerGas: quantity(2)},
+			receipt: &types.Receipt{},
+			baseFee: new(big.Int).SetInt64(30),
+			want:    32,
+		},
+		{
+			name:    "capped at the fee cap",
+			tx:      rpcTx{MaxFeePerGas: quantity(31), MaxPriorityFeePerGas: quantity(5)},
+			receipt: &types.Receipt{},
+			baseFee: new(big.Int).SetInt64(30),
+			want:    31,
+		},
+		{
+			name:    "no tip",
+			tx:      rpcTx{MaxFeePerGas: quantity(100)},
+			receipt: &types.Receipt{},
+			baseFee: new(big.Int).SetInt64(30),
+			want:    30,
+		},
+		{
+			name:    "no price at all",
+			tx:      rpcTx{},
+			receipt: &types.Receipt{},
+			baseFee: new(big.Int).SetInt64(30),
+			want:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := effectiveGasPrice(tt.tx, tt.receipt, tt.baseFee); got.Int64() != tt.want {
+				t.Errorf("effectiveGasPrice() = %s, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeePaid(t *testing.T) {
+	tests := []struct {
+		name     string
+		receipt  *types.Receipt
+		gasPrice int64
+		want     string
+	}{
+		{name: "gas used times price", receipt: &types.Receipt{GasUsed: 21000}, gasPrice: 30000000000, want: "630000000000000"},
+		{name: "no gas used", receipt: &types.Receipt{}, gasPrice: 30000000000, want: "0"},
+		{name: "free transaction", receipt: &types.Receipt{GasUsed: 46264}, gasPrice: 0, want: "0"},
+		{
+			name:     "blob fee added",
+			receipt:  &types.Receipt{GasUsed: 21000, BlobGasUsed: 131072, BlobGasPrice: new(big.Int).SetInt64(3)},
+			gasPrice: 10,
+			want:     "603216",
+		},
+		{
+			name:     "blob price without blob gas",
+			receipt:  &types.Receipt{GasUsed: 21000, BlobGasPrice: new(big.Int).SetInt64(3)},
+			gasPrice: 10,
+			want:     "210000",
+		},
+		{
+			name:     "beyond 64 bits",
+			receipt:  &types.Receipt{GasUsed: 30000000},
+			gasPrice: 1 << 62,
+			want:     "138350580552821637120000000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := feePaid(tt.receipt, new(big.Int).SetInt64(tt.gasPrice)); got.String() != tt.want {
+				t.Errorf("feePaid() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package workers
+
+import (
+	"indexer/internal/model"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	standardERC20   = "erc20"
+	standardERC721  = "erc721"
+	standardERC1155 = "erc1155"
+)
+
+var (
+	// ERC-20 and ERC-721 share the Transfer signature; ERC-721 indexes the token ID as a third topic
+	transferTopic       = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	transferBatchTopic  = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+// decodeTokenTransfers extracts every token transfer emitted in a receipt. Logs that use a
+// transfer signature but do not follow the standard layout are skipped.
+func decodeTokenTransfers(receipt *types.Receipt, timestamp time.Time) []model.TokenTransfer {
+	var transfers []model.TokenTransfer
+	for _, l := range receipt.Logs {
+		if l.Removed || len(l.Topics) == 0 {
+			continue
+		}
+
+		base := model.TokenTransfer{
+			TxHash:    l.TxHash.Hex(),
+			LogIndex:  l.Index,
+			Contract:  l.Address.Hex(),
+			Height:    l.BlockNumber,
+			Timestamp: timestamp,
+		}
+
+		switch l.Topics[0] {
+		case transferTopic:
+			if len(l.Topics) == 3 && len(l.Data) == 32 {
+				t := base
+				t.Standard = standardERC20
+				t.From = topicAddress(l.Topics[1])
+				t.To = topicAddress(l.Topics[2])
+				t.Amount = model.Amount(new(big.Int).SetBytes(l.Data).String())
+				transfers = append(transfers, t)
+			} else if len(l.Topics) == 4 && len(l.Data) == 0 {
+				t := base
+				t.Standard = standardERC721
+				t.From = topicAddress(l.Topics[1])
+				t.To = topicAddress(l.Topics[2])
+				t.TokenID = l.Topics[3].Big().String()
+				t.Amount = "1"
+				transfers = append(transfers, t)
+			}
+
+		case transferSingleTopic:
+			if len(l.Topics) != 4 || len(l.Data) != 64 {
+				continue
+			}
+			t := base
+			t.Standard = standardERC1155
+			t.From = topicAddress(l.Topics[2])
+			t.To = topicAddress(l.Topics[3])
+			t.TokenID = new(big.Int).SetBytes(l.Data[:32]).String()
+			t.Amount = model.Amount(new(big.Int).SetBytes(l.Data[32:64]).String())
+			transfers = append(transfers, t)
+
+		case transferBatchTopic:
+			if len(l.Topics) != 4 {
+				continue
+			}
+			ids, ok := decodeUintArray(l.Data, 0)
+			if !ok {
+				continue
+			}
+			values, ok := decodeUintArray(l.Data, 1)
+			if !ok || len(values) != len(ids) {
+				continue
+			}
+			for i := range ids {
+				t := base
+				t.Standard = standardERC1155
+				t.BatchIndex = uint(i)
+				t.From = topicAddress(l.Topics[2])
+				t.To = topicAddress(l.Topics[3])
+				t.TokenID = ids[i].String()
+				t.Amount = model.Amount(values[i].String())
+				transfers = append(transfers, t)
+			}
+		}
+	}
+	return transfers
+}
+
+func topicAddress(topic common.Hash) string {
+	return common.BytesToAddress(topic.Bytes()).Hex()
+}
+
+// decodeUintArray decodes the ABI-encoded uint256[] whose offset is stored in head slot `slot`.
+func decodeUintArray(data []byte, slot int) ([]*big.Int, bool) {
+	word := func(pos uint64) (*big.Int, bool) {
+		if pos+32 > uint64(len(data)) {
+			return nil, false
+		}
+		return new(big.Int).SetBytes(data[pos : pos+32]), true
+	}
+
+	offset, ok := word(uint64(slot) * 32)
+	if !ok || !offset.IsUint64() || offset.Uint64() > uint64(len(data)) {
+		return nil, false
+	}
+	length, ok := word(offset.Uint64())
+	if !ok || !length.IsUint64() || length.Uint64() > uint64(len(data))/32 {
+		return nil, false
+	}
+
+	out := make([]*big.Int, 0, length.Uint64())
+	for i := uint64(0); i < length.Uint64(); i++ {
+		v, ok := word(offset.Uint64() + 32 + i*32)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, v)
+	}
+	return out, true
+}
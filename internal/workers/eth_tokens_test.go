@@ -0,0 +1,194 @@
+package workers
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// words ABI-encodes each value as one 32-byte word
+func words(values ...uint64) []byte {
+	var out []byte
+	for _, v := range values {
+		out = append(out, common.LeftPadBytes(new(big.Int).SetUint64(v).Bytes(), 32)...)
+	}
+	return out
+}
+
+func addressTopic(addr string) common.Hash {
+	return common.BytesToHash(common.HexToAddress(addr).Bytes())
+}
+
+func TestDecodeUintArray(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		slot   int
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "first of two arrays",
+			data:   words(64, 160, 2, 7, 8, 2, 100, 200),
+			slot:   0,
+			want:   "[7 8]",
+			wantOK: true,
+		},
+		{
+			name:   "second of two arrays",
+			data:   words(64, 160, 2, 7, 8, 2, 100, 200),
+			slot:   1,
+			want:   "[100 200]",
+			wantOK: true,
+		},
+		{
+			name:   "empty array",
+			data:   words(32, 0),
+			want:   "[]",
+			wantOK: true,
+		},
+		{
+			name: "offset past the data",
+			data: words(4096, 1, 5),
+		},
+		{
+			name: "length larger than the data",
+			data: words(32, 1000, 5),
+		},
+		{
+			name: "truncated elements",
+			data: words(32, 3, 5, 6),
+		},
+		{
+			name: "offset beyond uint64",
+			data: append(common.LeftPadBytes(new(big.Int).Lsh(big.NewInt(1), 80).Bytes(), 32), words(0)...),
+		},
+		{
+			name: "no head slot",
+			data: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := decodeUintArray(tt.data, tt.slot)
+			if ok != tt.wantOK {
+				t.Fatalf("decodeUintArray() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && fmt.Sprint(got) != tt.want {
+				t.Errorf("decodeUintArray() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeTokenTransfers(t *testing.T) {
+	const (
+		contract = "0x00000000000000000000000000000000000000c0"
+		operator = "0x0000000000000000000000000000000000000001"
+		alice    = "0x000000000000000000000000000000000000000A"
+		bob      = "0x000000000000000000000000000000000000000b"
+	)
+	from, to := common.HexToAddress(alice).Hex(), common.HexToAddress(bob).Hex()
+
+	type transfer struct {
+		standard, from, to, tokenID, amount string
+		batchIndex                          uint
+	}
+	tests := []struct {
+		name string
+		log  types.Log
+		want []transfer
+	}{
+		{
+			name: "erc20",
+			log: types.Log{
+				Topics: []common.Hash{transferTopic, addressTopic(alice), addressTopic(bob)},
+				Data:   words(1500),
+			},
+			want: []transfer{{standard: standardERC20, from: from, to: to, amount: "1500"}},
+		},
+		{
+			name: "erc721",
+			log: types.Log{
+				Topics: []common.Hash{transferTopic, addressTopic(alice), addressTopic(bob), common.BigToHash(big.NewInt(42))},
+			},
+			want: []transfer{{standard: standardERC721, from: from, to: to, tokenID: "42", amount: "1"}},
+		},
+		{
+			name: "erc1155 single",
+			log: types.Log{
+				Topics: []common.Hash{transferSingleTopic, addressTopic(operator), addressTopic(alice), addressTopic(bob)},
+				Data:   words(7, 3),
+			},
+			want: []transfer{{standard: standardERC1155, from: from, to: to, tokenID: "7", amount: "3"}},
+		},
+		{
+			name: "erc1155 batch",
+			log: types.Log{
+				Topics: []common.Hash{transferBatchTopic, addressTopic(operator), addressTopic(alice), addressTopic(bob)},
+				Data:   words(64, 160, 2, 7, 8, 2, 100, 200),
+			},
+			want: []transfer{
+				{standard: standardERC1155, from: from, to: to, tokenID: "7", amount: "100"},
+				{standard: standardERC1155, from: from, to: to, tokenID: "8", amount: "200", batchIndex: 1},
+			},
+		},
+		{
+			name: "erc1155 batch with mismatched lengths",
+			log: types.Log{
+				Topics: []common.Hash{transferBatchTopic, addressTopic(operator), addressTopic(alice), addressTopic(bob)},
+				Data:   words(64, 160, 2, 7, 8, 1, 100),
+			},
+		},
+		{
+			name: "transfer with a non-standard layout",
+			log: types.Log{
+				Topics: []common.Hash{transferTopic, addressTopic(alice)},
+				Data:   words(1),
+			},
+		},
+		{
+			name: "removed log",
+			log: types.Log{
+				Topics:  []common.Hash{transferTopic, addressTopic(alice), addressTopic(bob)},
+				Data:    words(1),
+				Removed: true,
+			},
+		},
+		{
+			name: "unrelated event",
+			log: types.Log{
+				Topics: []common.Hash{common.HexToHash("0x1234")},
+				Data:   words(1),
+			},
+		},
+	}
+
+	ts := time.Unix(1700000000, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := tt.log
+			l.Address = common.HexToAddress(contract)
+			l.TxHash = common.HexToHash("0xabc")
+			l.BlockNumber = 123
+			l.Index = 9
+
+			transfers := decodeTokenTransfers(&types.Receipt{Logs: []*types.Log{&l}}, ts)
+			got := make([]transfer, len(transfers))
+			for i, tr := range transfers {
+				if tr.Contract != l.Address.Hex() || tr.TxHash != l.TxHash.Hex() || tr.Height != 123 || tr.LogIndex != 9 || !tr.Timestamp.Equal(ts) {
+					t.Errorf("transfer %+v does not carry the log's position", tr)
+				}
+				got[i] = transfer{tr.Standard, tr.From, tr.To, tr.TokenID, string(tr.Amount), tr.BatchIndex}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("decodeTokenTransfers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
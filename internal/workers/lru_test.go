@@ -0,0 +1,119 @@
+package workers
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLRUCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		ops      func(c *lruCache[string, int])
+		want     map[string]int
+		gone     []string
+	}{
+		{
+			name:     "evicts the oldest entry",
+			capacity: 2,
+			ops: func(c *lruCache[string, int]) {
+				c.Add("a", 1)
+				c.Add("b", 2)
+				c.Add("c", 3)
+			},
+			want: map[string]int{"b": 2, "c": 3},
+			gone: []string{"a"},
+		},
+		{
+			name:     "a read makes an entry recent",
+			capacity: 2,
+			ops: func(c *lruCache[string, int]) {
+				c.Add("a", 1)
+				c.Add("b", 2)
+				c.Get("a")
+				c.Add("c", 3)
+			},
+			want: map[string]int{"a": 1, "c": 3},
+			gone: []string{"b"},
+		},
+		{
+			name:     "updating an entry makes it recent without growing the cache",
+			capacity: 2,
+			ops: func(c *lruCache[string, int]) {
+				c.Add("a", 1)
+				c.Add("b", 2)
+				c.Add("a", 10)
+				c.Add("c", 3)
+			},
+			want: map[string]int{"a": 10, "c": 3},
+			gone: []string{"b"},
+		},
+		{
+			name:     "a miss does not change the order",
+			capacity: 2,
+			ops: func(c *lruCache[string, int]) {
+				c.Add("a", 1)
+				c.Add("b", 2)
+				c.Get("x")
+				c.Add("c", 3)
+			},
+			want: map[string]int{"b": 2, "c": 3},
+			gone: []string{"a", "x"},
+		},
+		{
+			name:     "capacity below one holds one entry",
+			capacity: 0,
+			ops: func(c *lruCache[string, int]) {
+				c.Add("a", 1)
+				c.Add("b", 2)
+			},
+			want: map[string]int{"b": 2},
+			gone: []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newLRUCache[string, int](tt.capacity)
+			tt.ops(c)
+			for _, key := range tt.gone {
+				if v, ok := c.Get(key); ok {
+					t.Errorf("Get(%q) = %d, want evicted", key, v)
+				}
+			}
+			for key, want := range tt.want {
+				if v, ok := c.Get(key); !ok || v != want {
+					t.Errorf("Get(%q) = %d, %v, want %d", key, v, ok, want)
+				}
+			}
+			if c.order.Len() != len(c.items) || len(c.items) != len(tt.want) {
+				t.Errorf("cache holds %d entries in the list and %d in the map, want %d", c.order.Len(), len(c.items), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestLRUCacheConcurrent(t *testing.T) {
+	const capacity = 16
+	c := newLRUCache[int, int](capacity)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c.Add(g*1000+i, i)
+				c.Get(i)
+			}
+		}()
+	}
+	wg.Wait()
+	if c.order.Len() != capacity || len(c.items) != capacity {
+		t.Errorf("cache holds %d entries in the list and %d in the map, want %d", c.order.Len(), len(c.items), capacity)
+	}
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		key := el.Value.(*lruEntry[int, int]).key
+		if c.items[key] != el {
+			t.Errorf("key %d: list and map disagree", key)
+		}
+	}
+}
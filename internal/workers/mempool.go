@@ -0,0 +1,65 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/chains"
+	"log"
+	"time"
+)
+
+// followMempool mirrors the node's mempool into pending_transactions until ctx is done:
+// new transactions are stored with their first-seen time and those the node no longer
+// has are removed. Mined ones are also removed by the repository when their block is saved.
+func (w *Worker) followMempool(ctx context.Context, mempool chains.MempoolAdapter) {
+	ticker := time.NewTicker(w.mempoolInterval)
+	defer ticker.Stop()
+
+	log.Printf("%s Mempool tracking started", w.tag)
+	for {
+		if err := w.syncMempool(ctx, mempool); err != nil {
+			log.Printf("%s Mempool sync error: %v", w.tag, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) syncMempool(ctx context.Context, mempool chains.MempoolAdapter) error {
+	stored, err := w.repo.GetPendingHashes(ctx, w.info.Type)
+	if err != nil {
+		return fmt.Errorf("failed to load pending transactions: %w", err)
+	}
+	known := make(map[string]bool, len(stored))
+	for _, hash := range stored {
+		known[hash] = true
+	}
+
+	pending, fresh, err := mempool.FetchMempool(ctx, known)
+	if err != nil {
+		return fmt.Errorf("failed to fetch mempool: %w", err)
+	}
+
+	current := make(map[string]bool, len(pending))
+	for _, hash := range pending {
+		current[hash] = true
+	}
+	var gone []string
+	for _, hash := range stored {
+		if !current[hash] {
+			gone = append(gone, hash)
+		}
+	}
+
+	if err := w.repo.DeletePendingTransactions(ctx, w.info.Type, gone); err != nil {
+		return fmt.Errorf("failed to remove pending transactions: %w", err)
+	}
+	if err := w.repo.SavePendingTransactions(ctx, fresh); err != nil {
+		return fmt.Errorf("failed to save pending transactions: %w", err)
+	}
+	return nil
+}
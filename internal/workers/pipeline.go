@@ -0,0 +1,154 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/model"
+	"indexer/internal/repository"
+	"indexer/internal/supervisor"
+	"sync"
+)
+
+// blockFetcher fetches a single block and its transactions from the node.
+type blockFetcher func(ctx context.Context, height uint64) (*model.Block, []*model.Transaction, error)
+
+type fetchResult struct {
+	height uint64
+	block  *model.Block
+	txs    []*model.Transaction
+	err    error
+}
+
+// syncWindow caps how many blocks a single sync pass fetches, which also bounds how many
+// out-of-order results the committer may have to hold in memory. Both inputs are clamped to
+// at least 1 like in syncRange, so the window is never empty.
+func syncWindow(concurrency, batchSize int) uint64 {
+	return uint64(max(concurrency, 1) * max(batchSize, 1) * 4)
+}
+
+// windowEnd returns the last height of the window that starts at from, capped at to. The
+// window always holds at least from itself, so a loop advancing past it always makes progress.
+func windowEnd(from, to, window uint64) uint64 {
+	window = max(window, 1)
+	if to-from >= window {
+		return from + window - 1
+	}
+	return to
+}
+
+// syncRange fetches heights from..to with `concurrency` parallel fetchers and commits them
+// strictly in height order, batchSize blocks per DB transaction. It stops at the first fetch
+// error or broken parent link after committing everything before it. reorg is true when the
+// first block does not build on the block we have stored, so the caller can roll back.
+func syncRange(ctx context.Context, repo repository.Repository, from, to uint64, concurrency, batchSize int, fetch blockFetcher) (reorg bool, err error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 1. Fan out heights to the fetchers
+	heights := make(chan uint64)
+	go func() {
+		defer close(heights)
+		for h := from; h <= to; h++ {
+			select {
+			case heights <- h:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make(chan fetchResult, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		supervisor.Spawn(ctx, func() {
+			defer wg.Done()
+			for h := range heights {
+				block, txs, err := fetch(ctx, h)
+				select {
+				case results <- fetchResult{height: h, block: block, txs: txs, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// 2. Commit in height order, buffering anything that arrives early
+	var batch []repository.BlockWithTransactions
+	commit := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		var err error
+		if len(batch) == 1 {
+			err = repo.SaveBlockWithTransactions(ctx, batch[0].Block, batch[0].Txs)
+		} else {
+			err = repo.SaveBlocks(ctx, batch)
+		}
+		if err != nil {
+			first, last := batch[0].Block.Height, batch[len(batch)-1].Block.Height
+			return fmt.Errorf("failed to save blocks %d-%d: %w", first, last, err)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	pending := make(map[uint64]fetchResult)
+	next := from
+	prevHash := ""
+	for res := range results {
+		if res.err != nil {
+			if err := commit(); err != nil {
+				return false, err
+			}
+			return false, fmt.Errorf("failed to fetch block %d: %w", res.height, res.err)
+		}
+		pending[res.height] = res
+
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+
+			if next == from {
+				reorg, err := isReorg(ctx, repo, r.block)
+				if err != nil {
+					return false, fmt.Errorf("failed to check parent of block %d: %w", next, err)
+				}
+				if reorg {
+					return true, nil
+				}
+			} else if r.block.BlockHash != prevHash {
+				// The chain moved while we were fetching. Keep what links up and let the
+				// next pass compare against the database and roll back if needed.
+				return false, commit()
+			}
+
+			batch = append(batch, repository.BlockWithTransactions{Block: r.block, Txs: r.txs})
+			prevHash = r.block.Hash
+			next++
+
+			if len(batch) >= batchSize {
+				if err := commit(); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+
+	return false, commit()
+}
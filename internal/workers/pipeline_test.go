@@ -0,0 +1,253 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"indexer/internal/model"
+	"indexer/internal/repository"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRepo stores block hashes in memory and records what the pipeline saves. Methods the
+// tests do not expect panic through the nil embedded interface.
+type fakeRepo struct {
+	repository.Repository
+
+	mu        sync.Mutex
+	hashes    map[uint64]string
+	saved     []uint64
+	batches   []int
+	saveErr   error
+	rollbacks []uint64
+}
+
+func newFakeRepo(hashes map[uint64]string) *fakeRepo {
+	if hashes == nil {
+		hashes = map[uint64]string{}
+	}
+	return &fakeRepo{hashes: hashes}
+}
+
+func (r *fakeRepo) GetBlockHash(ctx context.Context, chain model.ChainType, height uint64) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.hashes[height], nil
+}
+
+func (r *fakeRepo) SaveBlockWithTransactions(ctx context.Context, block *model.Block, txs []*model.Transaction) error {
+	return r.SaveBlocks(ctx, []repository.BlockWithTransactions{{Block: block, Txs: txs}})
+}
+
+func (r *fakeRepo) SaveBlocks(ctx context.Context, batch []repository.BlockWithTransactions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	for _, b := range batch {
+		r.hashes[b.Block.Height] = b.Block.Hash
+		r.saved = append(r.saved, b.Block.Height)
+	}
+	r.batches = append(r.batches, len(batch))
+	return nil
+}
+
+func (r *fakeRepo) RollbackToHeight(ctx context.Context, chain model.ChainType, height uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rollbacks = append(r.rollbacks, height)
+	for h := range r.hashes {
+		if h > height {
+			delete(r.hashes, h)
+		}
+	}
+	return nil
+}
+
+func blockHash(height uint64) string {
+	return fmt.Sprintf("h%d", height)
+}
+
+// chainFetcher serves a linear chain of blocks after a random delay, so results reach the
+// committer out of order. parents overrides the parent hash of single heights and fail
+// makes a height return an error.
+func chainFetcher(parents map[uint64]string, fail map[uint64]bool) blockFetcher {
+	return func(ctx context.Context, height uint64) (*model.Block, []*model.Transaction, error) {
+		time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+		if fail[height] {
+			return nil, nil, errors.New("node unavailable")
+		}
+		parent, ok := parents[height]
+		if !ok && height > 0 {
+			parent = blockHash(height - 1)
+		}
+		return &model.Block{Chain: model.ChainBTC, Height: height, Hash: blockHash(height), BlockHash: parent}, nil, nil
+	}
+}
+
+func heightRange(from, to uint64) []uint64 {
+	var res []uint64
+	for h := from; h <= to; h++ {
+		res = append(res, h)
+	}
+	return res
+}
+
+func TestSyncRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		batchSize   int
+		parents     map[uint64]string
+		fail        map[uint64]bool
+		saveErr     error
+		wantSaved   []uint64
+		wantReorg   bool
+		wantErr     string
+	}{
+		{
+			name:        "commits in height order",
+			concurrency: 4,
+			batchSize:   3,
+			wantSaved:   heightRange(1, 20),
+		},
+		{
+			name:        "clamps non-positive settings",
+			concurrency: 0,
+			batchSize:   -1,
+			wantSaved:   heightRange(1, 20),
+		},
+		{
+			name:        "stops at a fetch error after committing what came before",
+			concurrency: 1,
+			batchSize:   4,
+			fail:        map[uint64]bool{7: true},
+			wantSaved:   heightRange(1, 6),
+			wantErr:     "failed to fetch block 7",
+		},
+		{
+			name:        "reports a reorg when the first block does not build on ours",
+			concurrency: 4,
+			batchSize:   3,
+			parents:     map[uint64]string{1: "other"},
+			wantReorg:   true,
+		},
+		{
+			name:        "keeps what links up when the chain moves mid-range",
+			concurrency: 4,
+			batchSize:   3,
+			parents:     map[uint64]string{9: "other"},
+			wantSaved:   heightRange(1, 8),
+		},
+		{
+			name:        "returns save errors",
+			concurrency: 2,
+			batchSize:   5,
+			saveErr:     errors.New("disk full"),
+			wantErr:     "disk full",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo(map[uint64]string{0: blockHash(0)})
+			repo.saveErr = tt.saveErr
+
+			reorg, err := syncRange(context.Background(), repo, 1, 20, tt.concurrency, tt.batchSize, chainFetcher(tt.parents, tt.fail))
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("syncRange() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("syncRange() error = %v, want %q", err, tt.wantErr)
+			}
+			if reorg != tt.wantReorg {
+				t.Errorf("syncRange() reorg = %v, want %v", reorg, tt.wantReorg)
+			}
+			if fmt.Sprint(repo.saved) != fmt.Sprint(tt.wantSaved) {
+				t.Errorf("saved %v, want %v", repo.saved, tt.wantSaved)
+			}
+			for _, n := range repo.batches {
+				if n > max(tt.batchSize, 1) {
+					t.Errorf("batch of %d blocks, want at most %d", n, tt.batchSize)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncWindow(t *testing.T) {
+	tests := []struct {
+		concurrency, batchSize int
+		want                   uint64
+	}{
+		{4, 10, 160},
+		{1, 1, 4},
+		{0, 10, 40},
+		{4, 0, 16},
+		{-3, -3, 4},
+	}
+	for _, tt := range tests {
+		if got := syncWindow(tt.concurrency, tt.batchSize); got != tt.want {
+			t.Errorf("syncWindow(%d, %d) = %d, want %d", tt.concurrency, tt.batchSize, got, tt.want)
+		}
+	}
+}
+
+func TestWindowEnd(t *testing.T) {
+	tests := []struct {
+		from, to, window uint64
+		want             uint64
+	}{
+		{from: 10, to: 100, window: 20, want: 29},
+		{from: 10, to: 15, window: 20, want: 15},
+		{from: 10, to: 29, window: 20, want: 29},
+		{from: 10, to: 30, window: 20, want: 29},
+		{from: 10, to: 10, window: 20, want: 10},
+		{from: 10, to: 100, window: 0, want: 10},
+	}
+	for _, tt := range tests {
+		if got := windowEnd(tt.from, tt.to, tt.window); got != tt.want {
+			t.Errorf("windowEnd(%d, %d, %d) = %d, want %d", tt.from, tt.to, tt.window, got, tt.want)
+		}
+	}
+}
+
+func TestFetchBlocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		heights []uint64
+		fail    map[uint64]bool
+		wantErr string
+	}{
+		{name: "keeps the requested order", heights: []uint64{5, 3, 9, 4, 12, 1}},
+		{name: "no heights", heights: nil},
+		{name: "fails on an error", heights: heightRange(1, 10), fail: map[uint64]bool{6: true}, wantErr: "failed to fetch block 6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := fetchBlocks(context.Background(), tt.heights, 3, chainFetcher(nil, tt.fail))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("fetchBlocks() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetchBlocks() error = %v", err)
+			}
+			if len(blocks) != len(tt.heights) {
+				t.Fatalf("got %d blocks, want %d", len(blocks), len(tt.heights))
+			}
+			for i, h := range tt.heights {
+				if blocks[i].Block.Height != h {
+					t.Errorf("blocks[%d] has height %d, want %d", i, blocks[i].Block.Height, h)
+				}
+			}
+		})
+	}
+}
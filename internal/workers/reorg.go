@@ -0,0 +1,73 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/model"
+	"indexer/internal/repository"
+	"log"
+)
+
+// maxReorgDepth bounds how far back we walk looking for a common ancestor.
+// Anything deeper than this needs operator attention rather than an automatic rollback.
+const maxReorgDepth = 100
+
+// hashFetcher returns the canonical block hash at a height according to the node.
+type hashFetcher func(ctx context.Context, height uint64) (string, error)
+
+// isReorg reports whether block does not build on the hash we stored at height-1.
+// If we never indexed height-1 (e.g. the first block after the start height) there is nothing to compare.
+func isReorg(ctx context.Context, repo repository.Repository, block *model.Block) (bool, error) {
+	if block.Height == 0 {
+		return false, nil
+	}
+	storedParent, err := repo.GetBlockHash(ctx, block.Chain, block.Height-1)
+	if err != nil {
+		return false, err
+	}
+	if storedParent == "" {
+		return false, nil
+	}
+	return storedParent != block.BlockHash, nil
+}
+
+// handleReorg walks back from height until the stored hash matches the canonical one,
+// then rolls the database back to that common ancestor. The next sync re-indexes the
+// canonical branch from ancestor+1.
+func handleReorg(ctx context.Context, repo repository.Repository, chain model.ChainType, height uint64, canonicalHash hashFetcher) (uint64, error) {
+	ancestor := height
+	for depth := 0; ; depth++ {
+		if depth >= maxReorgDepth {
+			return 0, fmt.Errorf("no common ancestor within %d blocks of %d", maxReorgDepth, height)
+		}
+
+		stored, err := repo.GetBlockHash(ctx, chain, ancestor)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read stored hash at %d: %w", ancestor, err)
+		}
+		// Nothing stored below this point, so there is nothing older to orphan.
+		if stored == "" {
+			break
+		}
+
+		canonical, err := canonicalHash(ctx, ancestor)
+		if err != nil {
+			return 0, fmt.Errorf("failed to fetch canonical hash at %d: %w", ancestor, err)
+		}
+		if stored == canonical {
+			break
+		}
+
+		// Every chain shares its genesis block, so a different one is not a reorg
+		if ancestor == 0 {
+			return 0, fmt.Errorf("stored genesis block %s differs from the node's %s: the node is on another network or the database belongs to another chain", stored, canonical)
+		}
+		ancestor--
+	}
+
+	log.Printf("[%s] Reorg detected at height %d, rolling back to common ancestor %d", chain, height+1, ancestor)
+	if err := repo.RollbackToHeight(ctx, chain, ancestor); err != nil {
+		return 0, fmt.Errorf("failed to roll back to %d: %w", ancestor, err)
+	}
+	return ancestor, nil
+}
@@ -0,0 +1,138 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"indexer/internal/model"
+	"strings"
+	"testing"
+)
+
+func TestIsReorg(t *testing.T) {
+	repo := newFakeRepo(map[uint64]string{9: "h9"})
+	tests := []struct {
+		name  string
+		block *model.Block
+		want  bool
+	}{
+		{name: "builds on stored parent", block: &model.Block{Height: 10, BlockHash: "h9"}, want: false},
+		{name: "different parent", block: &model.Block{Height: 10, BlockHash: "x9"}, want: true},
+		{name: "parent not stored", block: &model.Block{Height: 20, BlockHash: "x19"}, want: false},
+		{name: "genesis", block: &model.Block{Height: 0}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isReorg(context.Background(), repo, tt.block)
+			if err != nil {
+				t.Fatalf("isReorg() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("isReorg() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleReorg(t *testing.T) {
+	// stored holds h0..h<tip> as we indexed them; the node's chain forks after forkAt
+	stored := func(from, tip uint64) map[uint64]string {
+		m := map[uint64]string{}
+		for h := from; h <= tip; h++ {
+			m[h] = blockHash(h)
+		}
+		return m
+	}
+	forkedAfter := func(forkAt uint64) hashFetcher {
+		return func(ctx context.Context, height uint64) (string, error) {
+			if height > forkAt {
+				return fmt.Sprintf("fork%d", height), nil
+			}
+			return blockHash(height), nil
+		}
+	}
+
+	tests := []struct {
+		name         string
+		stored       map[uint64]string
+		height       uint64
+		canonical    hashFetcher
+		wantAncestor uint64
+		wantErr      string
+	}{
+		{
+			name:         "rolls back to the common ancestor",
+			stored:       stored(0, 10),
+			height:       10,
+			canonical:    forkedAfter(6),
+			wantAncestor: 6,
+		},
+		{
+			name:         "only the tip was replaced",
+			stored:       stored(0, 10),
+			height:       10,
+			canonical:    forkedAfter(9),
+			wantAncestor: 9,
+		},
+		{
+			name:         "stops below the first stored block",
+			stored:       stored(5, 10),
+			height:       10,
+			canonical:    forkedAfter(0),
+			wantAncestor: 4,
+		},
+		{
+			name:         "walks back to genesis",
+			stored:       stored(0, 10),
+			height:       10,
+			canonical:    forkedAfter(0),
+			wantAncestor: 0,
+		},
+		{
+			name:      "different genesis block",
+			stored:    stored(0, 10),
+			height:    10,
+			canonical: func(ctx context.Context, height uint64) (string, error) { return "other", nil },
+			wantErr:   "stored genesis block h0 differs from the node's other",
+		},
+		{
+			name:      "gives up beyond the maximum depth",
+			stored:    stored(0, maxReorgDepth+50),
+			height:    maxReorgDepth + 50,
+			canonical: forkedAfter(10),
+			wantErr:   "no common ancestor",
+		},
+		{
+			name:      "canonical hash unavailable",
+			stored:    stored(0, 10),
+			height:    10,
+			canonical: func(ctx context.Context, height uint64) (string, error) { return "", errors.New("timeout") },
+			wantErr:   "failed to fetch canonical hash at 10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo(tt.stored)
+			ancestor, err := handleReorg(context.Background(), repo, model.ChainBTC, tt.height, tt.canonical)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("handleReorg() error = %v, want %q", err, tt.wantErr)
+				}
+				if len(repo.rollbacks) != 0 {
+					t.Errorf("rolled back to %v after an error", repo.rollbacks)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("handleReorg() error = %v", err)
+			}
+			if ancestor != tt.wantAncestor {
+				t.Errorf("handleReorg() = %d, want %d", ancestor, tt.wantAncestor)
+			}
+			if len(repo.rollbacks) != 1 || repo.rollbacks[0] != tt.wantAncestor {
+				t.Errorf("rollbacks = %v, want [%d]", repo.rollbacks, tt.wantAncestor)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package workers
+
+import (
+	"context"
+	"indexer/internal/verify"
+	"log"
+	"time"
+)
+
+// followVerify checks the indexed chain every verifyInterval until ctx is done, queueing
+// repairs when enabled
+func (w *Worker) followVerify(ctx context.Context) {
+	ticker := time.NewTicker(w.verifyInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if w.paused.Load() {
+			continue
+		}
+		if _, err := w.Verify(ctx, verify.Options{Repair: w.verifyRepair}); err != nil && ctx.Err() == nil {
+			log.Printf("%s Verification failed: %v", w.tag, err)
+		}
+	}
+}
+
+// Verify checks the chain's indexed blocks now and keeps the report for LastVerifyReport
+func (w *Worker) Verify(ctx context.Context, opts verify.Options) (*verify.Report, error) {
+	report, err := verify.Run(ctx, w.repo, w.info, opts)
+	if report == nil {
+		return nil, err
+	}
+
+	if report.OK() {
+		log.Printf("%s Verification passed: %s", w.tag, report.Summary())
+	} else {
+		log.Printf("%s Verification found problems: %s", w.tag, report.Summary())
+	}
+	if len(report.RepairJobs) > 0 {
+		select {
+		case w.backfillWake <- struct{}{}:
+		default:
+		}
+	}
+
+	w.verifyMu.Lock()
+	w.lastVerify = report
+	w.verifyMu.Unlock()
+	return report, err
+}
+
+// LastVerifyReport returns the most recent verification report, or nil
+func (w *Worker) LastVerifyReport() *verify.Report {
+	w.verifyMu.Lock()
+	defer w.verifyMu.Unlock()
+	return w.lastVerify
+}
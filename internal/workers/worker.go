@@ -0,0 +1,279 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"indexer/internal/chains"
+	"indexer/internal/repository"
+	"indexer/internal/supervisor"
+	"indexer/internal/verify"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SyncOptions are the per-chain knobs of the generic sync loop
+type SyncOptions struct {
+	StartHeight    int
+	SyncIntervalMS int
+	Concurrency    int
+	BatchSize      int
+
+	// MempoolIntervalMS enables mempool tracking on adapters supporting it when positive
+	MempoolIntervalMS int
+
+	// VerifyIntervalMS enables periodic integrity checks when positive; VerifyRepair
+	// queues re-fetches for what they find
+	VerifyIntervalMS int
+	VerifyRepair     bool
+}
+
+const (
+	// notifiedPollInterval is the safety-net poll while block notifications are flowing
+	notifiedPollInterval = 30 * time.Second
+
+	// resubscribeInterval is how long we poll before trying notifications again
+	resubscribeInterval = 30 * time.Second
+
+	// maxSyncFailures consecutive failed passes make the worker give up and be restarted
+	maxSyncFailures = 10
+)
+
+// prepare runs the adapter's one-time setup and creates the indexer state from the tip
+func (w *Worker) prepare(ctx context.Context) error {
+	if init, ok := w.adapter.(initializer); ok {
+		if err := init.Init(ctx); err != nil {
+			return fmt.Errorf("adapter initialization: %w", err)
+		}
+	}
+
+	tip, err := w.adapter.GetTip(ctx)
+	if err != nil {
+		return fmt.Errorf("RPC connection: %w", err)
+	}
+	log.Printf("%s RPC connection validated. Current tip: %d", w.tag, tip)
+
+	if _, err := w.repo.GetOrCreateState(ctx, w.info.Type, tip, w.startHeight); err != nil {
+		return fmt.Errorf("initializing state: %w", err)
+	}
+	return nil
+}
+
+// initializer is implemented by adapters that need to talk to the node once before syncing
+type initializer interface {
+	Init(ctx context.Context) error
+}
+
+// Worker drives any chains.Adapter: it follows the tip, fetches blocks through the
+// pipeline, and rolls back on reorgs.
+type Worker struct {
+	repo         repository.Repository
+	adapter      chains.Adapter
+	info         chains.Info
+	tag          string
+	startHeight  int
+	syncInterval time.Duration
+	concurrency  int
+	batchSize    int
+
+	mempoolInterval time.Duration
+
+	// operator commands, run between sync passes while the sync loop is running; paused
+	// outlives restarts
+	commands chan command
+	running  atomic.Bool
+	paused   atomic.Bool
+
+	// signalled when a backfill job is queued
+	backfillWake chan struct{}
+
+	verifyInterval time.Duration
+	verifyRepair   bool
+	verifyMu       sync.Mutex
+	lastVerify     *verify.Report
+}
+
+func NewWorker(repo repository.Repository, adapter chains.Adapter, opts SyncOptions) *Worker {
+	info := adapter.Info()
+	return &Worker{
+		repo:         repo,
+		adapter:      adapter,
+		info:         info,
+		tag:          "[" + strings.ToUpper(info.Name) + "]",
+		startHeight:  opts.StartHeight,
+		syncInterval: time.Duration(opts.SyncIntervalMS) * time.Millisecond,
+		concurrency:  opts.Concurrency,
+		batchSize:    opts.BatchSize,
+
+		mempoolInterval: time.Duration(opts.MempoolIntervalMS) * time.Millisecond,
+		commands:        make(chan command),
+		backfillWake:    make(chan struct{}, 1),
+		verifyInterval:  time.Duration(opts.VerifyIntervalMS) * time.Millisecond,
+		verifyRepair:    opts.VerifyRepair,
+	}
+}
+
+func (w *Worker) Info() chains.Info {
+	return w.info
+}
+
+// Run indexes the chain until ctx is done. It returns an error when the node cannot be
+// reached at startup or syncing keeps failing, for the supervisor to restart it.
+func (w *Worker) Run(ctx context.Context, t *supervisor.Tracker) error {
+	log.Printf("%s Worker starting...", w.tag)
+	t.Set(supervisor.StateStarting)
+
+	// Background goroutines end with this run
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 1. Reach the node and ensure state exists
+	if err := w.prepare(ctx); err != nil {
+		return err
+	}
+
+	if err := w.queueStartBackfill(ctx); err != nil {
+		log.Printf("%s Failed to queue backfill below the configured start height: %v", w.tag, err)
+	}
+	// A panic in any of them makes this run return it, so the supervisor restarts us
+	supervisor.Spawn(ctx, func() { w.runBackfills(ctx) })
+	if w.verifyInterval > 0 {
+		supervisor.Spawn(ctx, func() { w.followVerify(ctx) })
+	}
+
+	if mempool, ok := w.adapter.(chains.MempoolAdapter); ok && w.mempoolInterval > 0 {
+		supervisor.Spawn(ctx, func() { w.followMempool(ctx, mempool) })
+	}
+
+	notifier, _ := w.adapter.(chains.HeadNotifier)
+	var heads <-chan struct{}
+	var nextSubscribe time.Time
+
+	log.Printf("%s Worker sync loop started", w.tag)
+	w.running.Store(true)
+	defer w.running.Store(false)
+	failures := 0
+	for {
+		if w.paused.Load() {
+			t.Set(supervisor.StatePaused)
+			select {
+			case <-ctx.Done():
+				log.Printf("%s Worker stopping...", w.tag)
+				return nil
+			case cmd := <-w.commands:
+				w.handle(ctx, cmd)
+			}
+			continue
+		}
+
+		caughtUp, err := w.sync(ctx, t)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("%s Sync error: %v", w.tag, err)
+			t.Fail(err)
+			failures++
+			if failures >= maxSyncFailures {
+				return fmt.Errorf("%d consecutive sync failures, last: %w", failures, err)
+			}
+		case caughtUp:
+			failures = 0
+			t.Set(supervisor.StateAtTip)
+		default:
+			failures = 0
+			t.Set(supervisor.StateSyncing)
+		}
+
+		// Behind the tip: go straight into the next pass instead of waiting
+		if err == nil && !caughtUp {
+			select {
+			case <-ctx.Done():
+				log.Printf("%s Worker stopping...", w.tag)
+				return nil
+			case cmd := <-w.commands:
+				w.handle(ctx, cmd)
+				continue
+			default:
+				continue
+			}
+		}
+
+		// At the tip: wait for the node to push a new block, or poll when it cannot
+		if notifier != nil && heads == nil && time.Now().After(nextSubscribe) {
+			heads, err = notifier.SubscribeHeads(ctx)
+			switch {
+			case err != nil:
+				log.Printf("%s Block notifications unavailable, polling: %v", w.tag, err)
+				nextSubscribe = time.Now().Add(resubscribeInterval)
+			case heads == nil:
+				notifier = nil // not configured for push
+			default:
+				log.Printf("%s Following block notifications", w.tag)
+			}
+		}
+
+		wait := w.syncInterval
+		if heads != nil {
+			wait = notifiedPollInterval
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Printf("%s Worker stopping...", w.tag)
+			return nil
+		case cmd := <-w.commands:
+			w.handle(ctx, cmd)
+		case _, ok := <-heads:
+			if !ok {
+				log.Printf("%s Block notifications dropped, falling back to polling", w.tag)
+				heads = nil
+				nextSubscribe = time.Now().Add(resubscribeInterval)
+			}
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// sync indexes the next window of blocks and reports whether we have reached the tip.
+func (w *Worker) sync(ctx context.Context, t *supervisor.Tracker) (bool, error) {
+	tip, err := w.adapter.GetTip(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get tip: %w", err)
+	}
+
+	lastIndexed, err := w.repo.GetOrCreateState(ctx, w.info.Type, tip, w.startHeight)
+	if err != nil {
+		return false, fmt.Errorf("failed to get state: %w", err)
+	}
+
+	t.Progress(lastIndexed, tip)
+	if lastIndexed > tip {
+		log.Printf("%s Node tip %d is behind last indexed block %d, waiting for it to catch up", w.tag, tip, lastIndexed)
+	}
+	if lastIndexed >= tip {
+		return true, nil
+	}
+
+	from := lastIndexed + 1
+	to := tip
+	if window := syncWindow(w.concurrency, w.batchSize); to-lastIndexed > window {
+		to = lastIndexed + window
+	}
+
+	log.Printf("%s Syncing blocks %d-%d / %d", w.tag, from, to, tip)
+
+	reorg, err := syncRange(ctx, w.repo, from, to, w.concurrency, w.batchSize, w.adapter.FetchBlock)
+	if err != nil {
+		return false, err
+	}
+	if reorg {
+		_, err := handleReorg(ctx, w.repo, w.info.Type, lastIndexed, w.adapter.GetBlockHash)
+		return false, err
+	}
+
+	t.Progress(to, tip)
+	return to >= tip, nil
+}
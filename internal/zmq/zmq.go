@@ -0,0 +1,184 @@
+// Package zmq implements the subscriber side of ZMTP 3.0 over TCP with the NULL security
+// mechanism, which is all bitcoind's -zmqpub* notifications need.
+package zmq
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	flagMore    = 0x01
+	flagLong    = 0x02
+	flagCommand = 0x04
+
+	// maxFrameSize guards against a corrupt length allocating unbounded memory
+	maxFrameSize = 16 << 20
+
+	// handshakeTimeout bounds connecting, the ZMTP handshake and subscribing, so a peer that
+	// accepts the connection but never answers cannot stall the caller
+	handshakeTimeout = 10 * time.Second
+)
+
+// Subscriber is a connected SUB socket
+type Subscriber struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Dial connects to a publisher at endpoint, e.g. "tcp://127.0.0.1:28332", and subscribes to
+// the given topics.
+func Dial(ctx context.Context, endpoint string, topics ...string) (*Subscriber, error) {
+	addr, ok := strings.CutPrefix(endpoint, "tcp://")
+	if !ok {
+		return nil, fmt.Errorf("unsupported ZMQ endpoint %q, expected tcp://host:port", endpoint)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Subscriber{conn: conn, r: bufio.NewReader(conn)}
+
+	deadline, _ := ctx.Deadline()
+	if err := s.setup(deadline, topics); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// setup runs the handshake and subscribes to topics, failing once deadline passes. Recv
+// blocks without a deadline afterwards.
+func (s *Subscriber) setup(deadline time.Time, topics []string) error {
+	if err := s.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	if err := s.handshake(); err != nil {
+		return err
+	}
+	for _, topic := range topics {
+		// ZMTP 3.0 subscriptions are plain messages starting with 0x01
+		if err := s.writeFrame(0, append([]byte{1}, topic...)); err != nil {
+			return err
+		}
+	}
+	return s.conn.SetDeadline(time.Time{})
+}
+
+func (s *Subscriber) handshake() error {
+	greeting := make([]byte, 64)
+	greeting[0] = 0xff
+	greeting[9] = 0x7f
+	greeting[10] = 3 // version 3.0
+	copy(greeting[12:32], "NULL")
+	if _, err := s.conn.Write(greeting); err != nil {
+		return err
+	}
+
+	peer := make([]byte, 64)
+	if _, err := io.ReadFull(s.r, peer); err != nil {
+		return fmt.Errorf("reading greeting: %w", err)
+	}
+	if peer[0] != 0xff || peer[9] != 0x7f || peer[10] < 3 {
+		return fmt.Errorf("peer does not speak ZMTP 3")
+	}
+	if mechanism := strings.TrimRight(string(peer[12:32]), "\x00"); mechanism != "NULL" {
+		return fmt.Errorf("unsupported security mechanism %q", mechanism)
+	}
+
+	ready := []byte{5}
+	ready = append(ready, "READY"...)
+	ready = append(ready, 11)
+	ready = append(ready, "Socket-Type"...)
+	ready = binary.BigEndian.AppendUint32(ready, 3)
+	ready = append(ready, "SUB"...)
+	if err := s.writeFrame(flagCommand, ready); err != nil {
+		return err
+	}
+
+	flags, body, err := s.readFrame()
+	if err != nil {
+		return fmt.Errorf("reading READY: %w", err)
+	}
+	if flags&flagCommand == 0 || len(body) < 6 || string(body[1:6]) != "READY" {
+		return fmt.Errorf("peer did not answer with READY")
+	}
+	return nil
+}
+
+// Recv blocks until the next message and returns its frames
+func (s *Subscriber) Recv() ([][]byte, error) {
+	var parts [][]byte
+	for {
+		flags, body, err := s.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		// Commands such as PING from newer peers carry no message data
+		if flags&flagCommand != 0 {
+			continue
+		}
+		parts = append(parts, body)
+		if flags&flagMore == 0 {
+			return parts, nil
+		}
+	}
+}
+
+// Close disconnects, making a blocked Recv return an error
+func (s *Subscriber) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Subscriber) writeFrame(flags byte, body []byte) error {
+	var header []byte
+	if len(body) > 255 {
+		header = binary.BigEndian.AppendUint64([]byte{flags | flagLong}, uint64(len(body)))
+	} else {
+		header = []byte{flags, byte(len(body))}
+	}
+	_, err := s.conn.Write(append(header, body...))
+	return err
+}
+
+func (s *Subscriber) readFrame() (byte, []byte, error) {
+	flags, err := s.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var size uint64
+	if flags&flagLong != 0 {
+		var buf [8]byte
+		if _, err := io.ReadFull(s.r, buf[:]); err != nil {
+			return 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(buf[:])
+	} else {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		size = uint64(b)
+	}
+	if size > maxFrameSize {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds limit", size)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(s.r, body); err != nil {
+		return 0, nil, err
+	}
+	return flags, body, nil
+}
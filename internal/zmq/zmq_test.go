@@ -0,0 +1,220 @@
+package zmq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// frame encodes one ZMTP frame the way a publisher would
+func frame(flags byte, body []byte) []byte {
+	if len(body) > 255 {
+		return append(binary.BigEndian.AppendUint64([]byte{flags | flagLong}, uint64(len(body))), body...)
+	}
+	return append([]byte{flags, byte(len(body))}, body...)
+}
+
+func concat(frames ...[]byte) []byte {
+	return bytes.Join(frames, nil)
+}
+
+func TestRecv(t *testing.T) {
+	long := bytes.Repeat([]byte{0xab}, 300)
+	tests := []struct {
+		name    string
+		input   []byte
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "single frame",
+			input: frame(0, []byte("hello")),
+			want:  []string{"hello"},
+		},
+		{
+			name: "multipart message",
+			input: concat(
+				frame(flagMore, []byte("hashblock")),
+				frame(flagMore, []byte{0x01, 0x02}),
+				frame(0, []byte{0, 0, 0, 1}),
+			),
+			want: []string{"hashblock", "\x01\x02", "\x00\x00\x00\x01"},
+		},
+		{
+			name:  "long frame",
+			input: frame(0, long),
+			want:  []string{string(long)},
+		},
+		{
+			name:  "empty frame",
+			input: frame(0, nil),
+			want:  []string{""},
+		},
+		{
+			name:  "commands are skipped",
+			input: concat(frame(flagCommand, []byte("\x04PING")), frame(0, []byte("after"))),
+			want:  []string{"after"},
+		},
+		{
+			name:    "oversized frame",
+			input:   binary.BigEndian.AppendUint64([]byte{flagLong}, maxFrameSize+1),
+			wantErr: "exceeds limit",
+		},
+		{
+			name:    "truncated body",
+			input:   []byte{0, 10, 'a', 'b'},
+			wantErr: io.ErrUnexpectedEOF.Error(),
+		},
+		{
+			name:    "message cut after a more flag",
+			input:   frame(flagMore, []byte("part")),
+			wantErr: io.EOF.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Subscriber{r: bufio.NewReader(bytes.NewReader(tt.input))}
+			parts, err := s.Recv()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Recv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Recv() error = %v", err)
+			}
+			got := make([]string, len(parts))
+			for i, p := range parts {
+				got[i] = string(p)
+			}
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+				t.Errorf("Recv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFrame(t *testing.T) {
+	for _, size := range []int{0, 1, 255, 256, 70000} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+
+			body := bytes.Repeat([]byte{'x'}, size)
+			go (&Subscriber{conn: client}).writeFrame(flagCommand, body)
+
+			s := &Subscriber{r: bufio.NewReader(server)}
+			flags, got, err := s.readFrame()
+			if err != nil {
+				t.Fatalf("readFrame() error = %v", err)
+			}
+			if flags&flagCommand == 0 || (flags&flagLong != 0) != (size > 255) {
+				t.Errorf("flags = %#x for a %d-byte command", flags, size)
+			}
+			if !bytes.Equal(got, body) {
+				t.Errorf("body of %d bytes, want %d", len(got), size)
+			}
+		})
+	}
+}
+
+// publisher accepts one subscriber, completes the handshake and sends it msg
+func publisher(t *testing.T, ln net.Listener, msg []byte) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	greeting := make([]byte, 64)
+	greeting[0], greeting[9], greeting[10] = 0xff, 0x7f, 3
+	copy(greeting[12:32], "NULL")
+	conn.Write(greeting)
+	if _, err := io.ReadFull(r, make([]byte, 64)); err != nil {
+		t.Errorf("reading greeting: %v", err)
+		return
+	}
+
+	peer := &Subscriber{conn: conn, r: r}
+	if _, _, err := peer.readFrame(); err != nil {
+		t.Errorf("reading READY: %v", err)
+		return
+	}
+	peer.writeFrame(flagCommand, []byte("\x05READY"))
+	if _, sub, err := peer.readFrame(); err != nil || string(sub) != "\x01hashblock" {
+		t.Errorf("subscription = %q, %v", sub, err)
+		return
+	}
+	conn.Write(msg)
+	time.Sleep(100 * time.Millisecond)
+}
+
+func TestDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer ln.Close()
+	go publisher(t, ln, concat(frame(flagMore, []byte("hashblock")), frame(0, []byte{0xaa})))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := Dial(ctx, "tcp://"+ln.Addr().String(), "hashblock")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer sub.Close()
+
+	parts, err := sub.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error = %v", err)
+	}
+	if len(parts) != 2 || string(parts[0]) != "hashblock" {
+		t.Errorf("Recv() = %q", parts)
+	}
+}
+
+func TestDialSilentPeer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer ln.Close()
+	// Accept the connection and never answer the greeting
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(2 * time.Second)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := Dial(ctx, "tcp://"+ln.Addr().String(), "hashblock"); err == nil {
+		t.Fatal("Dial() succeeded against a silent peer")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Dial() took %s, want it bounded by the deadline", elapsed)
+	}
+}
+
+func TestDialRejectsEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"ipc:///tmp/zmq", "127.0.0.1:28332", ""} {
+		if _, err := Dial(context.Background(), endpoint); err == nil || !strings.Contains(err.Error(), "unsupported ZMQ endpoint") {
+			t.Errorf("Dial(%q) error = %v", endpoint, err)
+		}
+	}
+}